@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	activityDocument = "activity"
+	readTouchPeriod  = time.Hour
+)
+
+//Activity tracks when a project was last bumped and read
+type Activity struct {
+	TrackedSince time.Time  `json:"trackedSince"`
+	LastBump     *time.Time `json:"lastBump,omitempty"`
+	LastRead     *time.Time `json:"lastRead,omitempty"`
+}
+
+//LastActivity returns the time of the last bump or read, or the start of tracking if there was none
+func (activity *Activity) LastActivity() time.Time {
+	last := activity.TrackedSince
+	if activity.LastBump != nil && activity.LastBump.After(last) {
+		last = *activity.LastBump
+	}
+	if activity.LastRead != nil && activity.LastRead.After(last) {
+		last = *activity.LastRead
+	}
+
+	return last
+}
+
+//IsStale returns true, if the project was untouched for the given age, projects without activity are never stale
+func (activity *Activity) IsStale(now time.Time, age time.Duration) bool {
+	return activity != nil && now.Sub(activity.LastActivity()) >= age
+}
+
+//parseAge parses a duration, additionally accepting days like "90d"
+func parseAge(text string) (time.Duration, error) {
+	if strings.HasSuffix(text, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(text, "d"))
+		if err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+
+	age, err := time.ParseDuration(text)
+	if err != nil || age <= 0 {
+		return 0, errors.Errorf("%v is not a valid age", text)
+	}
+
+	return age, nil
+}
+
+//GetActivity returns the activity of the given project or nil, if it was never tracked
+func (v *Version) GetActivity(project string) (*Activity, error) {
+	document, err := v.fileProvider.ReadDocument(activityDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get activity for project %v", project)
+	}
+	if document == nil {
+		return nil, nil
+	}
+
+	activity := &Activity{}
+	if err := json.Unmarshal(document, activity); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse activity for project %v", project)
+	}
+
+	return activity, nil
+}
+
+//touch records a bump or a read on the given project, reads are only recorded once per period
+func (v *Version) touch(project string, bumped bool) error {
+	activity, err := v.GetActivity(project)
+	if err != nil {
+		return err
+	}
+
+	now := v.now().UTC()
+	if activity == nil {
+		activity = &Activity{TrackedSince: now}
+	}
+	if bumped {
+		activity.LastBump = &now
+	} else {
+		if activity.LastRead != nil && now.Sub(*activity.LastRead) < readTouchPeriod {
+			return nil
+		}
+		activity.LastRead = &now
+	}
+
+	return v.storeActivity(project, activity)
+}
+
+func (v *Version) storeActivity(project string, activity *Activity) error {
+	document, err := json.Marshal(activity)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode activity for project %v", project)
+	}
+
+	err = v.fileProvider.StoreDocument(activityDocument, project, document)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot store activity for project %v", project)
+	}
+
+	return nil
+}
+
+//CollectGarbage archives all projects of all namespaces untouched for the given age and returns their names
+func (v *Version) CollectGarbage(age time.Duration) ([]string, error) {
+	archived, err := v.collectGarbage(age, "")
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := v.fileProvider.ListNamespaces()
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list namespaces")
+	}
+	for _, namespace := range namespaces {
+		namespaced, err := v.Namespace(namespace)
+		if err != nil {
+			return nil, err
+		}
+		collected, err := namespaced.collectGarbage(age, namespace+"/")
+		if err != nil {
+			return nil, err
+		}
+		archived = append(archived, collected...)
+	}
+
+	return archived, nil
+}
+
+func (v *Version) collectGarbage(age time.Duration, prefix string) ([]string, error) {
+	projects, err := v.ListProjects(ProjectFilter{StaleFor: age})
+	if err != nil {
+		return nil, err
+	}
+
+	archived := []string{}
+	for _, project := range projects {
+		if err := v.Archive(project.Name); err != nil {
+			return nil, err
+		}
+		archived = append(archived, prefix+project.Name)
+	}
+
+	// projects created before activity tracking start to age from now on
+	untracked, err := v.Projects()
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range untracked {
+		activity, err := v.GetActivity(project)
+		if err != nil {
+			return nil, err
+		}
+		if activity == nil {
+			if err := v.storeActivity(project, &Activity{TrackedSince: v.now().UTC()}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return archived, nil
+}
+
+//StartGarbageCollection archives stale projects periodically in the background
+func (handler *Handler) StartGarbageCollection(interval time.Duration, age time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			archived, err := handler.version.CollectGarbage(age)
+			if err != nil {
+				handler.logger.Error(err)
+				continue
+			}
+			if len(archived) > 0 {
+				handler.auditLog().Infof("archived stale projects %v", archived)
+			}
+		}
+	}()
+}
+
+//OnGarbageCollection is a handler for archiving all projects untouched for the requested age
+func (handler *Handler) OnGarbageCollection(context *gin.Context) {
+	age, err := parseAge(context.DefaultQuery("age", handler.gcAge))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	archived, err := handler.version.CollectGarbage(age)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.auditLog().Infof("archived stale projects %v", archived)
+	context.JSON(http.StatusOK, gin.H{"archived": archived})
+}
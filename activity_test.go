@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Parse_Age(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	days, _ := parseAge("90d")
+	hours, _ := parseAge("36h")
+	_, err := parseAge("soon")
+
+	Ω.Expect(days).To(Equal(90 * 24 * time.Hour))
+	Ω.Expect(hours).To(Equal(36 * time.Hour))
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Activity_Tracks_Bumps_And_Reads(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	version.now = func() time.Time { return now }
+
+	_, _ = version.BumpPatch("p1")
+	now = now.Add(time.Minute)
+	_, _ = version.GetVersion("p1")
+	activity, _ := version.GetActivity("p1")
+
+	Ω.Expect(activity.TrackedSince).To(Equal(now.Add(-time.Minute)))
+	Ω.Expect(*activity.LastBump).To(Equal(now.Add(-time.Minute)))
+	Ω.Expect(*activity.LastRead).To(Equal(now))
+}
+
+func Test_List_Stale_Projects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	version.now = func() time.Time { return now }
+
+	_, _ = version.BumpPatch("old")
+	now = now.Add(100 * 24 * time.Hour)
+	_, _ = version.BumpPatch("new")
+	stale, _ := version.ListProjects(ProjectFilter{StaleFor: 90 * 24 * time.Hour})
+
+	Ω.Expect(stale).To(HaveLen(1))
+	Ω.Expect(stale[0].Name).To(Equal("old"))
+}
+
+func Test_Garbage_Collection_Archives_Stale_Projects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0", "untracked"))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	version.now = func() time.Time { return now }
+	team, _ := version.Namespace("team")
+
+	_, _ = version.BumpPatch("old")
+	_, _ = team.BumpPatch("old")
+	now = now.Add(100 * 24 * time.Hour)
+	_, _ = version.BumpPatch("new")
+	archived, err := version.CollectGarbage(90 * 24 * time.Hour)
+	projects, _ := version.ListProjects(ProjectFilter{})
+	untracked, _ := version.GetActivity("untracked")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(archived).To(Equal([]string{"old", "team/old"}))
+	Ω.Expect(projects).To(HaveLen(2))
+	Ω.Expect(untracked.TrackedSince).To(Equal(now))
+}
+
+func Test_Garbage_Collection_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	tokens := NewTokenStore()
+	tokens.Add("ci", "ci-secret", "ns:team")
+	tokens.Add("ops", "admin-secret", "*", "admin")
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0", "p1")), nil)
+	handler.SetTokenStore(tokens)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/gc?age=30d", nil)
+	req.Header.Set("Authorization", "Bearer ci-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/gc", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/gc?age=30d", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"archived":[]}`))
+}
@@ -1,9 +1,11 @@
 package adapter
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -11,10 +13,22 @@ import (
 type IFileProvider interface {
 	ReadVersion(project string) (string, error)
 	StoreVersion(project string, version string) error
+	ListProjects() ([]string, error)
+	ReadDocument(kind string, project string) ([]byte, error)
+	StoreDocument(kind string, project string, document []byte) error
+	DeleteDocument(kind string, project string) error
+	ListDocuments(kind string) ([]string, error)
+	AppendHistory(project string, entry []byte) error
+	ReadHistory(project string) ([][]byte, error)
+	ReplaceHistory(project string, entries [][]byte) error
+	Namespace(namespace string) (IFileProvider, error)
+	ListNamespaces() ([]string, error)
 }
 
 type FileProvider struct {
 	basePath string
+	// the directory of a namespace is created by its first write
+	namespaced bool
 }
 
 func New(basePath string) IFileProvider {
@@ -25,6 +39,9 @@ func (provider *FileProvider) ReadVersion(project string) (string, error) {
 	filename := path.Join(provider.basePath, project)
 
 	if _, err := os.Stat(provider.basePath); os.IsNotExist(err) {
+		if provider.namespaced {
+			return "", nil
+		}
 		return "", errors.Wrapf(err, "Basedirectory %v not exist", provider.basePath)
 	}
 
@@ -41,12 +58,192 @@ func (provider *FileProvider) ReadVersion(project string) (string, error) {
 }
 
 func (provider *FileProvider) StoreVersion(project string, version string) error {
+	if provider.namespaced {
+		if err := os.MkdirAll(provider.basePath, 0755); err != nil {
+			return errors.Wrap(err, "Create directory for namespace failed")
+		}
+	}
+
 	text := []byte(version)
 	filename := path.Join(provider.basePath, project)
-	err := ioutil.WriteFile(filename, text, 0644)
+	err := writeAtomic(filename, text)
 	if err != nil {
 		return errors.Wrap(err, "Store version in file failed")
 	}
 
 	return nil
 }
+
+func (provider *FileProvider) ListProjects() ([]string, error) {
+	files, err := ioutil.ReadDir(provider.basePath)
+	if provider.namespaced && os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "List projects in %v failed", provider.basePath)
+	}
+
+	projects := []string{}
+	for _, file := range files {
+		if file.Mode().IsRegular() && file.Size() > 0 && !strings.HasPrefix(file.Name(), ".") {
+			projects = append(projects, file.Name())
+		}
+	}
+
+	return projects, nil
+}
+
+func (provider *FileProvider) ReadDocument(kind string, project string) ([]byte, error) {
+	filename := path.Join(provider.basePath, "_"+kind, project)
+	document, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Read %v document from file %v failed", kind, filename)
+	}
+
+	return document, nil
+}
+
+func (provider *FileProvider) StoreDocument(kind string, project string, document []byte) error {
+	dirname := path.Join(provider.basePath, "_"+kind)
+	if err := os.MkdirAll(dirname, 0755); err != nil {
+		return errors.Wrapf(err, "Create directory for %v documents failed", kind)
+	}
+
+	err := writeAtomic(path.Join(dirname, project), document)
+	if err != nil {
+		return errors.Wrapf(err, "Store %v document in file failed", kind)
+	}
+
+	return nil
+}
+
+func (provider *FileProvider) DeleteDocument(kind string, project string) error {
+	filename := path.Join(provider.basePath, "_"+kind, project)
+	err := os.Remove(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "Delete %v document %v failed", kind, filename)
+	}
+
+	return nil
+}
+
+func (provider *FileProvider) ListDocuments(kind string) ([]string, error) {
+	files, err := ioutil.ReadDir(path.Join(provider.basePath, "_"+kind))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "List %v documents in %v failed", kind, provider.basePath)
+	}
+
+	projects := []string{}
+	for _, file := range files {
+		if file.Mode().IsRegular() && !strings.HasPrefix(file.Name(), ".") {
+			projects = append(projects, file.Name())
+		}
+	}
+
+	return projects, nil
+}
+
+func (provider *FileProvider) AppendHistory(project string, entry []byte) error {
+	dirname := path.Join(provider.basePath, "_history")
+	if err := os.MkdirAll(dirname, 0755); err != nil {
+		return errors.Wrap(err, "Create directory for history failed")
+	}
+
+	file, err := os.OpenFile(path.Join(dirname, project), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "Open history of project %v failed", project)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(entry, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "Append history of project %v failed", project)
+	}
+
+	return nil
+}
+
+func (provider *FileProvider) ReadHistory(project string) ([][]byte, error) {
+	history, err := provider.ReadDocument("history", project)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := [][]byte{}
+	for _, line := range bytes.Split(history, []byte("\n")) {
+		if len(line) > 0 {
+			entries = append(entries, line)
+		}
+	}
+
+	return entries, nil
+}
+
+func (provider *FileProvider) ReplaceHistory(project string, entries [][]byte) error {
+	dirname := path.Join(provider.basePath, "_history")
+	if err := os.MkdirAll(dirname, 0755); err != nil {
+		return errors.Wrap(err, "Create directory for history failed")
+	}
+
+	history := []byte{}
+	for _, entry := range entries {
+		history = append(append(history, entry...), '\n')
+	}
+	if err := writeAtomic(path.Join(dirname, project), history); err != nil {
+		return errors.Wrapf(err, "Replace history of project %v failed", project)
+	}
+
+	return nil
+}
+
+//writeAtomic replaces the file by renaming a temporary file, so readers without lock never see a partial file
+func writeAtomic(filename string, data []byte) error {
+	// every writer has its own temporary file, the last rename wins
+	temp, err := ioutil.TempFile(path.Dir(filename), "."+path.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), filename)
+}
+
+func (provider *FileProvider) Namespace(namespace string) (IFileProvider, error) {
+	return &FileProvider{basePath: path.Join(provider.basePath, "_ns", namespace), namespaced: true}, nil
+}
+
+func (provider *FileProvider) ListNamespaces() ([]string, error) {
+	files, err := ioutil.ReadDir(path.Join(provider.basePath, "_ns"))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "List namespaces in %v failed", provider.basePath)
+	}
+
+	namespaces := []string{}
+	for _, file := range files {
+		if file.IsDir() {
+			namespaces = append(namespaces, file.Name())
+		}
+	}
+
+	return namespaces, nil
+}
@@ -1,31 +1,120 @@
 package adapter
 
+import (
+	"sort"
+	"strings"
+)
+
 // FileProviderMock for testing
 type FileProviderMock struct {
-	version       string
-	project       string
+	versions      map[string]string
 	VersionStored bool
+	namespaces    map[string]IFileProvider
+	documents     map[string][]byte
+	history       map[string][][]byte
 }
 
 // NewMock constructs a new FileProvider Mock
 func NewMock(version string, project string) IFileProvider {
 	return &FileProviderMock{
-		version: version,
-		project: project,
+		versions:   map[string]string{project: version},
+		namespaces: map[string]IFileProvider{},
+		documents:  map[string][]byte{},
+		history:    map[string][][]byte{},
 	}
 }
 
 // ReadVersion return the current version from providermock
 func (provider *FileProviderMock) ReadVersion(project string) (string, error) {
-	if provider.project == project {
-		return provider.version, nil
-	}
-
-	return "", nil
+	return provider.versions[project], nil
 }
 
-//StoreVersion sets versionStored to true, if this funcion is called
+//StoreVersion stores the version in memory and sets versionStored to true, if this funcion is called
 func (provider *FileProviderMock) StoreVersion(project string, version string) error {
 	provider.VersionStored = true
+	provider.versions[project] = version
+	return nil
+}
+
+//ListProjects returns all projects with a version
+func (provider *FileProviderMock) ListProjects() ([]string, error) {
+	projects := []string{}
+	for project, version := range provider.versions {
+		if version != "" {
+			projects = append(projects, project)
+		}
+	}
+	sort.Strings(projects)
+
+	return projects, nil
+}
+
+//ReadDocument returns the document stored in memory
+func (provider *FileProviderMock) ReadDocument(kind string, project string) ([]byte, error) {
+	return provider.documents[kind+"/"+project], nil
+}
+
+//StoreDocument stores the document in memory
+func (provider *FileProviderMock) StoreDocument(kind string, project string, document []byte) error {
+	provider.documents[kind+"/"+project] = document
+	return nil
+}
+
+//DeleteDocument removes the document from memory, the history is the history document like in the file provider
+func (provider *FileProviderMock) DeleteDocument(kind string, project string) error {
+	delete(provider.documents, kind+"/"+project)
+	if kind == "history" {
+		delete(provider.history, project)
+	}
+	return nil
+}
+
+//ListDocuments returns the projects with a document of the kind in memory
+func (provider *FileProviderMock) ListDocuments(kind string) ([]string, error) {
+	projects := []string{}
+	for key := range provider.documents {
+		if strings.HasPrefix(key, kind+"/") {
+			projects = append(projects, strings.TrimPrefix(key, kind+"/"))
+		}
+	}
+	sort.Strings(projects)
+
+	return projects, nil
+}
+
+//AppendHistory appends the entry to the history in memory
+func (provider *FileProviderMock) AppendHistory(project string, entry []byte) error {
+	provider.history[project] = append(provider.history[project], entry)
+	return nil
+}
+
+//ReadHistory returns the history in memory
+func (provider *FileProviderMock) ReadHistory(project string) ([][]byte, error) {
+	return provider.history[project], nil
+}
+
+//ReplaceHistory replaces the history in memory
+func (provider *FileProviderMock) ReplaceHistory(project string, entries [][]byte) error {
+	provider.history[project] = entries
 	return nil
 }
+
+//Namespace returns an empty mock per namespace
+func (provider *FileProviderMock) Namespace(namespace string) (IFileProvider, error) {
+	if _, exists := provider.namespaces[namespace]; !exists {
+		provider.namespaces[namespace] = NewMock("", "")
+	}
+
+	return provider.namespaces[namespace], nil
+}
+
+//ListNamespaces returns all namespaces opened on the mock
+func (provider *FileProviderMock) ListNamespaces() ([]string, error) {
+	namespaces := []string{}
+	for namespace := range provider.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	return namespaces, nil
+}
@@ -1,8 +1,10 @@
 package adapter
 
 import (
+	"io/ioutil"
 	"log"
 	"os"
+	"path"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -56,3 +58,136 @@ func removeFile(filename string) {
 		}
 	}
 }
+
+func Test_Namespaces_Are_Isolated(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	provider := New(basePath)
+
+	namespaced, err := provider.Namespace("team")
+	Ω.Expect(err).To(BeNil())
+	missing, err := namespaced.ReadVersion("p1")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(missing).To(BeEmpty())
+	namespaces, _ := provider.ListNamespaces()
+	Ω.Expect(namespaces).To(BeEmpty())
+	provider.StoreVersion("p1", "1.0")
+	namespaced.StoreVersion("p1", "2.0")
+	namespaces, _ = provider.ListNamespaces()
+	Ω.Expect(namespaces).To(Equal([]string{"team"}))
+	actual, _ := provider.ReadVersion("p1")
+	actualNamespaced, _ := namespaced.ReadVersion("p1")
+
+	Ω.Expect(actual).To(Equal("1.0"))
+	Ω.Expect(actualNamespaced).To(Equal("2.0"))
+}
+
+func Test_List_Projects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	provider := New(basePath)
+
+	provider.StoreVersion("p2", "1.0")
+	provider.StoreVersion("p1", "2.0")
+	provider.StoreVersion("removed", "")
+	_, _ = provider.Namespace("team")
+	actual, err := provider.ListProjects()
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal([]string{"p1", "p2"}))
+}
+
+func Test_Store_And_Read_Document(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	provider := New(basePath)
+
+	missing, err := provider.ReadDocument("meta", "p1")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(missing).To(BeNil())
+
+	provider.StoreVersion("p1", "1.0")
+	provider.StoreDocument("meta", "p1", []byte("{}"))
+	actual, _ := provider.ReadDocument("meta", "p1")
+	projects, _ := provider.ListProjects()
+
+	Ω.Expect(string(actual)).To(Equal("{}"))
+	Ω.Expect(projects).To(Equal([]string{"p1"}))
+
+	Ω.Expect(provider.DeleteDocument("meta", "p1")).To(BeNil())
+	Ω.Expect(provider.DeleteDocument("meta", "p1")).To(BeNil())
+	deleted, _ := provider.ReadDocument("meta", "p1")
+	Ω.Expect(deleted).To(BeNil())
+}
+
+func Test_List_Documents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	provider := New(basePath)
+
+	none, err := provider.ListDocuments("tombstone")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(none).To(BeEmpty())
+
+	provider.StoreDocument("tombstone", "p2", []byte("{}"))
+	provider.StoreDocument("tombstone", "p1", []byte("{}"))
+	provider.StoreDocument("meta", "p3", []byte("{}"))
+	projects, err := provider.ListDocuments("tombstone")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(projects).To(Equal([]string{"p1", "p2"}))
+}
+
+func Test_Append_And_Read_History(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	provider := New(basePath)
+
+	empty, err := provider.ReadHistory("p1")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(empty).To(BeEmpty())
+
+	provider.AppendHistory("p1", []byte(`{"version":"1"}`))
+	provider.AppendHistory("p1", []byte(`{"version":"2"}`))
+	actual, _ := provider.ReadHistory("p1")
+
+	Ω.Expect(actual).To(Equal([][]byte{[]byte(`{"version":"1"}`), []byte(`{"version":"2"}`)}))
+}
+
+func Test_Replace_History(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	provider := New(basePath)
+
+	provider.AppendHistory("p1", []byte(`{"version":"1"}`))
+	provider.AppendHistory("p1", []byte(`{"version":"2"}`))
+	Ω.Expect(provider.ReplaceHistory("p1", [][]byte{[]byte(`{"version":"2"}`)})).To(BeNil())
+	provider.AppendHistory("p1", []byte(`{"version":"3"}`))
+	actual, _ := provider.ReadHistory("p1")
+	files, _ := ioutil.ReadDir(path.Join(basePath, "_history"))
+
+	Ω.Expect(actual).To(Equal([][]byte{[]byte(`{"version":"2"}`), []byte(`{"version":"3"}`)}))
+	Ω.Expect(files).To(HaveLen(1))
+}
+
+func Test_Store_Version_Replaces_The_File(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	provider := New(basePath)
+
+	Ω.Expect(provider.StoreVersion("p1", "1.0.0")).To(BeNil())
+	Ω.Expect(provider.StoreVersion("p1", "1.0.1")).To(BeNil())
+	actual, _ := provider.ReadVersion("p1")
+	files, _ := ioutil.ReadDir(basePath)
+
+	Ω.Expect(actual).To(Equal("1.0.1"))
+	Ω.Expect(files).To(HaveLen(1))
+	Ω.Expect(files[0].Mode().Perm()).To(Equal(os.FileMode(0644)))
+}
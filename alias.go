@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	aliasDocument = "alias"
+	latestAlias   = "latest"
+)
+
+var validAlias = regexp.MustCompile("^[A-Za-z][A-Za-z0-9_.-]*$")
+
+//Aliases returns all named versions of the given project
+func (v *Version) Aliases(project string) (map[string]string, error) {
+	aliases := map[string]string{}
+	document, err := v.fileProvider.ReadDocument(aliasDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get aliases for project %v", project)
+	}
+	if document == nil {
+		return aliases, nil
+	}
+
+	if err := json.Unmarshal(document, &aliases); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse aliases for project %v", project)
+	}
+
+	return aliases, nil
+}
+
+//SetAlias assigns the alias to a version of the given project
+func (v *Version) SetAlias(project string, alias string, version string) error {
+	if !validAlias.MatchString(alias) {
+		return errors.Errorf("%v is not a valid alias", alias)
+	}
+	version, err := v.parseVersion(project, version)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := v.Aliases(project)
+	if err != nil {
+		return err
+	}
+	aliases[alias] = version
+
+	return v.storeAliases(project, aliases)
+}
+
+//DeleteAlias removes the alias of the given project
+func (v *Version) DeleteAlias(project string, alias string) error {
+	aliases, err := v.Aliases(project)
+	if err != nil {
+		return err
+	}
+	delete(aliases, alias)
+
+	return v.storeAliases(project, aliases)
+}
+
+//ResolveAlias returns the version of the alias, "latest" is the current version unless it is assigned explicitly
+func (v *Version) ResolveAlias(project string, alias string) (string, error) {
+	aliases, err := v.Aliases(project)
+	if err != nil {
+		return "", err
+	}
+	if version, exists := aliases[alias]; exists {
+		return version, nil
+	}
+	if alias == latestAlias {
+		return v.GetVersion(project)
+	}
+
+	return "", nil
+}
+
+func (v *Version) storeAliases(project string, aliases map[string]string) error {
+	document, err := json.Marshal(aliases)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode aliases for project %v", project)
+	}
+
+	err = v.fileProvider.StoreDocument(aliasDocument, project, document)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot store aliases for project %v", project)
+	}
+
+	return nil
+}
+
+//OnGetAlias is a handler for getting the version of an alias of a given project
+func (handler *Handler) OnGetAlias(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	version, err := service.ResolveAlias(context.Param("project"), context.Param("alias"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if version == "" {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No alias %v for project %v", context.Param("alias"), projectKey(context)))
+		return
+	}
+	version = service.Display(context.Param("project"), version)
+	if notModified(context, version) {
+		return
+	}
+
+	handler.logger.Infof("get version of alias %v from project %v", context.Param("alias"), projectKey(context))
+	context.String(http.StatusOK, "%s", version)
+}
+
+//OnListAliases is a handler for listing all aliases of a given project
+func (handler *Handler) OnListAliases(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	aliases, err := service.Aliases(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, aliases)
+}
+
+//OnSetAlias is a handler for assigning an alias to a version of a given project
+func (handler *Handler) OnSetAlias(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	alias, version := context.Param("alias"), context.Param("version")
+	if err := service.SetAlias(context.Param("project"), alias, version); err != nil {
+		_ = context.AbortWithError(http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	handler.auditLog().Infof("set alias %v to %v on project %v", alias, version, projectKey(context))
+	context.String(http.StatusOK, "%s", version)
+}
+
+//OnDeleteAlias is a handler for removing an alias of a given project
+func (handler *Handler) OnDeleteAlias(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteAlias(context.Param("project"), context.Param("alias")); err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.auditLog().Infof("delete alias %v on project %v", context.Param("alias"), projectKey(context))
+	context.Status(http.StatusNoContent)
+}
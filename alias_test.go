@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Set_And_Resolve_Alias(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.0", "p1"))
+
+	err := version.SetAlias("p1", "stable", "1.3.2")
+	stable, _ := version.ResolveAlias("p1", "stable")
+	latest, _ := version.ResolveAlias("p1", "latest")
+	unknown, _ := version.ResolveAlias("p1", "beta")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(stable).To(Equal("1.3.2"))
+	Ω.Expect(latest).To(Equal("1.4.0"))
+	Ω.Expect(unknown).To(Equal(""))
+}
+
+func Test_Set_Invalid_Alias(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.0", "p1"))
+
+	Ω.Expect(version.SetAlias("p1", "1stable", "1.3.2")).NotTo(BeNil())
+	Ω.Expect(version.SetAlias("p1", "stable", "1.3.a")).NotTo(BeNil())
+}
+
+func Test_Delete_Alias(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.0", "p1"))
+
+	_ = version.SetAlias("p1", "stable", "1.3.2")
+	_ = version.DeleteAlias("p1", "stable")
+	aliases, _ := version.Aliases("p1")
+
+	Ω.Expect(aliases).To(BeEmpty())
+}
+
+func Test_Alias_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.4.0", "p1")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/alias/p1/stable/1.3.2", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1/stable", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.3.2"))
+	Ω.Expect(res.Header().Get("ETag")).To(Equal(versionETag("1.3.2")))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/alias/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"stable":"1.3.2"}`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/alias/p1/stable", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(204))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1/stable", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+}
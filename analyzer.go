@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//ErrAnalyzerHook is returned when the diff analyzer hook of a project fails
+var ErrAnalyzerHook = errors.New("diff analyzer hook failed")
+
+var (
+	analyzerClient = &http.Client{Timeout: 30 * time.Second}
+	elementRanks   = map[string]int{"patch": 1, "minor": 2, "major": 3}
+)
+
+//Analyzer is an external http hook, e.g. an API compatibility checker, which may upgrade the element of a bump
+type Analyzer struct {
+	HookURL string `json:"hookUrl"`
+}
+
+//AnalyzerRequest is posted to the hook before a bump
+type AnalyzerRequest struct {
+	Project string `json:"project"`
+	Version string `json:"version"`
+	Element string `json:"element"`
+}
+
+//AnalyzerResponse is the verdict of the hook with the element the changes require
+type AnalyzerResponse struct {
+	Element string `json:"element"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+//analyzerOf returns the diff analyzer of the given project or nil, if it has none
+func (v *Version) analyzerOf(project string) (*Analyzer, error) {
+	meta, err := v.GetMetadata(project)
+	if err != nil || meta == nil || meta.Analyzer == nil || meta.Analyzer.HookURL == "" {
+		return nil, err
+	}
+	if err := v.checkHookURL(meta.Analyzer.HookURL); err != nil {
+		return nil, err
+	}
+
+	return meta.Analyzer, nil
+}
+
+//analyze returns the element to bump, which is the requested one unless the analyzer of the project requires a higher one,
+//and the decision of the analyzer, "" without an analyzer
+func (v *Version) analyze(project string, element string) (string, string, error) {
+	if _, ok := elementRanks[element]; !ok {
+		return element, "", nil
+	}
+	analyzer, err := v.analyzerOf(project)
+	if err != nil || analyzer == nil {
+		return element, "", err
+	}
+	current, err := v.readVersion(project)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "Cannot get version for project %v", project)
+	}
+
+	verdict, err := analyzer.Verdict(AnalyzerRequest{Project: project, Version: current, Element: element})
+	if err != nil {
+		return "", "", err
+	}
+	if elementRanks[verdict.Element] <= elementRanks[element] {
+		return element, "kept " + element + ": " + verdict.Reason, nil
+	}
+
+	return verdict.Element, "upgraded " + element + " to " + verdict.Element + ": " + verdict.Reason, nil
+}
+
+//Verdict asks the hook for the element the changes since the current version require
+func (analyzer *Analyzer) Verdict(request AnalyzerRequest) (*AnalyzerResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot encode analyzer request")
+	}
+
+	res, err := analyzerClient.Post(analyzer.HookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(ErrAnalyzerHook, "Cannot call %v for project %v: %v", analyzer.HookURL, request.Project, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, errors.Wrapf(ErrAnalyzerHook, "Hook %v for project %v responded with status %v", analyzer.HookURL, request.Project, res.StatusCode)
+	}
+
+	response := &AnalyzerResponse{}
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		return nil, errors.Wrapf(ErrAnalyzerHook, "Cannot parse response of %v for project %v: %v", analyzer.HookURL, request.Project, err)
+	}
+	if _, ok := elementRanks[response.Element]; !ok {
+		return nil, errors.Wrapf(ErrAnalyzerHook, "Hook %v returned invalid element %q for project %v", analyzer.HookURL, response.Element, request.Project)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func newAnalyzerHook(element string, status int) (*httptest.Server, chan AnalyzerRequest) {
+	requests := make(chan AnalyzerRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := AnalyzerRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		requests <- request
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(AnalyzerResponse{Element: element, Reason: "removed method Foo"})
+	}))
+
+	return server, requests
+}
+
+func Test_Analyzer_Upgrades_Bump(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	hook, requests := newAnalyzerHook("major", http.StatusOK)
+	defer hook.Close()
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+	_ = version.AllowHookURLs([]string{hook.URL})
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Analyzer: &Analyzer{HookURL: hook.URL}}})
+
+	entry, err := version.Bump("p1", "patch")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Version).To(Equal("2.0.0"))
+	Ω.Expect(entry.Element).To(Equal("major"))
+	Ω.Expect(entry.Analysis).To(Equal("upgraded patch to major: removed method Foo"))
+	Ω.Expect(<-requests).To(Equal(AnalyzerRequest{Project: "p1", Version: "1.4.2", Element: "patch"}))
+	history, _ := version.History("p1")
+	Ω.Expect(history[len(history)-1].Analysis).To(Equal(entry.Analysis))
+}
+
+func Test_Analyzer_Never_Downgrades_Bump(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	hook, _ := newAnalyzerHook("patch", http.StatusOK)
+	defer hook.Close()
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+	_ = version.AllowHookURLs([]string{hook.URL})
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Analyzer: &Analyzer{HookURL: hook.URL}}})
+
+	entry, err := version.Bump("p1", "minor")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Version).To(Equal("1.5.0"))
+	Ω.Expect(entry.Analysis).To(Equal("kept minor: removed method Foo"))
+}
+
+func Test_Failing_Analyzer_Hook_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	hook, _ := newAnalyzerHook("", http.StatusOK)
+	defer hook.Close()
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+	_ = version.AllowHookURLs([]string{hook.URL})
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Analyzer: &Analyzer{HookURL: hook.URL}}})
+	router := NewHandler(version, nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(res.Code).To(Equal(502))
+	Ω.Expect(current).To(Equal("1.4.2"))
+}
+
+func Test_Analyzer_Hook_Must_Be_Allowed(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	hook, _ := newAnalyzerHook("major", http.StatusOK)
+	defer hook.Close()
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/config/p1", strings.NewReader(`{"schemaVersion": 1, "analyzer": {"hookUrl": "`+hook.URL+`"}}`))
+	router.ServeHTTP(res, req)
+	meta, _ := version.GetMetadata("p1")
+
+	Ω.Expect(res.Code).To(Equal(http.StatusForbidden))
+	Ω.Expect(meta).To(BeNil())
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const annotationKey = "annotation"
+
+//Annotation describes why, by whom and from which client a change was made
+type Annotation struct {
+	Reason string `json:"reason,omitempty"`
+	Actor  string `json:"-"`
+	Client string `json:"-"`
+
+	Analysis string `json:"-"`
+}
+
+//WithAnnotation returns the version service recording the given annotation with every change
+func (v *Version) WithAnnotation(annotation Annotation) *Version {
+	annotated := *v
+	annotated.annotation = annotation
+	return &annotated
+}
+
+//changingVersionFor returns the version service for the namespace of the request, annotated by the request
+func (handler *Handler) changingVersionFor(context *gin.Context) (*Version, bool) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return nil, false
+	}
+
+	annotation := Annotation{Reason: context.Query("reason")}
+	if strings.HasPrefix(context.ContentType(), "application/json") {
+		if err := context.ShouldBindBodyWith(&annotation, binding.JSON); err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid annotation"))
+			return nil, false
+		}
+	}
+
+	annotation.Actor = actor(context)
+	annotation.Client = handler.client(context)
+	context.Set(annotationKey, annotation)
+	return service.WithAnnotation(annotation), true
+}
+
+//actor returns the identity of the client, the name of its api token
+func actor(context *gin.Context) string {
+	return context.GetString(actorKey)
+}
+
+//annotation returns the annotation of the request
+func annotation(context *gin.Context) Annotation {
+	if value, exists := context.Get(annotationKey); exists {
+		return value.(Annotation)
+	}
+
+	return Annotation{}
+}
+
+//auditLog returns the logger for changes of projects, the application logger if there is no audit logger
+func (handler *Handler) auditLog() *log.Logger {
+	if handler.audit != nil {
+		return handler.audit
+	}
+
+	return handler.logger
+}
+
+//changeLog returns the audit logger for changes annotated with the request annotation
+func (handler *Handler) changeLog(context *gin.Context) *log.Entry {
+	fields := log.Fields{}
+	if reason := annotation(context).Reason; reason != "" {
+		fields["reason"] = reason
+	}
+	if actor := annotation(context).Actor; actor != "" {
+		fields["actor"] = actor
+	}
+	if client := annotation(context).Client; client != "" {
+		fields["client"] = client
+	}
+
+	return handler.auditLog().WithFields(fields)
+}
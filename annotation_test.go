@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Bump_Records_Reason_From_Query(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	router := NewHandler(version, nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/major/p1?reason=breaking+api", nil)
+	router.ServeHTTP(res, req)
+	history, _ := version.History("p1")
+
+	Ω.Expect(res.Body.String()).To(Equal("2.0.0"))
+	Ω.Expect(history[0].Reason).To(Equal("breaking api"))
+}
+
+func Test_Set_Version_Records_Reason_From_Body(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	router := NewHandler(version, nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/version/p1/3.0.0", strings.NewReader(`{"reason":"align with prod"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+	history, _ := version.History("p1")
+
+	Ω.Expect(res.Body.String()).To(Equal("3.0.0"))
+	Ω.Expect(history[0].Reason).To(Equal("align with prod"))
+}
+
+func Test_Bump_With_Invalid_Annotation(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/patch/p1", strings.NewReader(`{"reason":`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
+func Test_Notification_Contains_Reason(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	webhook, received := newWebhook()
+	defer webhook.Close()
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetNotifier(NewNotifier(webhook.URL, map[string]string{}, nil))
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/minor/p1?reason=new+feature", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Eventually(received, time.Second).Should(Receive(And(
+		HaveKeyWithValue("text", "vbump: minor bump of p1 to 1.1.0 (new feature)"),
+		HaveKeyWithValue("reason", "new feature"),
+	)))
+}
+
+func Test_Bump_Records_Actor_From_Token(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	tokens := NewTokenStore()
+	tokens.Add("jenkins", "secret", "*")
+	version := NewVersion(adapter.NewMock("1.0.0", "actorproject"))
+	handler := NewHandler(version, nil)
+	handler.SetTokenStore(tokens)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/patch/actorproject", strings.NewReader(`{"reason":"fix","actor":"someone else"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+	history, _ := version.History("actorproject")
+
+	Ω.Expect(history[0].Actor).To(Equal("jenkins"))
+
+	metrics, _ := http.NewRequest("GET", "/metrics", nil)
+	router.ServeHTTP(res, metrics)
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`vbump_last_change_info{actor="jenkins",element="patch",namespace="",project="actorproject",version="1.0.1"} 1`))
+}
+
+func Test_Last_Change_Info_Replaces_Previous_Change(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "infoproject")), nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	patch, _ := http.NewRequest("POST", "/patch/infoproject", nil)
+	minor, _ := http.NewRequest("POST", "/minor/infoproject", nil)
+	metrics, _ := http.NewRequest("GET", "/metrics", nil)
+	router.ServeHTTP(res, patch)
+	router.ServeHTTP(res, minor)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, metrics)
+
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`vbump_last_change_info{actor="",element="minor",namespace="",project="infoproject",version="1.1.0"} 1`))
+	Ω.Expect(res.Body.String()).NotTo(ContainSubstring(`element="patch",namespace="",project="infoproject"`))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const archiveDocument = "archive"
+
+//ErrArchived is returned when changing an archived project
+var ErrArchived = newError(ErrFrozen, "project is archived")
+
+//Archived records when a project was archived
+type Archived struct {
+	Time string `json:"time"`
+}
+
+//Archive hides the given project from listings, its version and metadata are kept
+func (v *Version) Archive(project string) error {
+	document, err := json.Marshal(Archived{Time: v.now().UTC().Format(time.RFC3339)})
+	if err != nil {
+		return errors.Wrapf(err, "Cannot archive project %v", project)
+	}
+
+	err = v.fileProvider.StoreDocument(archiveDocument, project, document)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot archive project %v", project)
+	}
+
+	return nil
+}
+
+//Unarchive makes an archived project visible and changeable again
+func (v *Version) Unarchive(project string) error {
+	err := v.fileProvider.DeleteDocument(archiveDocument, project)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot unarchive project %v", project)
+	}
+
+	return nil
+}
+
+//IsArchived returns true, if the given project is archived
+func (v *Version) IsArchived(project string) (bool, error) {
+	document, err := v.fileProvider.ReadDocument(archiveDocument, project)
+	if err != nil {
+		return false, errors.Wrapf(err, "Cannot get archive state of project %v", project)
+	}
+
+	return document != nil, nil
+}
+
+//readForChange reads the current version of a project, which is about to change
+func (v *Version) readForChange(project string) (string, error) {
+	if err := v.checkChangeable(project); err != nil {
+		return "", err
+	}
+
+	return v.readVersion(project)
+}
+
+//readLockedForChange reads the current version like readForChange, the caller holds the lock of the project, so a corrupted version is repaired
+func (v *Version) readLockedForChange(project string) (string, error) {
+	if err := v.checkChangeable(project); err != nil {
+		return "", err
+	}
+
+	return v.repairedVersion(project)
+}
+
+//checkChangeable returns an error, if the project is archived or deleted
+func (v *Version) checkChangeable(project string) error {
+	archived, err := v.IsArchived(project)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return ErrArchived
+	}
+
+	return v.checkDeleted(project)
+}
+
+//OnArchive is a handler for archiving a given project
+func (handler *Handler) OnArchive(context *gin.Context) {
+	handler.changeArchive(context, true)
+}
+
+//OnUnarchive is a handler for reviving an archived project
+func (handler *Handler) OnUnarchive(context *gin.Context) {
+	handler.changeArchive(context, false)
+}
+
+func (handler *Handler) changeArchive(context *gin.Context, archive bool) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	project := context.Param("project")
+	exists, err := service.Exists(project)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if !exists {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("Project %v does not exist", projectKey(context)))
+		return
+	}
+
+	if archive {
+		err = service.Archive(project)
+	} else {
+		err = service.Unarchive(project)
+	}
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.auditLog().Infof("set archived to %v on project %v", archive, projectKey(context))
+	context.Status(http.StatusNoContent)
+}
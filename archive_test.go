@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Archived_Project_Rejects_Bumps(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+
+	_ = version.Archive("p1")
+	_, bumpErr := version.BumpPatch("p1")
+	_, setErr := version.SetVersion("p1", "2.0.0")
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(bumpErr).NotTo(BeNil())
+	Ω.Expect(setErr).NotTo(BeNil())
+	Ω.Expect(current).To(Equal("1.0.0"))
+}
+
+func Test_Unarchived_Project_Accepts_Bumps(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+
+	_ = version.Archive("p1")
+	_ = version.Unarchive("p1")
+	actual, err := version.BumpPatch("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal("1.0.1"))
+}
+
+func Test_Archive_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/archive/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(204))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(409))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/projects", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`[]`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/unarchive/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(204))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+}
+
+func Test_Archive_Unknown_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/archive/p2", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(404))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+//AuditRotation rotates the audit log file by size and age and keeps the given number of rotated files, zero means unlimited
+type AuditRotation struct {
+	MaxSize int64
+	MaxAge  time.Duration
+	Keep    int
+}
+
+//SetAuditLogger logs the changes of projects to the audit logger instead of the application log
+func (handler *Handler) SetAuditLogger(audit *log.Logger) {
+	handler.audit = audit
+}
+
+//OpenAuditLog returns a json logger writing to syslog for "syslog", "syslog://host:514" (udp) or "syslog+tcp://host:514", otherwise to the rotated file
+func OpenAuditLog(target string, rotation AuditRotation) (*log.Logger, error) {
+	var out io.Writer
+	var err error
+	if isSyslog(target) {
+		out, err = openSyslog(target)
+	} else {
+		out, err = openRotatingFile(target, rotation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	audit := log.New()
+	audit.Out = out
+	audit.Formatter = &log.JSONFormatter{}
+	return audit, nil
+}
+
+//isSyslog returns true for the audit log targets sending to syslog
+func isSyslog(target string) bool {
+	return target == "syslog" || strings.HasPrefix(target, "syslog://") || strings.HasPrefix(target, "syslog+tcp://")
+}
+
+//syslogAddress returns the network and address of a syslog target, "" for the local syslog
+func syslogAddress(target string) (string, string) {
+	if strings.HasPrefix(target, "syslog+tcp://") {
+		return "tcp", strings.TrimPrefix(target, "syslog+tcp://")
+	}
+	if strings.HasPrefix(target, "syslog://") {
+		return "udp", strings.TrimPrefix(target, "syslog://")
+	}
+
+	return "", ""
+}
+
+//rotatingFile is a log file, which is moved aside to <file>.<time>, when it exceeds its size or age
+type rotatingFile struct {
+	path     string
+	rotation AuditRotation
+	now      func() time.Time
+
+	mutex  sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+func openRotatingFile(path string, rotation AuditRotation) (*rotatingFile, error) {
+	file := &rotatingFile{path: path, rotation: rotation, now: time.Now}
+	if err := file.open(); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot open audit log %v", f.path)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "Cannot open audit log %v", f.path)
+	}
+
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	full := f.rotation.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.rotation.MaxSize
+	old := f.rotation.MaxAge > 0 && f.now().Sub(f.opened) >= f.rotation.MaxAge
+	if full || old {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+//rotate moves the file aside, removes rotated files beyond keep and opens a new file
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return errors.Wrapf(err, "Cannot rotate audit log %v", f.path)
+	}
+	rotated := fmt.Sprintf("%v.%v", f.path, f.now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(f.path, rotated); err != nil {
+		return errors.Wrapf(err, "Cannot rotate audit log %v", f.path)
+	}
+
+	if f.rotation.Keep > 0 {
+		files, err := filepath.Glob(f.path + ".*")
+		if err != nil {
+			return errors.Wrapf(err, "Cannot rotate audit log %v", f.path)
+		}
+		// the time suffix sorts the rotated files from old to new
+		sort.Strings(files)
+		for len(files) > f.rotation.Keep {
+			if err := os.Remove(files[0]); err != nil {
+				return errors.Wrapf(err, "Cannot remove rotated audit log %v", files[0])
+			}
+			files = files[1:]
+		}
+	}
+
+	return f.open()
+}
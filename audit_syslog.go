@@ -0,0 +1,21 @@
+// +build !windows
+
+package main
+
+import (
+	"io"
+	"log/syslog"
+
+	"github.com/pkg/errors"
+)
+
+//openSyslog connects to the local or remote syslog of the target
+func openSyslog(target string) (io.Writer, error) {
+	network, address := syslogAddress(target)
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, "vbump")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot connect to syslog %v", target)
+	}
+
+	return writer, nil
+}
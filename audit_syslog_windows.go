@@ -0,0 +1,14 @@
+// +build windows
+
+package main
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+//openSyslog is not supported on windows
+func openSyslog(target string) (io.Writer, error) {
+	return nil, errors.New("Syslog is not supported on windows")
+}
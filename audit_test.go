@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+func Test_Changes_Are_Logged_To_The_Audit_Log(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	application, audited := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := log.New()
+	logger.Out = application
+	audit := log.New()
+	audit.Out = audited
+	audit.Formatter = &log.JSONFormatter{}
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), logger)
+	handler.SetAuditLogger(audit)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1?reason=hotfix", nil)
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(audited.String()).To(ContainSubstring(`"msg":"bump patch version to 1.0.1 on project p1"`))
+	Ω.Expect(audited.String()).To(ContainSubstring(`"reason":"hotfix"`))
+	Ω.Expect(audited.String()).NotTo(ContainSubstring("get version"))
+	Ω.Expect(application.String()).To(ContainSubstring("get version from project p1"))
+	Ω.Expect(application.String()).NotTo(ContainSubstring("bump patch"))
+}
+
+func Test_Rotate_Audit_Log_By_Size(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "audit")
+	defer os.RemoveAll(dir)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	file, err := openRotatingFile(filepath.Join(dir, "audit.log"), AuditRotation{MaxSize: 10, Keep: 2})
+	Ω.Expect(err).NotTo(HaveOccurred())
+	file.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := file.Write([]byte(line))
+		Ω.Expect(err).NotTo(HaveOccurred())
+	}
+
+	rotated, _ := filepath.Glob(filepath.Join(dir, "audit.log.*"))
+	current, _ := ioutil.ReadFile(filepath.Join(dir, "audit.log"))
+	Ω.Expect(rotated).To(HaveLen(2))
+	Ω.Expect(string(current)).To(Equal("fourth\n"))
+	previous, _ := ioutil.ReadFile(rotated[1])
+	Ω.Expect(string(previous)).To(Equal("third\n"))
+}
+
+func Test_Rotate_Audit_Log_By_Age(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "audit")
+	defer os.RemoveAll(dir)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	file, _ := openRotatingFile(filepath.Join(dir, "audit.log"), AuditRotation{MaxAge: time.Hour})
+	file.now = func() time.Time { return now }
+	file.opened = now
+
+	_, _ = file.Write([]byte("first\n"))
+	_, _ = file.Write([]byte("second\n"))
+	now = now.Add(time.Hour)
+	_, _ = file.Write([]byte("third\n"))
+
+	rotated, _ := filepath.Glob(filepath.Join(dir, "audit.log.*"))
+	Ω.Expect(rotated).To(Equal([]string{filepath.Join(dir, "audit.log.20240501T130000.000000000")}))
+	previous, _ := ioutil.ReadFile(rotated[0])
+	Ω.Expect(string(previous)).To(Equal("first\nsecond\n"))
+}
+
+func Test_Syslog_Address(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	network, address := syslogAddress("syslog")
+	Ω.Expect(network).To(BeEmpty())
+	Ω.Expect(address).To(BeEmpty())
+	network, address = syslogAddress("syslog://siem:514")
+	Ω.Expect(network).To(Equal("udp"))
+	Ω.Expect(address).To(Equal("siem:514"))
+	network, address = syslogAddress("syslog+tcp://siem:601")
+	Ω.Expect(network).To(Equal("tcp"))
+	Ω.Expect(address).To(Equal("siem:601"))
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+//chainField is the field of an audit entry with the hash of the entry before
+const chainField = "previous"
+
+//chainFormatter adds the hash of the previous entry to every entry, logrus formats the entries one at a time
+type chainFormatter struct {
+	next     log.Formatter
+	previous string
+}
+
+func (formatter *chainFormatter) Format(entry *log.Entry) ([]byte, error) {
+	data := make(log.Fields, len(entry.Data)+1)
+	for key, value := range entry.Data {
+		data[key] = value
+	}
+	data[chainField] = formatter.previous
+	chained := *entry
+	chained.Data = data
+
+	line, err := formatter.next.Format(&chained)
+	if err != nil {
+		return nil, err
+	}
+	formatter.previous = lineHash(bytes.TrimRight(line, "\n"))
+	return line, nil
+}
+
+//ChainAuditLog chains the entries of the audit log by hash, the chain continues after the last entry of the file
+func ChainAuditLog(audit *log.Logger, filename string) error {
+	formatter := &chainFormatter{next: audit.Formatter}
+	if filename != "" {
+		last, err := lastLine(filename)
+		if err != nil {
+			return err
+		}
+		if last != nil {
+			formatter.previous = lineHash(last)
+		}
+	}
+
+	audit.Formatter = formatter
+	return nil
+}
+
+//SetAuditChain enables the verification of the chained audit log file on GET /admin/audit/verify
+func (handler *Handler) SetAuditChain(filename string) {
+	handler.auditChain = filename
+}
+
+func lastLine(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot read audit log %v", filename)
+	}
+	defer file.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+
+	return last, errors.Wrapf(scanner.Err(), "Cannot read audit log %v", filename)
+}
+
+//AuditVerification is the result of verifying the chain of the audit log and its rotated files, oldest first
+type AuditVerification struct {
+	Valid   bool     `json:"valid"`
+	Files   []string `json:"files"`
+	Entries int      `json:"entries"`
+	File    string   `json:"file,omitempty"`
+	Line    int      `json:"line,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+//VerifyAuditLog checks the chain of the audit log with its rotated files, the first entry of the oldest file is trusted, older files may be removed
+func VerifyAuditLog(filename string) (*AuditVerification, error) {
+	rotated, err := filepath.Glob(filename + ".*")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot list rotated audit logs of %v", filename)
+	}
+	// the time suffix sorts the rotated files from old to new
+	sort.Strings(rotated)
+	verification := &AuditVerification{Valid: true, Files: append(rotated, filename)}
+
+	var previous *string
+	for _, name := range verification.Files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot read audit log %v", name)
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		number := 0
+		for scanner.Scan() {
+			number++
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			entry := map[string]interface{}{}
+			problem := ""
+			if err := json.Unmarshal(line, &entry); err != nil {
+				problem = "the entry is no json"
+			} else if chained, ok := entry[chainField].(string); !ok {
+				problem = "the entry has no hash of the entry before"
+			} else if previous != nil && chained != *previous {
+				problem = "the entry does not follow the entry before, entries were changed or removed"
+			}
+			if problem != "" {
+				file.Close()
+				verification.Valid, verification.File, verification.Line, verification.Error = false, name, number, problem
+				return verification, nil
+			}
+			hash := lineHash(line)
+			previous = &hash
+			verification.Entries++
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Wrapf(err, "Cannot read audit log %v", name)
+		}
+	}
+
+	return verification, nil
+}
+
+//OnVerifyAuditLog is a handler for verifying the chain of the audit log, a broken chain is returned with 409
+func (handler *Handler) OnVerifyAuditLog(context *gin.Context) {
+	verification, err := VerifyAuditLog(handler.auditChain)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if !verification.Valid {
+		handler.logger.Warnf("audit log %v was altered at line %v: %v", verification.File, verification.Line, verification.Error)
+		context.JSON(http.StatusConflict, verification)
+		return
+	}
+	context.JSON(http.StatusOK, verification)
+}
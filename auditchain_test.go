@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Verify_Chained_Audit_Log(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "audit")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "audit.log")
+
+	// the chain continues after a restart and across rotated files
+	for i := 0; i < 2; i++ {
+		audit, err := OpenAuditLog(filename, AuditRotation{MaxSize: 200})
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(ChainAuditLog(audit, filename)).To(BeNil())
+		audit.Infof("bump patch version to 1.0.%v on project p1", i)
+		audit.WithField("actor", "ci").Infof("bump minor version to 1.%v.0 on project p1", i)
+	}
+
+	verification, err := VerifyAuditLog(filename)
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(verification.Valid).To(BeTrue())
+	Ω.Expect(verification.Entries).To(Equal(4))
+	Ω.Expect(len(verification.Files)).To(BeNumerically(">", 1))
+}
+
+func Test_Verify_Altered_Audit_Log(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "audit")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "audit.log")
+	audit, _ := OpenAuditLog(filename, AuditRotation{})
+	_ = ChainAuditLog(audit, filename)
+	for _, version := range []string{"1.0.1", "1.0.2", "1.0.3"} {
+		audit.Infof("bump patch version to %v on project p1", version)
+	}
+	document, _ := ioutil.ReadFile(filename)
+	_ = ioutil.WriteFile(filename, bytes.Replace(document, []byte("1.0.2"), []byte("1.0.9"), 1), 0640)
+
+	verification, err := VerifyAuditLog(filename)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(verification.Valid).To(BeFalse())
+	Ω.Expect(verification.Line).To(Equal(3))
+	Ω.Expect(verification.Entries).To(Equal(2))
+}
+
+func Test_Verify_Audit_Log_Endpoint(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "audit")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "audit.log")
+	audit, _ := OpenAuditLog(filename, AuditRotation{})
+	_ = ChainAuditLog(audit, filename)
+	tokens := NewTokenStore()
+	tokens.Add("admin", "secret", "*")
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetTokenStore(tokens)
+	handler.SetAuditLogger(audit)
+	handler.SetAuditChain(filename)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin/audit/verify", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"valid": true, "files": ["` + filename + `"], "entries": 1}`))
+
+	_ = ioutil.WriteFile(filename, []byte("{\"msg\": \"forged\"}\n"), 0640)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(409))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	actorKey    = "actor"
+	globalScope = "*"
+)
+
+//Token grants a named client access to the api
+type Token struct {
+	Name       string
+	scopes     []string
+	breakGlass bool
+}
+
+//TokenStore holds all api tokens accepted by vbump
+type TokenStore struct {
+	mutex  sync.RWMutex
+	tokens map[[sha256.Size]byte]*Token
+}
+
+//NewTokenStore constructs an empty token store
+func NewTokenStore() *TokenStore {
+	return &TokenStore{tokens: map[[sha256.Size]byte]*Token{}}
+}
+
+//LoadTokens reads tokens from a file with one "name token scope[,scope]" entry per line
+func LoadTokens(filename string) (*TokenStore, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot open token file %v", filename)
+	}
+	defer file.Close()
+
+	tokens := NewTokenStore()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, errors.Errorf("Invalid token entry in %v line %v", filename, line)
+		}
+		tokens.Add(fields[0], fields[1], strings.Split(fields[2], ",")...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Cannot read token file %v", filename)
+	}
+
+	return tokens, nil
+}
+
+//Add registers a token with its scopes, "*" grants everything and "ns:<name>" grants a single namespace
+func (tokens *TokenStore) Add(name string, secret string, scopes ...string) {
+	tokens.mutex.Lock()
+	defer tokens.mutex.Unlock()
+	tokens.tokens[sha256.Sum256([]byte(secret))] = &Token{Name: name, scopes: scopes}
+}
+
+//Lookup returns the token for the given secret or nil, if the secret is unknown
+func (tokens *TokenStore) Lookup(secret string) *Token {
+	tokens.mutex.RLock()
+	defer tokens.mutex.RUnlock()
+	return tokens.tokens[sha256.Sum256([]byte(secret))]
+}
+
+//Reload replaces all tokens by the tokens of the file, the tokens are kept, if the file is invalid
+func (tokens *TokenStore) Reload(filename string) error {
+	loaded, err := LoadTokens(filename)
+	if err != nil {
+		return err
+	}
+
+	tokens.mutex.Lock()
+	defer tokens.mutex.Unlock()
+	tokens.tokens = loaded.tokens
+	return nil
+}
+
+//WatchTokens reloads the tokens from the file every interval, e.g. when it is renewed by the vault agent
+func (tokens *TokenStore) WatchTokens(filename string, interval time.Duration, logger *log.Logger) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := tokens.Reload(filename); err != nil {
+				logger.Error(err)
+			}
+		}
+	}()
+}
+
+//HasScope returns true, if the token was granted the given scope or the global scope
+func (token *Token) HasScope(scope string) bool {
+	for _, granted := range token.scopes {
+		if granted == globalScope || granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+//AllowsNamespace returns true, if the token may access projects in the given namespace, "" is the default namespace
+func (token *Token) AllowsNamespace(namespace string) bool {
+	if namespace == "" {
+		return token.HasScope(globalScope)
+	}
+
+	return token.HasScope("ns:" + namespace)
+}
+
+//AuthMiddleware rejects requests without a valid bearer token for the requested namespace
+func (handler *Handler) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" || path == "/" || path == "/metrics" || path == "/readyz" || path == "/slack/command" || (path == "/hooks/push" && handler.pushSecret.Value() != "") {
+			c.Next()
+			return
+		}
+
+		authorization := c.GetHeader("Authorization")
+		token := handler.tokens.Lookup(strings.TrimPrefix(authorization, "Bearer "))
+		if token == nil && strings.HasPrefix(authorization, "Bearer ") {
+			token = handler.lookupBreakGlass(c, strings.TrimPrefix(authorization, "Bearer "))
+		}
+		if token == nil || !strings.HasPrefix(authorization, "Bearer ") {
+			c.Header("WWW-Authenticate", "Bearer")
+			_ = c.AbortWithError(http.StatusUnauthorized, errors.Errorf("Missing or invalid token for %v", c.Request.URL.Path))
+			return
+		}
+
+		if !token.AllowsNamespace(c.Param("namespace")) {
+			_ = c.AbortWithError(http.StatusForbidden, errors.Errorf("Token %v is not allowed to access %v", token.Name, c.Request.URL.Path))
+			return
+		}
+
+		c.Set(actorKey, token.Name)
+		c.Set(tokenKey, token)
+		c.Next()
+		if token.breakGlass {
+			handler.auditBreakGlass(c)
+		}
+	}
+}
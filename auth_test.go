@@ -0,0 +1,108 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func newAuthRouter() http.Handler {
+	tokens := NewTokenStore()
+	tokens.Add("ci", "global-secret", "*")
+	tokens.Add("team", "team-secret", "ns:team")
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetTokenStore(tokens)
+
+	return handler.GetRouter()
+}
+
+func Test_Load_Tokens(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	file, _ := ioutil.TempFile("", "tokens")
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString("# comment\n\nci secret1 *\nteam secret2 ns:a,ns:b\n")
+	file.Close()
+
+	tokens, err := LoadTokens(file.Name())
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(tokens.Lookup("secret1").Name).To(Equal("ci"))
+	Ω.Expect(tokens.Lookup("secret2").AllowsNamespace("b")).To(BeTrue())
+	Ω.Expect(tokens.Lookup("secret2").AllowsNamespace("")).To(BeFalse())
+	Ω.Expect(tokens.Lookup("unknown")).To(BeNil())
+}
+
+func Test_Load_Tokens_With_Invalid_Entry(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	file, _ := ioutil.TempFile("", "tokens")
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString("ci secret1\n")
+	file.Close()
+
+	_, err := LoadTokens(file.Name())
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Auth_Without_Token(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := newAuthRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(401))
+}
+
+func Test_Auth_With_Global_Token(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := newAuthRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	req.Header.Set("Authorization", "Bearer global-secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(Equal("1.0.0"))
+}
+
+func Test_Auth_With_Namespace_Token(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := newAuthRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer team-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ns/other/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer team-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer team-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+}
+
+func Test_Auth_Skips_Health(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := newAuthRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//BenchOptions configures a load test against a running vbump
+type BenchOptions struct {
+	Server      string
+	Concurrency int
+	Requests    int
+	Projects    int
+	Prefix      string
+	Token       string
+}
+
+//BenchStats are the results of the requests to one endpoint
+type BenchStats struct {
+	Requests int
+	Errors   int
+	P50      time.Duration
+	P90      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+}
+
+//BenchResult is the result of a load test
+type BenchResult struct {
+	Duration time.Duration
+	Bump     BenchStats
+	Get      BenchStats
+}
+
+//Throughput returns the requests per second of the load test
+func (result BenchResult) Throughput() float64 {
+	if result.Duration <= 0 {
+		return 0
+	}
+
+	return float64(result.Bump.Requests+result.Get.Requests) / result.Duration.Seconds()
+}
+
+type benchSample struct {
+	bump    bool
+	latency time.Duration
+	failed  bool
+}
+
+//Bench alternates patch bumps and gets of the projects with the given concurrency until all requests are sent
+func Bench(client *http.Client, options BenchOptions) BenchResult {
+	if options.Projects <= 0 {
+		options.Projects = options.Concurrency
+	}
+	server := strings.TrimSuffix(options.Server, "/")
+
+	jobs := make(chan int)
+	samples := make(chan benchSample, options.Concurrency)
+	workers := sync.WaitGroup{}
+	for w := 0; w < options.Concurrency; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range jobs {
+				project := fmt.Sprintf("%v%v", options.Prefix, (i/2)%options.Projects)
+				bump := i%2 == 0
+				method, url := http.MethodGet, server+"/version/"+project
+				if bump {
+					method, url = http.MethodPost, server+"/patch/"+project
+				}
+				samples <- benchRequest(client, method, url, options.Token, bump)
+			}
+		}()
+	}
+
+	collected := make(chan BenchResult)
+	go func() {
+		bumps, gets := []benchSample{}, []benchSample{}
+		for sample := range samples {
+			if sample.bump {
+				bumps = append(bumps, sample)
+			} else {
+				gets = append(gets, sample)
+			}
+		}
+		collected <- BenchResult{Bump: benchStats(bumps), Get: benchStats(gets)}
+	}()
+
+	start := time.Now()
+	for i := 0; i < options.Requests; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	workers.Wait()
+	duration := time.Since(start)
+	close(samples)
+
+	result := <-collected
+	result.Duration = duration
+	return result
+}
+
+func benchRequest(client *http.Client, method string, url string, token string, bump bool) benchSample {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return benchSample{bump: bump, failed: true}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set(defaultClientHeader, "vbump-bench")
+
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return benchSample{bump: bump, latency: time.Since(start), failed: true}
+	}
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	return benchSample{bump: bump, latency: time.Since(start), failed: res.StatusCode >= http.StatusBadRequest}
+}
+
+func benchStats(samples []benchSample) BenchStats {
+	stats := BenchStats{Requests: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	latencies := []time.Duration{}
+	for _, sample := range samples {
+		if sample.failed {
+			stats.Errors++
+		}
+		latencies = append(latencies, sample.latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p int) time.Duration {
+		return latencies[(len(latencies)-1)*p/100]
+	}
+
+	stats.P50, stats.P90, stats.P99, stats.Max = percentile(50), percentile(90), percentile(99), latencies[len(latencies)-1]
+	return stats
+}
+
+//runBench runs the load test, writes a report and returns the exit code, 1 if requests failed
+func runBench(options BenchOptions, out io.Writer) int {
+	client := &http.Client{Timeout: 30 * time.Second, Transport: &http.Transport{MaxIdleConnsPerHost: options.Concurrency}}
+	result := Bench(client, options)
+
+	fmt.Fprintf(out, "%v requests in %v with concurrency %v: %.1f requests/s\n", options.Requests, result.Duration.Round(time.Millisecond), options.Concurrency, result.Throughput())
+	fmt.Fprintf(out, "%-5v %8v %8v %10v %10v %10v %10v\n", "", "requests", "errors", "p50", "p90", "p99", "max")
+	for _, row := range []struct {
+		name  string
+		stats BenchStats
+	}{{"bump", result.Bump}, {"get", result.Get}} {
+		fmt.Fprintf(out, "%-5v %8v %8v %10v %10v %10v %10v\n", row.name, row.stats.Requests, row.stats.Errors,
+			row.stats.P50.Round(time.Microsecond), row.stats.P90.Round(time.Microsecond), row.stats.P99.Round(time.Microsecond), row.stats.Max.Round(time.Microsecond))
+	}
+
+	if result.Bump.Errors+result.Get.Errors > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Bench(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	datadir, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(datadir)
+	version := NewVersion(adapter.New(datadir))
+	server := httptest.NewServer(NewHandler(version, nil).GetRouter())
+	defer server.Close()
+
+	result := Bench(http.DefaultClient, BenchOptions{Server: server.URL, Concurrency: 4, Requests: 40, Projects: 2, Prefix: "bench-"})
+	bumped, _ := version.GetVersion("bench-0")
+
+	Ω.Expect(result.Bump.Requests).To(Equal(20))
+	Ω.Expect(result.Get.Requests).To(Equal(20))
+	Ω.Expect(result.Bump.Errors + result.Get.Errors).To(Equal(0))
+	Ω.Expect(result.Bump.P50).To(BeNumerically("<=", result.Bump.P99))
+	Ω.Expect(result.Throughput()).To(BeNumerically(">", 0))
+	Ω.Expect(bumped).NotTo(BeEmpty())
+}
+
+func Test_Run_Bench_Reports_Errors(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	out := &bytes.Buffer{}
+
+	code := runBench(BenchOptions{Server: server.URL, Concurrency: 2, Requests: 4}, out)
+
+	Ω.Expect(code).To(Equal(1))
+	Ω.Expect(out.String()).To(ContainSubstring("bump"))
+	Ω.Expect(out.String()).To(ContainSubstring("requests/s"))
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	breakerClosed   = "closed"
+	breakerHalfOpen = "half-open"
+	breakerOpen     = "open"
+)
+
+//ErrStorageUnavailable is returned without calling the storage backend, while the circuit breaker is open
+var ErrStorageUnavailable = newError(ErrStorage, "Storage backend is unavailable")
+
+var breakerStates = map[string]float64{breakerClosed: 0, breakerHalfOpen: 1, breakerOpen: 2}
+
+//Breaker stops calling a storage backend, which consistently fails or is slow, until its cooldown is over
+type Breaker struct {
+	sync.Mutex
+	failures int
+	slow     time.Duration
+	cooldown time.Duration
+	now      func() time.Time
+
+	state       string
+	consecutive int
+	openedAt    time.Time
+}
+
+//NewBreaker constructs a circuit breaker, which opens after the given number of consecutive failures or calls slower than slow (0 never counts slow calls)
+func NewBreaker(failures int, slow time.Duration, cooldown time.Duration) *Breaker {
+	breaker := &Breaker{failures: failures, slow: slow, cooldown: cooldown, now: time.Now}
+	breaker.setState(breakerClosed)
+	return breaker
+}
+
+func (breaker *Breaker) setState(state string) {
+	breaker.state = state
+	breakerState.Set(breakerStates[state])
+}
+
+//Allow returns ErrStorageUnavailable, while the breaker is open, after the cooldown a single trial call is allowed
+func (breaker *Breaker) Allow() error {
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	switch breaker.state {
+	case breakerOpen:
+		if breaker.now().Sub(breaker.openedAt) < breaker.cooldown {
+			return ErrStorageUnavailable
+		}
+		breaker.setState(breakerHalfOpen)
+		return nil
+	case breakerHalfOpen:
+		// only the trial call may pass until it succeeded
+		return ErrStorageUnavailable
+	}
+
+	return nil
+}
+
+//Record records the result of a call, failures and slow calls open the breaker
+func (breaker *Breaker) Record(err error, took time.Duration) {
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	if err == nil && (breaker.slow <= 0 || took < breaker.slow) {
+		breaker.consecutive = 0
+		if breaker.state != breakerClosed {
+			breaker.setState(breakerClosed)
+		}
+		return
+	}
+
+	breaker.consecutive++
+	if breaker.state == breakerHalfOpen || breaker.consecutive >= breaker.failures {
+		breaker.openedAt = breaker.now()
+		breaker.setState(breakerOpen)
+	}
+}
+
+//State returns the state of the breaker: closed, half-open or open
+func (breaker *Breaker) State() string {
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	return breaker.state
+}
+
+//RetryAfter returns the time until the open breaker allows a trial call
+func (breaker *Breaker) RetryAfter() time.Duration {
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	if breaker.state != breakerOpen {
+		return 0
+	}
+	if wait := breaker.cooldown - breaker.now().Sub(breaker.openedAt); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+//guardedProvider calls the storage backend through the circuit breaker
+type guardedProvider struct {
+	provider adapter.IFileProvider
+	breaker  *Breaker
+}
+
+func (g *guardedProvider) call(f func() error) error {
+	if err := g.breaker.Allow(); err != nil {
+		return err
+	}
+
+	start := g.breaker.now()
+	err := f()
+	g.breaker.Record(err, g.breaker.now().Sub(start))
+	return err
+}
+
+func (g *guardedProvider) ReadVersion(project string) (version string, err error) {
+	err = g.call(func() error {
+		version, err = g.provider.ReadVersion(project)
+		return err
+	})
+	return version, err
+}
+
+func (g *guardedProvider) StoreVersion(project string, version string) error {
+	return g.call(func() error { return g.provider.StoreVersion(project, version) })
+}
+
+func (g *guardedProvider) ListProjects() (projects []string, err error) {
+	err = g.call(func() error {
+		projects, err = g.provider.ListProjects()
+		return err
+	})
+	return projects, err
+}
+
+func (g *guardedProvider) ReadDocument(kind string, project string) (document []byte, err error) {
+	err = g.call(func() error {
+		document, err = g.provider.ReadDocument(kind, project)
+		return err
+	})
+	return document, err
+}
+
+func (g *guardedProvider) StoreDocument(kind string, project string, document []byte) error {
+	return g.call(func() error { return g.provider.StoreDocument(kind, project, document) })
+}
+
+func (g *guardedProvider) DeleteDocument(kind string, project string) error {
+	return g.call(func() error { return g.provider.DeleteDocument(kind, project) })
+}
+
+func (g *guardedProvider) ListDocuments(kind string) (projects []string, err error) {
+	err = g.call(func() error {
+		projects, err = g.provider.ListDocuments(kind)
+		return err
+	})
+	return projects, err
+}
+
+func (g *guardedProvider) AppendHistory(project string, entry []byte) error {
+	return g.call(func() error { return g.provider.AppendHistory(project, entry) })
+}
+
+func (g *guardedProvider) ReadHistory(project string) (history [][]byte, err error) {
+	err = g.call(func() error {
+		history, err = g.provider.ReadHistory(project)
+		return err
+	})
+	return history, err
+}
+
+func (g *guardedProvider) ReplaceHistory(project string, entries [][]byte) error {
+	return g.call(func() error { return g.provider.ReplaceHistory(project, entries) })
+}
+
+func (g *guardedProvider) Namespace(namespace string) (adapter.IFileProvider, error) {
+	var provider adapter.IFileProvider
+	err := g.call(func() (err error) {
+		provider, err = g.provider.Namespace(namespace)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &guardedProvider{provider: provider, breaker: g.breaker}, nil
+}
+
+func (g *guardedProvider) ListNamespaces() (namespaces []string, err error) {
+	err = g.call(func() error {
+		namespaces, err = g.provider.ListNamespaces()
+		return err
+	})
+	return namespaces, err
+}
+
+//UseBreaker calls the storage backend through the circuit breaker
+func (v *Version) UseBreaker(breaker *Breaker) {
+	v.fileProvider = &guardedProvider{provider: v.fileProvider, breaker: breaker}
+}
+
+//SetBreaker fails requests fast with 503, while the circuit breaker of the storage backend is open
+func (handler *Handler) SetBreaker(breaker *Breaker) {
+	handler.breaker = breaker
+}
+
+//BreakerMiddleware rejects requests with 503 and Retry-After, while the circuit breaker is open
+func (handler *Handler) BreakerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if path := c.FullPath(); path == "" || path == "/" || path == "/metrics" || path == "/readyz" {
+			c.Next()
+			return
+		}
+
+		if wait := handler.breaker.RetryAfter(); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			_ = c.AbortWithError(http.StatusServiceUnavailable, errors.Wrapf(ErrStorageUnavailable, "Cannot serve %v", c.Request.URL.Path))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+//OnReady is a handler for the readiness check, vbump is not ready while the versions are preloaded, after a drain or the circuit breaker of the storage backend is open
+func (handler *Handler) OnReady(context *gin.Context) {
+	if handler.preloadingStatus(context) || handler.drainingStatus(context) {
+		return
+	}
+
+	state := breakerClosed
+	if handler.breaker != nil {
+		state = handler.breaker.State()
+	}
+
+	status := gin.H{"ready": state != breakerOpen, "storage": state}
+	if handler.diskGuard != nil {
+		// a read-only datadir still serves versions, so vbump stays ready
+		status["readOnly"] = handler.diskGuard.ReadOnly()
+	}
+
+	if state == breakerOpen {
+		if wait := handler.breaker.RetryAfter(); wait > 0 {
+			context.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		}
+		context.JSON(http.StatusServiceUnavailable, status)
+		return
+	}
+
+	context.JSON(http.StatusOK, status)
+}
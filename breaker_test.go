@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func Test_Breaker_Opens_After_Failures(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewBreaker(2, time.Second, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Record(errors.New("disk full"), 0)
+	Ω.Expect(breaker.State()).To(Equal("closed"))
+	breaker.Record(nil, 2*time.Second)
+	Ω.Expect(breaker.State()).To(Equal("open"))
+	Ω.Expect(breaker.Allow()).To(Equal(ErrStorageUnavailable))
+	Ω.Expect(breaker.RetryAfter()).To(Equal(time.Minute))
+
+	now = now.Add(time.Minute)
+	Ω.Expect(breaker.Allow()).To(BeNil())
+	Ω.Expect(breaker.State()).To(Equal("half-open"))
+	Ω.Expect(breaker.Allow()).To(Equal(ErrStorageUnavailable))
+	breaker.Record(nil, 0)
+	Ω.Expect(breaker.State()).To(Equal("closed"))
+}
+
+func Test_Breaker_Reopens_On_Failed_Trial(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewBreaker(1, 0, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Record(errors.New("disk full"), 0)
+	now = now.Add(time.Minute)
+	_ = breaker.Allow()
+	breaker.Record(errors.New("disk full"), 0)
+
+	Ω.Expect(breaker.State()).To(Equal("open"))
+	Ω.Expect(breaker.RetryAfter()).To(Equal(time.Minute))
+}
+
+func Test_Breaker_Fails_Requests_Fast(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	breaker := NewBreaker(1, 0, time.Minute)
+	version := NewVersion(&brokenProvider{adapter.NewMock("1.0.0", "p1")})
+	version.UseBreaker(breaker)
+	handler := NewHandler(version, nil)
+	handler.SetBreaker(breaker)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusInternalServerError))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+	Ω.Expect(res.Header().Get("Retry-After")).To(Equal("60"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"ready": false, "storage": "open"}`))
+}
+
+func Test_Ready_Without_Breaker(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"ready": true, "storage": "closed"}`))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/subtle"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+//breakGlassName is the actor of changes made with the break-glass token
+const breakGlassName = "break-glass"
+
+//BreakGlass accepts the token in the file as admin token, while the file exists and is younger than the ttl
+type BreakGlass struct {
+	file string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+//NewBreakGlass constructs a break-glass for the token file, it is enabled by creating the file during an incident
+func NewBreakGlass(file string, ttl time.Duration) *BreakGlass {
+	return &BreakGlass{file: file, ttl: ttl, now: time.Now}
+}
+
+//SetBreakGlass accepts the break-glass token in addition to the api tokens
+func (handler *Handler) SetBreakGlass(breakGlass *BreakGlass) {
+	handler.breakGlass = breakGlass
+}
+
+//Lookup returns the break-glass token for the secret or nil, if the secret differs or the file is missing or expired, expired tells the latter
+func (breakGlass *BreakGlass) Lookup(secret string) (*Token, bool) {
+	info, err := os.Stat(breakGlass.file)
+	if err != nil || secret == "" {
+		return nil, false
+	}
+	document, err := ioutil.ReadFile(breakGlass.file)
+	if err != nil {
+		return nil, false
+	}
+	expected := strings.TrimSpace(strings.SplitN(string(document), "\n", 2)[0])
+	if expected == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) != 1 {
+		return nil, false
+	}
+	if breakGlass.now().Sub(info.ModTime()) > breakGlass.ttl {
+		// the token is known, but expired
+		return nil, true
+	}
+
+	return &Token{Name: breakGlassName, scopes: []string{globalScope}, breakGlass: true}, false
+}
+
+//lookupBreakGlass authenticates the secret with the break-glass token, uses of expired tokens are audited as well
+func (handler *Handler) lookupBreakGlass(c *gin.Context, secret string) *Token {
+	if handler.breakGlass == nil {
+		return nil
+	}
+
+	token, expired := handler.breakGlass.Lookup(secret)
+	if expired {
+		handler.auditLog().WithField("actor", breakGlassName).Warnf("expired break-glass token rejected for %v %v from %v", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+	}
+	return token
+}
+
+//auditBreakGlass audits every request made with the break-glass token
+func (handler *Handler) auditBreakGlass(c *gin.Context) {
+	handler.auditLog().WithField("actor", breakGlassName).Warnf("break-glass %v %v from %v with status %v", c.Request.Method, c.Request.URL.Path, c.ClientIP(), c.Writer.Status())
+}
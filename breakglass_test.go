@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+func newBreakGlassRouter(file string, ttl time.Duration) (*Handler, *bytes.Buffer) {
+	audited := &bytes.Buffer{}
+	audit := log.New()
+	audit.Out = audited
+	tokens := NewTokenStore()
+	tokens.Add("ci", "ci-secret", "ns:team")
+	handler := NewHandler(NewVersion(adapter.NewMock("1.2.3", "p1")), nil)
+	handler.SetTokenStore(tokens)
+	handler.SetAuditLogger(audit)
+	handler.SetBreakGlass(NewBreakGlass(file, ttl))
+
+	return handler, audited
+}
+
+func decrementWith(router http.Handler, token string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/decrement/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(res, req)
+	return res
+}
+
+func Test_Break_Glass_Token(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "breakglass")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "break-glass")
+	handler, audited := newBreakGlassRouter(file, time.Hour)
+	router := handler.GetRouter()
+
+	Ω.Expect(decrementWith(router, "incident").Code).To(Equal(401))
+
+	_ = ioutil.WriteFile(file, []byte("incident\n"), 0600)
+	Ω.Expect(decrementWith(router, "ci-secret").Code).To(Equal(403))
+	Ω.Expect(decrementWith(router, "wrong").Code).To(Equal(401))
+	res := decrementWith(router, "incident")
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("1.2.2"))
+	Ω.Expect(audited.String()).To(ContainSubstring("break-glass POST /decrement/patch/p1"))
+	Ω.Expect(audited.String()).To(ContainSubstring("with status 200"))
+
+	os.Remove(file)
+	Ω.Expect(decrementWith(router, "incident").Code).To(Equal(401))
+}
+
+func Test_Expired_Break_Glass_Token(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "breakglass")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "break-glass")
+	_ = ioutil.WriteFile(file, []byte("incident"), 0600)
+	handler, audited := newBreakGlassRouter(file, time.Hour)
+	handler.breakGlass.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	Ω.Expect(decrementWith(handler.GetRouter(), "incident").Code).To(Equal(401))
+	Ω.Expect(audited.String()).To(ContainSubstring("expired break-glass token rejected"))
+}
+
+func Test_Break_Glass_Token_Skips_Policy_Agent(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "breakglass")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "break-glass")
+	_ = ioutil.WriteFile(file, []byte("incident"), 0600)
+	handler, _ := newBreakGlassRouter(file, time.Hour)
+	handler.SetPolicyAgent(NewPolicyAgent("http://127.0.0.1:1/v1/data/vbump/allow", time.Second))
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer ci-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(503))
+
+	Ω.Expect(decrementWith(router, "incident").Code).To(Equal(200))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+)
+
+//BulkProject is a project of a manifest to create, the version and metadata override the settings of the template
+type BulkProject struct {
+	Name     string          `json:"name"`
+	Version  string          `json:"version,omitempty"`
+	Template string          `json:"template,omitempty"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+//BulkManifest is a list of projects to create in one call
+type BulkManifest struct {
+	Projects []BulkProject `json:"projects" binding:"required"`
+}
+
+//Names returns the distinct project names of the manifest
+func (manifest *BulkManifest) Names() []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, project := range manifest.Projects {
+		if project.Name != "" && !seen[project.Name] {
+			seen[project.Name] = true
+			names = append(names, project.Name)
+		}
+	}
+
+	return names
+}
+
+//BulkResult tells whether a project of the manifest was created, already existed or failed
+type BulkResult struct {
+	Project string `json:"project"`
+	Status  string `json:"status"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	entry *HistoryEntry
+}
+
+//CreateProjects creates every project of the manifest on its own, a failing project doesn't stop the others
+func (v *Version) CreateProjects(manifest *BulkManifest) []BulkResult {
+	results := []BulkResult{}
+	for _, project := range manifest.Projects {
+		result := BulkResult{Project: project.Name}
+		entry, err := v.createBulkProject(project)
+		switch {
+		case err == nil:
+			result.Status, result.Version, result.entry = "created", entry.Version, entry
+		case errors.Is(err, ErrProjectExists):
+			result.Status = "exists"
+		default:
+			result.Status, result.Error = "failed", err.Error()
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (v *Version) createBulkProject(project BulkProject) (*HistoryEntry, error) {
+	if project.Name == "" {
+		return nil, errors.New("Invalid project without name")
+	}
+
+	version, meta := project.Version, (*Metadata)(nil)
+	if project.Template != "" {
+		template, err := v.projectTemplate(project.Template)
+		if err != nil {
+			return nil, err
+		}
+		meta = &Metadata{Config: template.Config}
+		if version == "" {
+			version = template.Version
+		}
+	}
+	if len(project.Metadata) != 0 {
+		if meta == nil {
+			meta = &Metadata{}
+		}
+		// the metadata overrides the settings of the template field by field
+		if err := json.Unmarshal(project.Metadata, meta); err != nil {
+			return nil, errors.Wrapf(err, "Invalid metadata of project %v", project.Name)
+		}
+	}
+
+	return v.Create(project.Name, version, meta)
+}
+
+//OnCreateProjects is a handler for creating all projects of a manifest
+func (handler *Handler) OnCreateProjects(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	manifest := &BulkManifest{}
+	if err := context.ShouldBindBodyWith(manifest, binding.JSON); err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid manifest"))
+		return
+	}
+	for _, project := range manifest.Projects {
+		if err := validateProjectName(project.Name); project.Name != "" && err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid manifest"))
+			return
+		}
+	}
+
+	results := service.CreateProjects(manifest)
+	created := 0
+	for _, result := range results {
+		if result.entry == nil {
+			continue
+		}
+		created++
+		countClientChange(result.entry)
+		handler.publish(context.Param("namespace"), result.Project, service, result.entry)
+	}
+
+	handler.changeLog(context).Infof("create %v of %v projects of a manifest", created, len(results))
+	context.JSON(http.StatusOK, gin.H{"created": created, "projects": results})
+}
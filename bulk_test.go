@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Create_Projects_From_Manifest(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.AddProjectTemplate("service-default", ProjectTemplate{Version: "0.1.0", Config: Config{Prefix: "v"}})
+
+	results := version.CreateProjects(&BulkManifest{Projects: []BulkProject{
+		{Name: "p1"},
+		{Name: "p2", Template: "service-default"},
+		{Name: "p3", Version: "2.0.0", Template: "service-default", Metadata: json.RawMessage(`{"owner": "payments"}`)},
+		{Name: "p4", Template: "unknown"},
+		{Name: ""},
+	}})
+
+	Ω.Expect(results).To(HaveLen(5))
+	Ω.Expect(results[0].Status).To(Equal("exists"))
+	Ω.Expect(results[1].Status).To(Equal("created"))
+	Ω.Expect(results[1].Version).To(Equal("0.1.0"))
+	Ω.Expect(results[2].Version).To(Equal("2.0.0"))
+	Ω.Expect(results[3].Status).To(Equal("failed"))
+	Ω.Expect(results[4].Status).To(Equal("failed"))
+	meta, _ := version.GetMetadata("p3")
+	Ω.Expect(meta.Owner).To(Equal("payments"))
+	Ω.Expect(meta.Prefix).To(Equal("v"))
+	exists, _ := version.Exists("p4")
+	Ω.Expect(exists).To(BeFalse())
+}
+
+func Test_Create_Projects_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/projects/bulk", bytes.NewBufferString(`{"projects": [{"name": "p1", "version": "1.2.0"}, {"name": "p2"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`"created":2`))
+	current, _ := version.GetVersion("p1")
+	Ω.Expect(current).To(Equal("1.2.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/projects/bulk", bytes.NewBufferString(`{"projects": "p1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
+func Test_Create_Projects_Rejects_Escaping_Names(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "bulk")
+	defer os.RemoveAll(dir)
+	datadir := filepath.Join(dir, "data")
+	router := NewHandler(NewVersion(adapter.New(datadir)), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/projects/bulk", bytes.NewBufferString(`{"projects": [{"name": "../escaped"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(400))
+	Ω.Expect(filepath.Join(dir, "escaped")).NotTo(BeAnExistingFile())
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+)
+
+var validPrerelease = regexp.MustCompile(`^[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*$`)
+
+//BumpRequest bumps several elements and sets a prerelease in a single change
+type BumpRequest struct {
+	Elements   []string `json:"elements"`
+	Prerelease string   `json:"prerelease,omitempty"`
+}
+
+//element returns the name of the change recorded in the history, e.g. "minor+patch"
+func (request BumpRequest) element() string {
+	element := strings.Join(request.Elements, "+")
+	if request.Prerelease != "" {
+		element += "+prerelease"
+	}
+
+	return strings.TrimPrefix(element, "+")
+}
+
+//BumpElements bumps the elements in order and appends the prerelease to the given project in a single change
+func (v *Version) BumpElements(project string, request BumpRequest) (*HistoryEntry, error) {
+	if len(request.Elements) == 0 && request.Prerelease == "" {
+		return nil, errors.New("Nothing to bump")
+	}
+	if request.Prerelease != "" && !validPrerelease.MatchString(request.Prerelease) {
+		return nil, invalidVersionf("%v is not a valid prerelease", request.Prerelease)
+	}
+	if err := v.checkUnreserved(project); err != nil {
+		return nil, err
+	}
+
+	entry, err := v.change(project, request.element(), func(currentVersion string) (string, error) {
+		if err := checkStoredVersion(project, currentVersion); err != nil {
+			return "", err
+		}
+		next := currentVersion
+		if request.Prerelease != "" && len(request.Elements) == 0 {
+			next = releaseOf(currentVersion)
+		}
+		for _, element := range request.Elements {
+			var err error
+			if next, err = nextVersion(next, element, 1); err != nil {
+				return "", err
+			}
+		}
+		if request.Prerelease != "" {
+			next += "-" + request.Prerelease
+		}
+		return next, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot bump %v on project %v", request.element(), project)
+	}
+
+	return entry, nil
+}
+
+//OnBump is a handler for bumping several elements of a given project at once, from the path or a JSON body
+func (handler *Handler) OnBump(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	request := BumpRequest{Prerelease: context.Query("prerelease")}
+	if strings.HasPrefix(context.ContentType(), "application/json") {
+		if err := context.ShouldBindBodyWith(&request, binding.JSON); err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid bump request"))
+			return
+		}
+	}
+	for _, element := range strings.Split(context.Param("elements"), "/") {
+		if element != "" {
+			request.Elements = append(request.Elements, element)
+		}
+	}
+
+	entry, err := service.BumpElements(context.Param("project"), request)
+	if err != nil {
+		countFailure(context.Param("namespace"), context.Param("project"), "bump", err)
+		abortChange(context, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	for _, element := range request.Elements {
+		countBump(context.Param("namespace"), context.Param("project"), element)
+	}
+	handler.changed(context, service, entry)
+	handler.changeLog(context).Infof("bump %v version to %v on project %v", entry.Element, entry.Version, projectKey(context))
+	context.String(http.StatusOK, "%s", service.Display(context.Param("project"), entry.Version))
+}
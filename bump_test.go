@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Bump_Elements(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for _, example := range []struct {
+		request  BumpRequest
+		expected string
+		element  string
+	}{
+		{BumpRequest{Elements: []string{"minor", "patch"}}, "1.3.1", "minor+patch"},
+		{BumpRequest{Elements: []string{"minor"}, Prerelease: "rc.1"}, "1.3.0-rc.1", "minor+prerelease"},
+		{BumpRequest{Prerelease: "beta"}, "1.2.3-beta", "prerelease"},
+	} {
+		version := NewVersion(adapter.NewMock("1.2.3", "p1"))
+		entry, err := version.BumpElements("p1", example.request)
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(entry.Version).To(Equal(example.expected))
+		Ω.Expect(entry.Element).To(Equal(example.element))
+	}
+}
+
+func Test_Bump_Invalid_Elements(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.3", "p1"))
+
+	_, errEmpty := version.BumpElements("p1", BumpRequest{})
+	_, errElement := version.BumpElements("p1", BumpRequest{Elements: []string{"minor", "build"}})
+	_, errPrerelease := version.BumpElements("p1", BumpRequest{Prerelease: "rc 1"})
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(errEmpty).NotTo(BeNil())
+	Ω.Expect(errElement).NotTo(BeNil())
+	Ω.Expect(errPrerelease).NotTo(BeNil())
+	Ω.Expect(current).To(Equal("1.2.3"))
+}
+
+func Test_Bump_Elements_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.3", "p1"))
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/bump/p1/major/minor?prerelease=rc.1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("2.1.0-rc.1"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/bump/p1", bytes.NewBufferString(`{"elements": ["minor"], "prerelease": "rc.2", "reason": "release candidate"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+	history, _ := version.History("p1")
+	Ω.Expect(res.Body.String()).To(Equal("2.1.0-rc.2"))
+	Ω.Expect(history[1].Reason).To(Equal("release candidate"))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"maibornwolff/vbump/adapter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//versionCache keeps the versions of the projects of all namespaces by namespace/project,
+//the generation of a key counts its writes, so a read started before a write doesn't fill in its older version
+type versionCache struct {
+	sync.RWMutex
+	versions    map[string]string
+	generations map[string]uint64
+}
+
+//cachingProvider reads versions from memory and writes them through to the storage backend
+type cachingProvider struct {
+	adapter.IFileProvider
+	namespace string
+	cache     *versionCache
+}
+
+//CacheVersions keeps the versions in memory, the datadir must not be changed by anyone else
+func (v *Version) CacheVersions() {
+	v.fileProvider = &cachingProvider{IFileProvider: v.fileProvider, cache: &versionCache{versions: map[string]string{}, generations: map[string]uint64{}}}
+}
+
+func (c *cachingProvider) ReadVersion(project string) (string, error) {
+	key := c.namespace + "/" + project
+	c.cache.RLock()
+	version, cached := c.cache.versions[key]
+	generation := c.cache.generations[key]
+	c.cache.RUnlock()
+	if cached {
+		return version, nil
+	}
+
+	version, err := c.IFileProvider.ReadVersion(project)
+	if err != nil {
+		return "", err
+	}
+	c.cache.Lock()
+	defer c.cache.Unlock()
+	if _, cached := c.cache.versions[key]; !cached && c.cache.generations[key] == generation {
+		c.cache.versions[key] = version
+	}
+
+	return version, nil
+}
+
+func (c *cachingProvider) StoreVersion(project string, version string) error {
+	key := c.namespace + "/" + project
+	if err := c.IFileProvider.StoreVersion(project, version); err != nil {
+		// a failed write may still have changed the file, it is read again
+		c.cache.Lock()
+		delete(c.cache.versions, key)
+		c.cache.generations[key]++
+		c.cache.Unlock()
+		return err
+	}
+
+	c.cache.Lock()
+	c.cache.versions[key] = version
+	c.cache.generations[key]++
+	c.cache.Unlock()
+	return nil
+}
+
+func (c *cachingProvider) Namespace(namespace string) (adapter.IFileProvider, error) {
+	provider, err := c.IFileProvider.Namespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachingProvider{IFileProvider: provider, namespace: namespace, cache: c.cache}, nil
+}
+
+//PreloadVersions reads the versions of all projects of all namespaces into the cache and returns their number
+func (v *Version) PreloadVersions() (int, error) {
+	count, err := v.preloadVersions()
+	if err != nil {
+		return count, err
+	}
+
+	namespaces, err := v.fileProvider.ListNamespaces()
+	if err != nil {
+		return count, errors.Wrap(err, "Cannot list namespaces")
+	}
+	for _, namespace := range namespaces {
+		namespaced, err := v.Namespace(namespace)
+		if err != nil {
+			return count, err
+		}
+		preloaded, err := namespaced.preloadVersions()
+		count += preloaded
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+func (v *Version) preloadVersions() (int, error) {
+	projects, err := v.Projects()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, project := range projects {
+		if _, err := v.fileProvider.ReadVersion(project); err != nil {
+			return i, errors.Wrapf(err, "Cannot preload version of project %v", project)
+		}
+	}
+
+	return len(projects), nil
+}
+
+//StartPreload preloads the versions in the background, vbump is not ready until they are loaded
+func (handler *Handler) StartPreload() {
+	atomic.StoreInt32(&handler.preloading, 1)
+	go func() {
+		defer atomic.StoreInt32(&handler.preloading, 0)
+		count, err := handler.version.PreloadVersions()
+		if err != nil {
+			// the missing versions are read on their first request
+			handler.logger.Error(err)
+		}
+		handler.logger.Infof("preloaded the versions of %v projects", count)
+	}()
+}
+
+//preloadingStatus answers the readiness check with 503, while the versions are preloaded
+func (handler *Handler) preloadingStatus(context *gin.Context) bool {
+	if atomic.LoadInt32(&handler.preloading) == 0 {
+		return false
+	}
+
+	context.JSON(http.StatusServiceUnavailable, gin.H{"ready": false, "preloading": true})
+	return true
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+type countingProvider struct {
+	adapter.IFileProvider
+	reads *int32
+}
+
+func (provider *countingProvider) ReadVersion(project string) (string, error) {
+	atomic.AddInt32(provider.reads, 1)
+	return provider.IFileProvider.ReadVersion(project)
+}
+
+func (provider *countingProvider) Namespace(namespace string) (adapter.IFileProvider, error) {
+	namespaced, err := provider.IFileProvider.Namespace(namespace)
+	return &countingProvider{IFileProvider: namespaced, reads: provider.reads}, err
+}
+
+func Test_Preload_Versions_Of_All_Namespaces(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	mock := adapter.NewMock("1.0.0", "p1")
+	namespaced, _ := mock.Namespace("team")
+	_ = namespaced.StoreVersion("p2", "2.0.0")
+	reads := int32(0)
+	version := NewVersion(&countingProvider{IFileProvider: mock, reads: &reads})
+	version.CacheVersions()
+
+	count, err := version.PreloadVersions()
+	Ω.Expect(err).NotTo(HaveOccurred())
+	Ω.Expect(count).To(Equal(2))
+	Ω.Expect(atomic.LoadInt32(&reads)).To(Equal(int32(2)))
+
+	router := NewHandler(version, nil).GetRouter()
+	for _, path := range []string{"/version/p1", "/ns/team/version/p2"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	}
+	Ω.Expect(atomic.LoadInt32(&reads)).To(Equal(int32(2)))
+}
+
+func Test_Cached_Versions_Are_Written_Through(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	mock := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(mock)
+	version.CacheVersions()
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/p1", nil)
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+
+	stored, _ := mock.ReadVersion("p1")
+	Ω.Expect(stored).To(Equal("1.1.0"))
+	Ω.Expect(res.Body.String()).To(Equal("1.1.0"))
+}
+
+func Test_Not_Ready_While_Preloading(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	atomic.StoreInt32(&handler.preloading, 1)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"ready": false, "preloading": true}`))
+
+	atomic.StoreInt32(&handler.preloading, 0)
+	res = httptest.NewRecorder()
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+}
+
+type gatedProvider struct {
+	adapter.IFileProvider
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (provider *gatedProvider) ReadVersion(project string) (string, error) {
+	version, err := provider.IFileProvider.ReadVersion(project)
+	provider.read <- struct{}{}
+	<-provider.release
+	return version, err
+}
+
+func Test_Cache_Miss_Racing_A_Bump_Keeps_The_Bumped_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	mock := adapter.NewMock("1.0.0", "p1")
+	provider := &gatedProvider{IFileProvider: mock, read: make(chan struct{}), release: make(chan struct{})}
+	version := NewVersion(provider)
+	version.CacheVersions()
+
+	done := make(chan string)
+	go func() {
+		stale, _ := version.fileProvider.ReadVersion("p1")
+		done <- stale
+	}()
+	<-provider.read
+	// the bump stores its version, while the read of the older version is still pending
+	Ω.Expect(version.fileProvider.StoreVersion("p1", "1.0.1")).To(Succeed())
+	close(provider.release)
+	Ω.Expect(<-done).To(Equal("1.0.0"))
+
+	current, err := version.fileProvider.ReadVersion("p1")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(current).To(Equal("1.0.1"))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"maibornwolff/vbump/adapter"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	//ErrTooManyProjects is returned when creating a project, while the datadir holds the maximum number of projects
+	ErrTooManyProjects = newError(ErrStorage, "maximum number of projects reached")
+	//ErrProjectTooLarge is returned when changing a project, whose version, documents and history exceed the maximum size
+	ErrProjectTooLarge = newError(ErrStorage, "project exceeds its maximum storage size")
+)
+
+//capacity limits the number of projects of all namespaces and the storage size of every project, zero means unlimited
+type capacity struct {
+	root           adapter.IFileProvider
+	maxProjects    int
+	maxProjectSize int64
+}
+
+//LimitStorage limits the number of projects in all namespaces and the size of a single project in bytes, zero means unlimited
+func (v *Version) LimitStorage(maxProjects int, maxProjectSize int64) {
+	if maxProjects <= 0 && maxProjectSize <= 0 {
+		v.capacity = nil
+		return
+	}
+
+	v.capacity = &capacity{root: v.fileProvider, maxProjects: maxProjects, maxProjectSize: maxProjectSize}
+}
+
+//checkCapacity returns an error, if creating or changing the project would exceed the storage limits
+func (v *Version) checkCapacity(project string, creating bool) error {
+	if v.capacity == nil {
+		return nil
+	}
+
+	if creating && v.capacity.maxProjects > 0 {
+		count, err := v.capacity.countProjects()
+		if err != nil {
+			return err
+		}
+		if count >= v.capacity.maxProjects {
+			return errors.Wrapf(ErrTooManyProjects, "Cannot create project %v, the limit is %v projects", project, v.capacity.maxProjects)
+		}
+	}
+
+	if !creating && v.capacity.maxProjectSize > 0 {
+		size, err := v.projectSize(project)
+		if err != nil {
+			return err
+		}
+		if size >= v.capacity.maxProjectSize {
+			return errors.Wrapf(ErrProjectTooLarge, "Cannot change project %v with %v bytes, the limit is %v bytes", project, size, v.capacity.maxProjectSize)
+		}
+	}
+
+	return nil
+}
+
+//countProjects returns the number of projects in the default and all other namespaces
+func (c *capacity) countProjects() (int, error) {
+	projects, err := c.root.ListProjects()
+	if err != nil {
+		return 0, errors.Wrap(err, "Cannot count projects")
+	}
+	count := len(projects)
+
+	namespaces, err := c.root.ListNamespaces()
+	if err != nil {
+		return 0, errors.Wrap(err, "Cannot count projects")
+	}
+	for _, namespace := range namespaces {
+		provider, err := c.root.Namespace(namespace)
+		if err != nil {
+			return 0, errors.Wrapf(err, "Cannot count projects of namespace %v", namespace)
+		}
+		projects, err := provider.ListProjects()
+		if err != nil {
+			return 0, errors.Wrapf(err, "Cannot count projects of namespace %v", namespace)
+		}
+		count += len(projects)
+	}
+
+	return count, nil
+}
+
+//projectSize returns the bytes stored for the version, the documents and the history of the project
+func (v *Version) projectSize(project string) (int64, error) {
+	version, err := v.fileProvider.ReadVersion(project)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Cannot get size of project %v", project)
+	}
+	size := int64(len(version))
+
+	for _, kind := range documentKinds {
+		document, err := v.fileProvider.ReadDocument(kind, project)
+		if err != nil {
+			return 0, errors.Wrapf(err, "Cannot get size of project %v", project)
+		}
+		size += int64(len(document))
+	}
+
+	lines, err := v.fileProvider.ReadHistory(project)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Cannot get size of project %v", project)
+	}
+	for _, line := range lines {
+		size += int64(len(line)) + 1
+	}
+
+	return size, nil
+}
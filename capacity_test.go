@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Max_Projects_Of_All_Namespaces(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.LimitStorage(2, 0)
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/patch/p2", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+
+	for _, path := range []string{"/patch/p3", "/ns/team/patch/p3", "/project/p3"} {
+		res = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(http.StatusForbidden), path)
+	}
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+}
+
+func Test_Max_Project_Size(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.LimitStorage(0, 300)
+	router := NewHandler(version, nil).GetRouter()
+
+	status := http.StatusOK
+	for i := 0; i < 10 && status == http.StatusOK; i++ {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/patch/p1", nil)
+		router.ServeHTTP(res, req)
+		status = res.Code
+	}
+	Ω.Expect(status).To(Equal(http.StatusInsufficientStorage))
+
+	size, err := version.projectSize("p1")
+	Ω.Expect(err).NotTo(HaveOccurred())
+	Ω.Expect(size).To(BeNumerically(">=", 300))
+	Ω.Expect(size).To(BeNumerically("<", 600))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+const otherProjects = "other"
+
+//ProjectLabels limits the cardinality of the project label of the metrics
+type ProjectLabels struct {
+	sync.Mutex
+	max  int
+	hash bool
+	drop bool
+	seen map[string]bool
+}
+
+//projectLabels is shared by all metrics with a project label, it is unlimited by default
+var projectLabels = NewProjectLabels(0, false)
+
+//NewProjectLabels constructs project labels, which aggregate all projects beyond max (0 is unlimited) into "other" and optionally hash the project names
+func NewProjectLabels(max int, hash bool) *ProjectLabels {
+	return &ProjectLabels{max: max, hash: hash, seen: map[string]bool{}}
+}
+
+//Disable aggregates all projects into one series without project label
+func (labels *ProjectLabels) Disable() {
+	labels.drop = true
+}
+
+//Disabled returns true, if the metrics have no project label
+func (labels *ProjectLabels) Disabled() bool {
+	return labels.drop
+}
+
+//Of returns the metric label of the project, the first max projects keep their own label
+func (labels *ProjectLabels) Of(namespace string, project string) string {
+	if labels.drop {
+		return ""
+	}
+	label := project
+	if labels.hash {
+		sum := sha256.Sum256([]byte(project))
+		label = hex.EncodeToString(sum[:4])
+	}
+	if labels.max <= 0 {
+		return label
+	}
+
+	key := namespace + "/" + label
+	labels.Lock()
+	defer labels.Unlock()
+	if !labels.seen[key] {
+		if len(labels.seen) >= labels.max {
+			return otherProjects
+		}
+		labels.seen[key] = true
+	}
+
+	return label
+}
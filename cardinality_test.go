@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Project_Labels_Unlimited(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	labels := NewProjectLabels(0, false)
+
+	Ω.Expect(labels.Of("", "p1")).To(Equal("p1"))
+	Ω.Expect(labels.Of("payments", "p2")).To(Equal("p2"))
+}
+
+func Test_Project_Labels_Aggregate_Beyond_Max(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	labels := NewProjectLabels(2, false)
+
+	Ω.Expect(labels.Of("", "p1")).To(Equal("p1"))
+	Ω.Expect(labels.Of("payments", "p1")).To(Equal("p1"))
+	Ω.Expect(labels.Of("", "p2")).To(Equal("other"))
+	Ω.Expect(labels.Of("", "p1")).To(Equal("p1"))
+}
+
+func Test_Project_Labels_Hashed(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	labels := NewProjectLabels(0, true)
+
+	Ω.Expect(labels.Of("", "p1")).To(HaveLen(8))
+	Ω.Expect(labels.Of("", "p1")).To(Equal(labels.Of("", "p1")))
+	Ω.Expect(labels.Of("", "p1")).NotTo(Equal(labels.Of("", "p2")))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var (
+	conventionalCommit = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?:\s*(.+)$`)
+	changelogSections  = []struct {
+		title string
+		types []string
+	}{
+		{"Breaking Changes", nil},
+		{"Features", []string{"feat"}},
+		{"Bug Fixes", []string{"fix"}},
+		{"Performance", []string{"perf"}},
+		{"Other Changes", nil},
+	}
+)
+
+//ChangelogRequest is the json body of a changelog request
+type ChangelogRequest struct {
+	Commits []string `json:"commits"`
+}
+
+//Changelog renders commit messages grouped by their conventional commit type as a markdown section for the version
+func Changelog(version string, date time.Time, commits []string) string {
+	grouped := map[string][]string{}
+	for _, commit := range commits {
+		subject := strings.TrimSpace(strings.SplitN(commit, "\n", 2)[0])
+		if subject == "" {
+			continue
+		}
+
+		section := "Other Changes"
+		if match := conventionalCommit.FindStringSubmatch(subject); match != nil {
+			section = sectionOf(match[1])
+			if match[2] != "" {
+				subject = "**" + strings.Trim(match[2], "()") + ":** " + match[4]
+			} else {
+				subject = match[4]
+			}
+			if match[3] == "!" {
+				section = "Breaking Changes"
+			}
+		}
+		if strings.Contains(commit, "BREAKING CHANGE") {
+			section = "Breaking Changes"
+		}
+		grouped[section] = append(grouped[section], subject)
+	}
+
+	changelog := fmt.Sprintf("## %v (%v)\n", version, date.Format("2006-01-02"))
+	for _, section := range changelogSections {
+		if len(grouped[section.title]) == 0 {
+			continue
+		}
+		changelog += fmt.Sprintf("\n### %v\n\n", section.title)
+		for _, subject := range grouped[section.title] {
+			changelog += fmt.Sprintf("- %v\n", subject)
+		}
+	}
+
+	return changelog
+}
+
+func sectionOf(commitType string) string {
+	for _, section := range changelogSections {
+		for _, t := range section.types {
+			if t == strings.ToLower(commitType) {
+				return section.title
+			}
+		}
+	}
+
+	return "Other Changes"
+}
+
+//readCommits reads commit messages from a json body or a plain text body with one commit subject per line
+func readCommits(context *gin.Context) ([]string, error) {
+	if strings.HasPrefix(context.ContentType(), "application/json") {
+		request := ChangelogRequest{}
+		if err := context.ShouldBindJSON(&request); err != nil {
+			return nil, errors.Wrap(err, "Invalid changelog request")
+		}
+		return request.Commits, nil
+	}
+
+	commits := []string{}
+	scanner := bufio.NewScanner(context.Request.Body)
+	for scanner.Scan() {
+		commits = append(commits, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Cannot read commits")
+	}
+
+	return commits, nil
+}
+
+//OnChangelog is a handler for rendering a changelog draft of the given commits for the current or requested version
+func (handler *Handler) OnChangelog(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	commits, err := readCommits(context)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	version := context.Query("version")
+	if version == "" {
+		version, err = service.GetVersion(context.Param("project"))
+		if err != nil {
+			_ = context.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		version = service.Display(context.Param("project"), version)
+	}
+	if version == "" {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("Project %v does not exist", projectKey(context)))
+		return
+	}
+
+	context.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(Changelog(version, service.now(), commits)))
+}
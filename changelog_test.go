@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Changelog_Groups_Conventional_Commits(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	actual := Changelog("1.4.0", date, []string{
+		"feat(api): add stats endpoint",
+		"fix: handle empty versions",
+		"chore: update deps",
+		"feat!: drop transient endpoints",
+		"refactor: simplify parsing\n\nBREAKING CHANGE: parts are required",
+		"",
+		"Merge branch 'main'",
+	})
+
+	Ω.Expect(actual).To(Equal(`## 1.4.0 (2024-05-01)
+
+### Breaking Changes
+
+- drop transient endpoints
+- simplify parsing
+
+### Features
+
+- **api:** add stats endpoint
+
+### Bug Fixes
+
+- handle empty versions
+
+### Other Changes
+
+- update deps
+- Merge branch 'main'
+`))
+}
+
+func Test_Changelog_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+	version.now = func() time.Time { return time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC) }
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/changelog/p1", strings.NewReader("fix: one\nfix: two\n"))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("## 1.2.0 (2024-05-01)\n\n### Bug Fixes\n\n- one\n- two\n"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/changelog/p1?version=1.3.0", strings.NewReader(`{"commits":["feat: three"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("## 1.3.0 (2024-05-01)\n\n### Features\n\n- three\n"))
+}
+
+func Test_Changelog_Shows_Version_With_Prefix(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+	version.now = func() time.Time { return time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC) }
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Prefix: "v"}})
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/changelog/p1", strings.NewReader("fix: one"))
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(HavePrefix("## v1.2.0 (2024-05-01)"))
+}
+
+func Test_Changelog_For_Unknown_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.2.0", "p1")), nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/changelog/p2", strings.NewReader("fix: one"))
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(404))
+}
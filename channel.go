@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const channelDocument = "channel"
+
+var (
+	validChannel = regexp.MustCompile("^[A-Za-z][A-Za-z0-9-]*$")
+	channelBuild = regexp.MustCompile(`^-([A-Za-z][A-Za-z0-9-]*)\.([1-9]\d*)$`)
+
+	//ErrUnknownBuild is returned when promoting a build, which was not handed out by its channel
+	ErrUnknownBuild = newError(ErrNotFound, "build was not handed out by the channel")
+	//ErrBuildReleased is returned when promoting a build of a version, which is already released
+	ErrBuildReleased = newError(ErrConflict, "build is not ahead of the current version")
+)
+
+//Channel counts the builds of a prerelease channel against the upcoming version of a project
+type Channel struct {
+	Base    string `json:"base"`
+	Counter int    `json:"counter"`
+	Version string `json:"version"`
+}
+
+//Channels returns the prerelease channels of the given project by name
+func (v *Version) Channels(project string) (map[string]Channel, error) {
+	channels := map[string]Channel{}
+	document, err := v.fileProvider.ReadDocument(channelDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get channels for project %v", project)
+	}
+	if document == nil {
+		return channels, nil
+	}
+
+	if err := json.Unmarshal(document, &channels); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse channels for project %v", project)
+	}
+
+	return channels, nil
+}
+
+//NextBuild hands out the next build of the channel without changing the project, the element sets the upcoming version,
+//otherwise the channel keeps its upcoming version until it is released and continues with the next patch
+func (v *Version) NextBuild(project string, name string, element string) (*Channel, error) {
+	return v.countBuild(project, name, func(current string, channel Channel) (string, error) {
+		if element != "" {
+			return nextVersion(current, element, 1)
+		}
+		if isAhead(channel.Base, current) {
+			return channel.Base, nil
+		}
+		return nextVersion(current, "patch", 1)
+	})
+}
+
+//Promote hands out the next build of the channel for the upcoming version of the given build of another channel
+func (v *Version) Promote(project string, build string, name string) (*Channel, error) {
+	base, from, counter, err := v.parseBuild(project, build)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := v.Channels(project)
+	if err != nil {
+		return nil, err
+	}
+	if channel, exists := channels[from]; !exists || channel.Base != base || channel.Counter < counter {
+		return nil, errors.Wrapf(ErrUnknownBuild, "Cannot promote build %v of project %v", build, project)
+	}
+
+	return v.countBuild(project, name, func(current string, channel Channel) (string, error) {
+		if !isAhead(base, current) {
+			return "", errors.Wrapf(ErrBuildReleased, "Cannot promote build %v of project %v at version %v", build, project, current)
+		}
+		return base, nil
+	})
+}
+
+//countBuild increments the counter of the channel for the upcoming version, the counter restarts for a new upcoming version
+func (v *Version) countBuild(project string, name string, base func(current string, channel Channel) (string, error)) (*Channel, error) {
+	if !validChannel.MatchString(name) {
+		return nil, invalidVersionf("%v is not a valid channel", name)
+	}
+
+	// builds are handed out apart from the changes of the project, which only move the upcoming version on
+	unlock, err := v.locks.Lock(v.namespace + "/" + project + "/" + channelDocument)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	current, err := v.readForChange(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot count build of channel %v on project %v", name, project)
+	}
+	if current == "" {
+		return nil, errors.Wrapf(ErrUnknownProject, "Cannot count build of channel %v on project %v", name, project)
+	}
+	if err := checkStoredVersion(project, current); err != nil {
+		return nil, errors.Wrapf(err, "Cannot count build of channel %v on project %v", name, project)
+	}
+
+	channels, err := v.Channels(project)
+	if err != nil {
+		return nil, err
+	}
+	channel := channels[name]
+	next, err := base(current, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	if next == channel.Base {
+		channel.Counter++
+	} else {
+		channel.Base, channel.Counter = next, 1
+	}
+	channel.Version = fmt.Sprintf("%v-%v.%v", channel.Base, name, channel.Counter)
+	channels[name] = channel
+
+	return &channel, v.storeChannels(project, channels)
+}
+
+//parseBuild returns the upcoming version, the channel and the counter of a build
+func (v *Version) parseBuild(project string, build string) (string, string, int, error) {
+	base, err := v.parseVersion(project, releaseOf(build))
+	if err != nil {
+		return "", "", 0, err
+	}
+	parts := channelBuild.FindStringSubmatch(prereleaseOf(build))
+	if parts == nil {
+		return "", "", 0, invalidVersionf("%v is no build of a channel, expected e.g. 1.3.0-beta.2", build)
+	}
+	counter, _ := strconv.Atoi(parts[2])
+
+	return base, parts[1], counter, nil
+}
+
+//isAhead returns true, if the upcoming version is higher than the release of the current version
+func isAhead(upcoming string, current string) bool {
+	next, err := parseSemver(upcoming)
+	if err != nil {
+		return false
+	}
+	released, err := parseSemver(releaseOf(current))
+	if err != nil {
+		return false
+	}
+	if prereleaseOf(current) != "" {
+		// a prerelease is not released yet, so its own release is still ahead
+		return next.compare(released) >= 0
+	}
+
+	return next.compare(released) > 0
+}
+
+func (v *Version) storeChannels(project string, channels map[string]Channel) error {
+	document, err := json.Marshal(channels)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode channels for project %v", project)
+	}
+
+	err = v.fileProvider.StoreDocument(channelDocument, project, document)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot store channels for project %v", project)
+	}
+
+	return nil
+}
+
+//OnNextBuild is a handler for handing out the next build of a prerelease channel of a given project
+func (handler *Handler) OnNextBuild(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	channel, err := service.NextBuild(context.Param("project"), context.Param("channel"), context.Query("element"))
+	if err != nil {
+		abortChange(context, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	version := service.Display(context.Param("project"), channel.Version)
+	handler.changeLog(context).Infof("hand out build %v of channel %v on project %v", version, context.Param("channel"), projectKey(context))
+	context.String(http.StatusOK, "%s", version)
+}
+
+//OnPromote is a handler for promoting a build of a given project to the channel of the "to" query parameter
+func (handler *Handler) OnPromote(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+	if context.Query("to") == "" {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.New("The channel to promote to is missing, use ?to="))
+		return
+	}
+
+	channel, err := service.Promote(context.Param("project"), context.Param("version"), context.Query("to"))
+	if err != nil {
+		abortChange(context, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	version := service.Display(context.Param("project"), channel.Version)
+	handler.changeLog(context).Infof("promote build %v to %v on project %v", context.Param("version"), version, projectKey(context))
+	context.String(http.StatusOK, "%s", version)
+}
+
+//OnListChannels is a handler for listing the prerelease channels of a given project
+func (handler *Handler) OnListChannels(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	channels, err := service.Channels(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, channels)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Channels_Count_Independently(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+
+	alpha, err := version.NextBuild("p1", "alpha", "minor")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(alpha.Version).To(Equal("1.3.0-alpha.1"))
+	alpha, _ = version.NextBuild("p1", "alpha", "")
+	Ω.Expect(alpha.Version).To(Equal("1.3.0-alpha.2"))
+	beta, _ := version.NextBuild("p1", "beta", "")
+	Ω.Expect(beta.Version).To(Equal("1.2.1-beta.1"))
+
+	current, _ := version.GetVersion("p1")
+	Ω.Expect(current).To(Equal("1.2.0"))
+
+	_, err = version.NextBuild("p1", "no.channel", "")
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Promote_Build(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+	_, _ = version.NextBuild("p1", "beta", "minor")
+	_, _ = version.NextBuild("p1", "beta", "")
+
+	rc, err := version.Promote("p1", "1.3.0-beta.2", "rc")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(rc.Version).To(Equal("1.3.0-rc.1"))
+
+	_, err = version.Promote("p1", "1.3.0-beta.3", "rc")
+	Ω.Expect(err).To(MatchError(ErrUnknownBuild))
+
+	_, _ = version.Set("p1", "1.3.0")
+	_, err = version.Promote("p1", "1.3.0-beta.1", "rc")
+	Ω.Expect(err).To(MatchError(ErrBuildReleased))
+}
+
+func Test_Channel_Routes(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/channel/p1/alpha?element=major", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("2.0.0-alpha.1"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/promote/p1/2.0.0-alpha.1?to=beta", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("2.0.0-beta.1"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/promote/p1/2.0.0-alpha.1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/channels/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`"beta":{"base":"2.0.0","counter":1,"version":"2.0.0-beta.1"}`))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var chaosStatus = []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable}
+
+//Chaos injects faults into a percentage of the requests, so clients can test their retries
+type Chaos struct {
+	Percent    int
+	MaxLatency time.Duration
+
+	mutex  sync.Mutex
+	random *rand.Rand
+	sleep  func(time.Duration)
+}
+
+//NewChaos constructs fault injection for the given percentage of requests with latencies up to maxLatency
+func NewChaos(percent int, maxLatency time.Duration) *Chaos {
+	return &Chaos{
+		Percent:    percent,
+		MaxLatency: maxLatency,
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		sleep:      time.Sleep,
+	}
+}
+
+//fault returns the latency or error status to inject into a request, both are zero for requests without fault
+func (chaos *Chaos) fault() (time.Duration, int) {
+	chaos.mutex.Lock()
+	defer chaos.mutex.Unlock()
+
+	if chaos.random.Intn(100) >= chaos.Percent {
+		return 0, 0
+	}
+	if chaos.MaxLatency > 0 && chaos.random.Intn(2) == 0 {
+		return time.Duration(chaos.random.Int63n(int64(chaos.MaxLatency))) + 1, 0
+	}
+
+	return 0, chaosStatus[chaos.random.Intn(len(chaosStatus))]
+}
+
+//SetChaos enables fault injection, never use it in production
+func (handler *Handler) SetChaos(chaos *Chaos) {
+	handler.chaos = chaos
+}
+
+//ChaosMiddleware delays or fails a percentage of the requests, the injected fault is named in the X-Vbump-Chaos header
+func (handler *Handler) ChaosMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if path := c.FullPath(); path == "" || path == "/" || path == "/metrics" || path == "/readyz" {
+			c.Next()
+			return
+		}
+
+		latency, status := handler.chaos.fault()
+		if latency > 0 {
+			c.Header("X-Vbump-Chaos", "latency")
+			handler.chaos.sleep(latency)
+		}
+		if status != 0 {
+			c.Header("X-Vbump-Chaos", "error")
+			_ = c.AbortWithError(status, errors.Errorf("Injected chaos error for %v", c.Request.URL.Path))
+			return
+		}
+
+		c.Next()
+	}
+}
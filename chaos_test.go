@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Chaos_Faults(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	chaos := NewChaos(50, time.Second)
+	chaos.random = rand.New(rand.NewSource(1))
+
+	latencies, failures := 0, 0
+	for i := 0; i < 1000; i++ {
+		latency, status := chaos.fault()
+		if latency > 0 {
+			Ω.Expect(latency).To(BeNumerically("<=", time.Second))
+			latencies++
+		}
+		if status != 0 {
+			Ω.Expect(status).To(BeNumerically(">=", 500))
+			failures++
+		}
+	}
+
+	Ω.Expect(latencies + failures).To(BeNumerically("~", 500, 50))
+	Ω.Expect(latencies).To(BeNumerically("~", 250, 50))
+}
+
+func Test_Chaos_Middleware(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetChaos(NewChaos(100, 0))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(BeNumerically(">=", 500))
+	Ω.Expect(res.Header().Get("X-Vbump-Chaos")).To(Equal("error"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+}
+
+func Test_Chaos_Latency(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	chaos := NewChaos(100, time.Minute)
+	chaos.random = rand.New(rand.NewSource(3))
+	slept := time.Duration(0)
+	chaos.sleep = func(latency time.Duration) { slept += latency }
+	handler.SetChaos(chaos)
+
+	for i := 0; i < 10; i++ {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/version/p1", nil)
+		handler.GetRouter().ServeHTTP(res, req)
+	}
+
+	Ω.Expect(slept).To(BeNumerically(">", 0))
+}
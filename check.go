@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"maibornwolff/vbump/adapter"
+
+	"github.com/pkg/errors"
+)
+
+//Check validates the stored projects without changing them: corruption, versions, which don't parse, and invalid configs
+func (v *Version) Check() ([]FsckProblem, error) {
+	problems, err := v.Fsck(false)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := v.checkNamespaces(func(namespaced *Version, namespace string) ([]FsckProblem, error) {
+		return namespaced.checkProjects(namespace)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(problems, found...), nil
+}
+
+func (v *Version) checkProjects(namespace string) ([]FsckProblem, error) {
+	projects, err := v.Projects()
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []FsckProblem{}
+	for _, project := range projects {
+		problem := func(kind string, err error) {
+			problems = append(problems, FsckProblem{Namespace: namespace, Project: project, Kind: kind, Problem: err.Error()})
+		}
+
+		meta, err := v.GetMetadata(project)
+		if err != nil {
+			problem("meta", err)
+			continue
+		}
+		if meta != nil {
+			if err := meta.Validate(); err != nil {
+				problem("config", err)
+			}
+		}
+
+		version, err := v.fileProvider.ReadVersion(project)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot get version for project %v", project)
+		}
+		if isCorruptVersion(version) {
+			// corrupted versions are already reported by fsck
+			continue
+		}
+		if _, err := v.parseVersion(project, version); err != nil {
+			// bumps append prereleases, which the parse mode of set version may not accept
+			if _, strictErr := parseVersionIn("strict", version); strictErr != nil {
+				problem("version", err)
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+//checkPermissions returns a problem for every directory of the datadir, which is not writable, and every file, which cannot be opened for writing
+func checkPermissions(datadir string) []FsckProblem {
+	problems := []FsckProblem{}
+	problem := func(path string, err error) {
+		problems = append(problems, FsckProblem{Project: path, Kind: "permission", Problem: err.Error()})
+	}
+
+	err := filepath.Walk(datadir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			problem(path, err)
+			return nil
+		}
+
+		if info.IsDir() {
+			probe, err := ioutil.TempFile(path, ".vbump-check")
+			if err != nil {
+				problem(path, err)
+				return nil
+			}
+			probe.Close()
+			return os.Remove(probe.Name())
+		}
+
+		file, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			problem(path, err)
+			return nil
+		}
+		return file.Close()
+	})
+	if err != nil {
+		problem(datadir, err)
+	}
+
+	return problems
+}
+
+//runCheck checks the datadir, writes a report and returns the exit code, 1 if there are problems
+func runCheck(datadir string, out io.Writer) int {
+	if info, err := os.Stat(datadir); err != nil || !info.IsDir() {
+		fmt.Fprintf(out, "datadir %v is not a directory\n", datadir)
+		return 1
+	}
+
+	problems := checkPermissions(datadir)
+	found, err := NewVersion(adapter.New(datadir)).Check()
+	if err != nil {
+		fmt.Fprintf(out, "check of %v failed: %v\n", datadir, err)
+		return 1
+	}
+	problems = append(problems, found...)
+
+	for _, problem := range problems {
+		project := problem.Project
+		if problem.Namespace != "" {
+			project = problem.Namespace + "/" + project
+		}
+		fmt.Fprintf(out, "%v %v: %v\n", problem.Kind, project, problem.Problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(out, "%v problems found in %v\n", len(problems), datadir)
+		return 1
+	}
+
+	fmt.Fprintf(out, "no problems found in %v\n", datadir)
+	return 0
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Check_Projects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	provider := adapter.NewMock("1.0.0-rc.1", "p1")
+	version := NewVersion(provider)
+	_ = provider.StoreVersion("p2", "1.x")
+	_ = provider.StoreDocument("meta", "p3", []byte(`{"prefix": "x"}`))
+	_ = provider.StoreVersion("p3", "1.0")
+
+	problems, err := version.Check()
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(problems).To(HaveLen(2))
+	Ω.Expect(problems[0].Project).To(Equal("p2"))
+	Ω.Expect(problems[0].Kind).To(Equal("version"))
+	Ω.Expect(problems[1].Project).To(Equal("p3"))
+	Ω.Expect(problems[1].Kind).To(Equal("config"))
+}
+
+func Test_Run_Check(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	datadir, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(datadir)
+	out := &bytes.Buffer{}
+
+	_ = ioutil.WriteFile(path.Join(datadir, "p1"), []byte("1.0"), 0644)
+	Ω.Expect(runCheck(datadir, out)).To(Equal(0))
+	Ω.Expect(out.String()).To(ContainSubstring("no problems found"))
+
+	out.Reset()
+	_ = ioutil.WriteFile(path.Join(datadir, "p2"), []byte("1.0\x00"), 0644)
+	Ω.Expect(runCheck(datadir, out)).To(Equal(1))
+	Ω.Expect(out.String()).To(ContainSubstring(`version p2: invalid version "1.0\x00"`))
+
+	out.Reset()
+	Ω.Expect(runCheck(path.Join(datadir, "missing"), out)).To(Equal(1))
+}
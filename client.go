@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultClientHeader = "X-Vbump-Client"
+	maxClientLength     = 128
+	//defaultMaxClientLabels is the number of distinct clients with their own label on metrics
+	defaultMaxClientLabels = 100
+)
+
+//clientLabels limits the client label, which is sent by the clients, further clients are counted as "other"
+var clientLabels = NewProjectLabels(defaultMaxClientLabels, false)
+
+//clientElements are the elements counted by their name, others like combined bumps are counted as "other"
+var clientElements = map[string]bool{
+	"major": true, "minor": true, "patch": true, "set": true, createElement: true, epochElement: true,
+	"decrement-major": true, "decrement-minor": true, "decrement-patch": true,
+}
+
+//SetClientHeader sets the header identifying the client of a change, e.g. the pipeline, "" disables it
+func (handler *Handler) SetClientHeader(header string) {
+	handler.clientHeader = header
+}
+
+//client returns the client identifier sent by the client, limited to a sane length
+func (handler *Handler) client(context *gin.Context) string {
+	if handler.clientHeader == "" {
+		return ""
+	}
+
+	client := strings.TrimSpace(context.GetHeader(handler.clientHeader))
+	if len(client) > maxClientLength {
+		client = client[:maxClientLength]
+	}
+
+	return client
+}
+
+func countClientChange(entry *HistoryEntry) {
+	if entry.Client == "" {
+		return
+	}
+
+	element := entry.Element
+	if !clientElements[element] {
+		element = otherProjects
+	}
+	clientChanges.With(prometheus.Labels{"client": clientLabels.Of("", entry.Client), "element": element}).Inc()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_Client_Is_Recorded(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	labels := prometheus.Labels{"client": "jenkins-job-foo", "element": "patch"}
+	before := testutil.ToFloat64(clientChanges.With(labels))
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("X-Vbump-Client", "jenkins-job-foo")
+	handler.GetRouter().ServeHTTP(res, req)
+	history, _ := version.History("p1")
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(history[0].Client).To(Equal("jenkins-job-foo"))
+	Ω.Expect(testutil.ToFloat64(clientChanges.With(labels))).To(Equal(before + 1))
+}
+
+func Test_Client_Header_Is_Configurable(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	handler := NewHandler(version, nil)
+	handler.SetClientHeader("X-Pipeline")
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("X-Vbump-Client", "ignored")
+	req.Header.Set("X-Pipeline", strings.Repeat("x", 200))
+	handler.GetRouter().ServeHTTP(res, req)
+	history, _ := version.History("p1")
+
+	Ω.Expect(history[0].Client).To(HaveLen(maxClientLength))
+}
+
+func Test_Client_Header_Disabled(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	handler := NewHandler(version, nil)
+	handler.SetClientHeader("")
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("X-Vbump-Client", "jenkins-job-foo")
+	handler.GetRouter().ServeHTTP(res, req)
+	history, _ := version.History("p1")
+
+	Ω.Expect(history[0].Client).To(BeEmpty())
+}
+
+func Test_Client_Labels_Are_Limited(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	defer func(labels *ProjectLabels) { clientLabels = labels }(clientLabels)
+	clientLabels = NewProjectLabels(1, false)
+	other := prometheus.Labels{"client": "other", "element": "patch"}
+	combined := prometheus.Labels{"client": "first", "element": "other"}
+	before, beforeCombined := testutil.ToFloat64(clientChanges.With(other)), testutil.ToFloat64(clientChanges.With(combined))
+
+	countClientChange(&HistoryEntry{Client: "first", Element: "patch"})
+	countClientChange(&HistoryEntry{Client: "second", Element: "patch"})
+	countClientChange(&HistoryEntry{Client: "third", Element: "patch"})
+	countClientChange(&HistoryEntry{Client: "first", Element: "minor+patch+patch"})
+
+	Ω.Expect(testutil.ToFloat64(clientChanges.With(other))).To(Equal(before + 2))
+	Ω.Expect(testutil.ToFloat64(clientChanges.With(combined))).To(Equal(beforeCombined + 1))
+}
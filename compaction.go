@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//HistoryRetention limits the history of every project by age and number of entries, zero means unlimited
+type HistoryRetention struct {
+	MaxAge     time.Duration
+	MaxEntries int
+}
+
+//Enabled returns true, if the retention limits the history
+func (retention HistoryRetention) Enabled() bool {
+	return retention.MaxAge > 0 || retention.MaxEntries > 0
+}
+
+//CompactHistory removes history entries beyond the retention from all projects of all namespaces and returns the number of removed entries,
+//the quotas of namespaces may replace the retention
+func (v *Version) CompactHistory(retention HistoryRetention, quotas *Quotas) (int, error) {
+	removed, err := v.compactHistory(retention)
+	if err != nil {
+		return removed, err
+	}
+
+	namespaces, err := v.fileProvider.ListNamespaces()
+	if err != nil {
+		return removed, errors.Wrap(err, "Cannot list namespaces")
+	}
+	for _, namespace := range namespaces {
+		namespaced, err := v.Namespace(namespace)
+		if err != nil {
+			return removed, err
+		}
+		compacted, err := namespaced.compactHistory(quotas.retention(namespace, retention))
+		removed += compacted
+		if err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+func (v *Version) compactHistory(retention HistoryRetention) (int, error) {
+	if !retention.Enabled() {
+		return 0, nil
+	}
+
+	projects, err := v.Projects()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, project := range projects {
+		compacted, err := v.compactProjectHistory(project, retention)
+		if err != nil {
+			return removed, err
+		}
+		removed += compacted
+	}
+
+	return removed, nil
+}
+
+//compactProjectHistory keeps the entries within the retention, the latest entry is always kept to restore corrupted versions
+func (v *Version) compactProjectHistory(project string, retention HistoryRetention) (int, error) {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	lines, err := v.fileProvider.ReadHistory(project)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Cannot compact history of project %v", project)
+	}
+
+	cut := 0
+	if retention.MaxEntries > 0 && len(lines) > retention.MaxEntries {
+		cut = len(lines) - retention.MaxEntries
+	}
+	if retention.MaxAge > 0 {
+		since := v.now().Add(-retention.MaxAge)
+		for cut < len(lines) {
+			entry := HistoryEntry{}
+			// corrupted entries are not removed by age, fsck reports them
+			if err := json.Unmarshal(lines[cut], &entry); err != nil || !entry.Time.Before(since) {
+				break
+			}
+			cut++
+		}
+	}
+	if cut >= len(lines) {
+		cut = len(lines) - 1
+	}
+	if cut <= 0 {
+		return 0, nil
+	}
+
+	if err := v.fileProvider.ReplaceHistory(project, lines[cut:]); err != nil {
+		return 0, errors.Wrapf(err, "Cannot compact history of project %v", project)
+	}
+	purgedHistoryEntries.Add(float64(cut))
+
+	return cut, nil
+}
+
+//StartHistoryCompaction removes history entries beyond the retention in the background
+func (handler *Handler) StartHistoryCompaction(interval time.Duration, retention HistoryRetention) {
+	go func() {
+		for range time.Tick(interval) {
+			removed, err := handler.version.CompactHistory(retention, handler.quotas)
+			if err != nil {
+				handler.logger.Error(err)
+			}
+			if removed > 0 {
+				handler.logger.Infof("removed %v history entries beyond the retention", removed)
+			}
+		}
+	}()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_Compact_History_By_Entries(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	for i := 0; i < 5; i++ {
+		_, _ = version.BumpPatch("p1")
+	}
+	team, _ := version.Namespace("team")
+	_, _ = team.BumpMinor("p2")
+	_, _ = team.BumpMinor("p2")
+	before := testutil.ToFloat64(purgedHistoryEntries)
+
+	removed, err := version.CompactHistory(HistoryRetention{MaxEntries: 2}, nil)
+
+	Ω.Expect(err).NotTo(HaveOccurred())
+	Ω.Expect(removed).To(Equal(3))
+	Ω.Expect(testutil.ToFloat64(purgedHistoryEntries) - before).To(Equal(3.0))
+	history, _ := version.History("p1")
+	Ω.Expect(history).To(HaveLen(2))
+	Ω.Expect(history[0].Version).To(Equal("1.0.4"))
+	Ω.Expect(history[1].Version).To(Equal("1.0.5"))
+	teamHistory, _ := team.History("p2")
+	Ω.Expect(teamHistory).To(HaveLen(2))
+}
+
+func Test_Compact_History_By_Age_Keeps_Latest_Entry(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.now = func() time.Time { return now }
+	_, _ = version.BumpPatch("p1")
+	now = now.Add(48 * time.Hour)
+	_, _ = version.BumpPatch("p1")
+	_, _ = version.BumpPatch("p1")
+
+	removed, _ := version.CompactHistory(HistoryRetention{MaxAge: 24 * time.Hour}, nil)
+	Ω.Expect(removed).To(Equal(1))
+
+	now = now.Add(30 * 24 * time.Hour)
+	removed, _ = version.CompactHistory(HistoryRetention{MaxAge: 24 * time.Hour}, nil)
+	Ω.Expect(removed).To(Equal(1))
+	history, _ := version.History("p1")
+	Ω.Expect(history).To(HaveLen(1))
+	Ω.Expect(history[0].Version).To(Equal("1.0.3"))
+}
+
+func Test_Compact_History_With_Namespace_Retention(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	team, _ := version.Namespace("team")
+	other, _ := version.Namespace("other")
+	for i := 0; i < 3; i++ {
+		_, _ = version.BumpPatch("p1")
+		_, _ = team.BumpPatch("p2")
+		_, _ = other.BumpPatch("p3")
+	}
+	quotas := NewQuotas(Quota{})
+	quotas.Set("team", Quota{History: HistoryRetention{MaxEntries: 1}})
+
+	removed, err := version.CompactHistory(HistoryRetention{MaxEntries: 2}, quotas)
+
+	Ω.Expect(err).NotTo(HaveOccurred())
+	Ω.Expect(removed).To(Equal(4))
+	teamHistory, _ := team.History("p2")
+	otherHistory, _ := other.History("p3")
+	Ω.Expect(teamHistory).To(HaveLen(1))
+	Ω.Expect(otherHistory).To(HaveLen(2))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+const (
+	concurrentBumpers = 20
+	bumpsPerBumper    = 10
+)
+
+//bumpResult is the outcome of a bump, which is asserted by the test goroutine
+type bumpResult struct {
+	version string
+	err     error
+}
+
+//assertNoLostBumps runs concurrent bumpers against the version service and asserts neither duplicate nor skipped versions
+func assertNoLostBumps(t *testing.T, version *Version, project string) {
+	Ω := NewGomegaWithT(t)
+	results := make(chan bumpResult, concurrentBumpers*bumpsPerBumper)
+	bumpers := sync.WaitGroup{}
+	for b := 0; b < concurrentBumpers; b++ {
+		bumpers.Add(1)
+		go func() {
+			defer bumpers.Done()
+			for i := 0; i < bumpsPerBumper; i++ {
+				bumped, err := version.BumpPatch(project)
+				results <- bumpResult{version: bumped, err: err}
+			}
+		}()
+	}
+	bumpers.Wait()
+	close(results)
+
+	seen := map[string]bool{}
+	for result := range results {
+		Ω.Expect(result.err).To(BeNil())
+		Ω.Expect(seen).NotTo(HaveKey(result.version), "duplicate version %v", result.version)
+		seen[result.version] = true
+	}
+	for patch := 1; patch <= concurrentBumpers*bumpsPerBumper; patch++ {
+		Ω.Expect(seen).To(HaveKey(fmt.Sprintf("0.0.%v", patch)), "skipped version 0.0.%v", patch)
+	}
+	history, err := version.History(project)
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(history).To(HaveLen(concurrentBumpers * bumpsPerBumper))
+}
+
+func Test_Concurrent_Bumps_File_Backend(t *testing.T) {
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+
+	assertNoLostBumps(t, NewVersion(adapter.New(basePath)), "p1")
+}
+
+func Test_Concurrent_Bumps_File_Backend_Namespace(t *testing.T) {
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	namespaced, _ := NewVersion(adapter.New(basePath)).Namespace("team")
+
+	assertNoLostBumps(t, namespaced, "p1")
+}
+
+func Test_Concurrent_Bumps_Through_Annotated_Copies(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	version := NewVersion(adapter.New(basePath))
+
+	bumpers := sync.WaitGroup{}
+	for b := 0; b < concurrentBumpers; b++ {
+		bumpers.Add(1)
+		go func(b int) {
+			defer bumpers.Done()
+			// every request changes through its own annotated copy of the service
+			_, _ = version.WithAnnotation(Annotation{Actor: fmt.Sprint(b)}).BumpPatch("p1")
+		}(b)
+	}
+	bumpers.Wait()
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(current).To(Equal(fmt.Sprintf("0.0.%v", concurrentBumpers)))
+}
+
+func Test_Reads_During_Concurrent_Bumps_See_A_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	version := NewVersion(adapter.New(basePath))
+	_, _ = version.BumpPatch("p1")
+
+	done := make(chan struct{})
+	// every reader stops at its first failure, which is asserted by the test goroutine
+	failures := make(chan error, 4)
+	readers := sync.WaitGroup{}
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				current, err := version.GetVersion("p1")
+				if err == nil && current == "" {
+					err = errors.New("read an empty version")
+				}
+				if err != nil {
+					failures <- err
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < concurrentBumpers*bumpsPerBumper; i++ {
+		_, err := version.BumpPatch("p1")
+		Ω.Expect(err).To(BeNil())
+	}
+	close(done)
+	readers.Wait()
+	close(failures)
+
+	for err := range failures {
+		Ω.Expect(err).To(BeNil())
+	}
+}
+
+func Test_Reads_Do_Not_Wait_For_The_Lock_Of_The_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	// a long running change holds the lock of the project
+	unlock, _ := version.locks.Lock("/p1")
+	defer unlock()
+
+	read := make(chan string)
+	go func() {
+		current, _ := version.GetVersion("p1")
+		read <- current
+	}()
+
+	Ω.Eventually(read, 100*time.Millisecond).Should(Receive(Equal("1.0.0")))
+}
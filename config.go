@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	configSchemaVersion = 1
+
+	//maskedSecret is returned instead of a webhook secret, sent back it keeps the stored secret
+	maskedSecret = "********"
+)
+
+//Config holds all settings of a project, it is stored with the metadata of the project
+type Config struct {
+	Templates *Templates `json:"templates,omitempty"`
+	Policy    *Policy    `json:"policy,omitempty"`
+	ParseMode string     `json:"parseMode,omitempty"`
+	Prefix    string     `json:"prefix,omitempty"`
+	Epoch     int        `json:"epoch,omitempty"`
+	Padding   *Padding   `json:"padding,omitempty"`
+	Scheme    *Scheme    `json:"scheme,omitempty"`
+	Analyzer  *Analyzer  `json:"analyzer,omitempty"`
+	Schedule  *Schedule  `json:"schedule,omitempty"`
+	Webhook   string     `json:"webhook,omitempty"`
+
+	WebhookSecret  string `json:"webhookSecret,omitempty"`
+	EpochSeparator string `json:"epochSeparator,omitempty"`
+
+	Dependents []string `json:"dependents,omitempty"`
+}
+
+//ProjectConfig is the configuration document of a project with its schema version
+type ProjectConfig struct {
+	SchemaVersion int  `json:"schemaVersion"`
+	Archived      bool `json:"archived"`
+	Config
+}
+
+//Validate returns an error, if the config has invalid templates, parse mode, prefix, epoch, padding, dependents or schedule
+func (config *Config) Validate() error {
+	if err := config.Templates.Validate(); err != nil {
+		return err
+	}
+	if !validParseMode(config.ParseMode) {
+		return errors.Errorf("%v is not a valid parse mode", config.ParseMode)
+	}
+	if !validPrefix(config.Prefix) {
+		return errors.Errorf("%v is not a valid version prefix", config.Prefix)
+	}
+	if !validEpoch(config.Epoch, config.EpochSeparator) {
+		return errors.Errorf("%v%v is not a valid epoch", config.Epoch, config.EpochSeparator)
+	}
+	if err := config.Padding.Validate(); err != nil {
+		return err
+	}
+	for _, dependent := range config.Dependents {
+		if err := validateProjectName(dependent); err != nil {
+			return errors.Wrap(err, "Invalid dependent")
+		}
+	}
+
+	return config.Schedule.Validate()
+}
+
+//masked returns the config with its webhook secret hidden, webhook secrets are write-only
+func (config Config) masked() Config {
+	if config.WebhookSecret != "" {
+		config.WebhookSecret = maskedSecret
+	}
+
+	return config
+}
+
+//keepSecret replaces a masked webhook secret by the secret of the stored metadata
+func (config *Config) keepSecret(stored *Metadata) {
+	if config.WebhookSecret != maskedSecret {
+		return
+	}
+
+	config.WebhookSecret = ""
+	if stored != nil {
+		config.WebhookSecret = stored.WebhookSecret
+	}
+}
+
+//parseProjectConfig decodes a configuration document, unknown fields and schema versions are rejected
+func parseProjectConfig(document []byte) (*ProjectConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	config := &ProjectConfig{}
+	if err := decoder.Decode(config); err != nil {
+		return nil, errors.Wrap(err, "Invalid config")
+	}
+	if config.SchemaVersion != configSchemaVersion {
+		return nil, errors.Errorf("Unsupported config schema version %v, expected %v", config.SchemaVersion, configSchemaVersion)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+//GetConfig returns the configuration document of the given project
+func (v *Version) GetConfig(project string) (*ProjectConfig, error) {
+	meta, err := v.GetMetadata(project)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := v.IsArchived(project)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ProjectConfig{SchemaVersion: configSchemaVersion, Archived: archived}
+	if meta != nil {
+		config.Config = meta.Config
+	}
+
+	return config, nil
+}
+
+//SetConfig replaces all settings of the given project, the metadata describing the project is kept
+func (v *Version) SetConfig(project string, config *ProjectConfig) error {
+	meta, err := v.GetMetadata(project)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		meta = &Metadata{}
+	}
+
+	meta.Config = config.Config
+	if err := v.SetMetadata(project, meta); err != nil {
+		return err
+	}
+
+	archived, err := v.IsArchived(project)
+	if err != nil {
+		return err
+	}
+	switch {
+	case config.Archived && !archived:
+		return v.Archive(project)
+	case !config.Archived && archived:
+		return v.Unarchive(project)
+	}
+
+	return nil
+}
+
+//OnGetConfig is a handler for getting the configuration document of a given project
+func (handler *Handler) OnGetConfig(context *gin.Context) {
+	service, ok := handler.existingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	config, err := service.GetConfig(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	config.Config = config.Config.masked()
+	context.JSON(http.StatusOK, config)
+}
+
+//OnSetConfig is a handler for replacing the configuration document of a given project
+func (handler *Handler) OnSetConfig(context *gin.Context) {
+	service, ok := handler.existingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	document, err := ioutil.ReadAll(context.Request.Body)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Cannot read config"))
+		return
+	}
+	config, err := parseProjectConfig(document)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := service.SetConfig(context.Param("project"), config); err != nil {
+		_ = context.AbortWithError(dependencyStatus(err), err)
+		return
+	}
+
+	handler.auditLog().Infof("set config on project %v", projectKey(context))
+	config.Config = config.Config.masked()
+	context.JSON(http.StatusOK, config)
+}
+
+//existingVersionFor returns the version service for the namespace of the request and aborts, if the project doesn't exist
+func (handler *Handler) existingVersionFor(context *gin.Context) (*Version, bool) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return nil, false
+	}
+
+	exists, err := service.Exists(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if !exists {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("Project %v does not exist", projectKey(context)))
+		return nil, false
+	}
+
+	return service, true
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Parse_Project_Config(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	config, err := parseProjectConfig([]byte(`{"schemaVersion": 1, "prefix": "v", "policy": {"monotonic": true}, "webhook": "http://hook"}`))
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(config.Prefix).To(Equal("v"))
+	Ω.Expect(config.Policy.Monotonic).To(BeTrue())
+	Ω.Expect(config.Webhook).To(Equal("http://hook"))
+}
+
+func Test_Parse_Invalid_Project_Config(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for _, document := range []string{
+		`{"prefix": "v"}`,
+		`{"schemaVersion": 2}`,
+		`{"schemaVersion": 1, "owner": "payments"}`,
+		`{"schemaVersion": 1, "parseMode": "sloppy"}`,
+		`{"schemaVersion": 1, "schedule": {"cron": "@often", "element": "patch"}}`,
+	} {
+		_, err := parseProjectConfig([]byte(document))
+		Ω.Expect(err).NotTo(BeNil(), document)
+	}
+}
+
+func Test_Set_Config_Keeps_Metadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Owner: "payments", Config: Config{Prefix: "v"}})
+
+	err := version.SetConfig("p1", &ProjectConfig{SchemaVersion: 1, Archived: true, Config: Config{ParseMode: strictParsing}})
+	meta, _ := version.GetMetadata("p1")
+	config, _ := version.GetConfig("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(meta.Owner).To(Equal("payments"))
+	Ω.Expect(meta.Prefix).To(Equal(""))
+	Ω.Expect(config.ParseMode).To(Equal(strictParsing))
+	Ω.Expect(config.Archived).To(BeTrue())
+}
+
+func Test_Config_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/config/p1", bytes.NewBufferString(`{"schemaVersion": 1, "prefix": "v"}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/config/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"schemaVersion": 1, "archived": false, "prefix": "v"}`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/config/unknown", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/config/p1", bytes.NewBufferString(`{"schemaVersion": 1, "prefx": "v"}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const quarantineDocument = "quarantine"
+
+//documentKinds are all documents stored per project, which are checked for corruption
+var documentKinds = []string{metadataDocument, aliasDocument, activityDocument, archiveDocument, reservationDocument, deliveryDocument, outboxDocument, channelDocument, hotfixDocument}
+
+//Quarantined is a corrupted entry moved aside, so it can be inspected and restored by hand
+type Quarantined struct {
+	Kind    string `json:"kind"`
+	Content string `json:"content"`
+	Time    string `json:"time"`
+}
+
+//FsckProblem is a corrupted entry found by the check of the stored projects
+type FsckProblem struct {
+	Namespace string `json:"namespace,omitempty"`
+	Project   string `json:"project"`
+	Kind      string `json:"kind"`
+	Problem   string `json:"problem"`
+	Repaired  bool   `json:"repaired"`
+}
+
+//isCorruptVersion returns true, if the stored version cannot have been written by vbump
+func isCorruptVersion(version string) bool {
+	return version != "" && !schemeVersion.MatchString(version)
+}
+
+func countCorruption(kind string) {
+	corruptedEntries.With(prometheus.Labels{"kind": kind}).Inc()
+}
+
+//readVersion reads the stored version of the project, a corrupted version is read from the history,
+//it is only repaired by the next change or fsck holding the lock of the project
+func (v *Version) readVersion(project string) (string, error) {
+	version, err := v.fileProvider.ReadVersion(project)
+	if err != nil || !isCorruptVersion(version) {
+		return version, err
+	}
+
+	countCorruption("version")
+	return v.lastRecordedVersion(project), nil
+}
+
+//repairedVersion reads the stored version of the project, a corrupted version is quarantined and restored from the history,
+//the caller holds the lock of the project
+func (v *Version) repairedVersion(project string) (string, error) {
+	version, err := v.fileProvider.ReadVersion(project)
+	if err != nil || !isCorruptVersion(version) {
+		return version, err
+	}
+
+	countCorruption("version")
+	return v.repairVersion(project, version)
+}
+
+//repairVersion moves the corrupted version aside and restores the last version of the history, without history the version is removed
+func (v *Version) repairVersion(project string, corrupted string) (string, error) {
+	if err := v.quarantine(project, "version", []byte(corrupted)); err != nil {
+		return "", err
+	}
+
+	restored := v.lastRecordedVersion(project)
+	if err := v.fileProvider.StoreVersion(project, restored); err != nil {
+		return "", errors.Wrapf(err, "Cannot restore version of project %v", project)
+	}
+
+	return restored, nil
+}
+
+//repairLocked repairs the version of the project under its lock, a change in the meantime is kept
+func (v *Version) repairLocked(project string) (string, error) {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	return v.repairedVersion(project)
+}
+
+//lastRecordedVersion returns the latest valid version of the history, corrupted lines are skipped
+func (v *Version) lastRecordedVersion(project string) string {
+	lines, err := v.fileProvider.ReadHistory(project)
+	if err != nil {
+		return ""
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		entry := HistoryEntry{}
+		if json.Unmarshal(lines[i], &entry) == nil && entry.Version != "" && !isCorruptVersion(entry.Version) {
+			return entry.Version
+		}
+	}
+
+	return ""
+}
+
+//quarantine records the corrupted content in the quarantine document of the project
+func (v *Version) quarantine(project string, kind string, content []byte) error {
+	entries := []Quarantined{}
+	if document, err := v.fileProvider.ReadDocument(quarantineDocument, project); err == nil && document != nil {
+		// a corrupted quarantine is replaced, it only holds copies
+		_ = json.Unmarshal(document, &entries)
+	}
+
+	entries = append(entries, Quarantined{Kind: kind, Content: string(content), Time: v.now().UTC().Format(time.RFC3339)})
+	document, err := json.Marshal(entries)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode quarantine of project %v", project)
+	}
+
+	if err := v.fileProvider.StoreDocument(quarantineDocument, project, document); err != nil {
+		return errors.Wrapf(err, "Cannot quarantine %v of project %v", kind, project)
+	}
+
+	return nil
+}
+
+//Fsck checks the versions, documents and history of all projects in all namespaces, with repair corrupted entries are quarantined
+func (v *Version) Fsck(repair bool) ([]FsckProblem, error) {
+	return v.checkNamespaces(func(namespaced *Version, namespace string) ([]FsckProblem, error) {
+		return namespaced.fsck(repair, namespace)
+	})
+}
+
+//checkNamespaces runs the check on the default namespace and all other namespaces
+func (v *Version) checkNamespaces(check func(*Version, string) ([]FsckProblem, error)) ([]FsckProblem, error) {
+	problems, err := check(v, "")
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := v.fileProvider.ListNamespaces()
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list namespaces")
+	}
+	for _, namespace := range namespaces {
+		namespaced, err := v.Namespace(namespace)
+		if err != nil {
+			return nil, err
+		}
+		found, err := check(namespaced, namespace)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+
+	return problems, nil
+}
+
+func (v *Version) fsck(repair bool, namespace string) ([]FsckProblem, error) {
+	projects, err := v.Projects()
+	if err != nil {
+		return nil, err
+	}
+
+	problems := []FsckProblem{}
+	for _, project := range projects {
+		version, err := v.fileProvider.ReadVersion(project)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot get version for project %v", project)
+		}
+		if isCorruptVersion(version) {
+			countCorruption("version")
+			problem := FsckProblem{Namespace: namespace, Project: project, Kind: "version", Problem: "invalid version " + strconv.Quote(version)}
+			if repair {
+				restored, err := v.repairLocked(project)
+				if err != nil {
+					return nil, err
+				}
+				problem.Repaired = true
+				problem.Problem += ", restored " + strconv.Quote(restored)
+			}
+			problems = append(problems, problem)
+		}
+
+		for _, kind := range documentKinds {
+			document, err := v.fileProvider.ReadDocument(kind, project)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Cannot read %v document of project %v", kind, project)
+			}
+			if document == nil || json.Valid(document) {
+				continue
+			}
+			countCorruption(kind)
+			problem := FsckProblem{Namespace: namespace, Project: project, Kind: kind, Problem: "invalid JSON document"}
+			if repair {
+				if err := v.quarantine(project, kind, document); err != nil {
+					return nil, err
+				}
+				if err := v.fileProvider.DeleteDocument(kind, project); err != nil {
+					return nil, errors.Wrapf(err, "Cannot remove %v document of project %v", kind, project)
+				}
+				problem.Repaired = true
+			}
+			problems = append(problems, problem)
+		}
+
+		lines, err := v.fileProvider.ReadHistory(project)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot get history for project %v", project)
+		}
+		for i, line := range lines {
+			// the history is append only, so corrupted lines are only reported
+			if !json.Valid(line) {
+				countCorruption("history")
+				problems = append(problems, FsckProblem{Namespace: namespace, Project: project, Kind: "history", Problem: "invalid JSON in line " + strconv.Itoa(i+1)})
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+//OnFsck is a handler for checking all stored projects, ?repair=true quarantines corrupted entries
+func (handler *Handler) OnFsck(context *gin.Context) {
+	repair := context.Query("repair") == "true"
+	problems, err := handler.version.Fsck(repair)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(problems) > 0 {
+		handler.logger.Warnf("fsck found %v problems, repair %v", len(problems), repair)
+	}
+	context.JSON(http.StatusOK, gin.H{"repair": repair, "problems": problems})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Corrupted_Version_Is_Restored_From_History(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	provider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(provider)
+	_, _ = version.BumpMinor("p1")
+	_ = provider.StoreVersion("p1", "1.1\x00\x00")
+
+	current, err := version.GetVersion("p1")
+	quarantined, _ := provider.ReadDocument("quarantine", "p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(current).To(Equal("1.1.0"))
+	Ω.Expect(quarantined).To(BeNil())
+
+	// the next change repairs the version under the lock of the project
+	bumped, err := version.BumpPatch("p1")
+	quarantined, _ = provider.ReadDocument("quarantine", "p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bumped).To(Equal("1.1.1"))
+	Ω.Expect(string(quarantined)).To(ContainSubstring(`"kind":"version","content":"1.1\u0000\u0000"`))
+}
+
+func Test_Corrupted_Version_Without_History(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0\n\xff", "p1"))
+
+	exists, err := version.Exists("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(exists).To(BeFalse())
+}
+
+func Test_Fsck_Reports_And_Repairs(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	provider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(provider)
+	_, _ = version.BumpPatch("p1")
+	_ = provider.StoreVersion("p1", "\x00")
+	_ = provider.StoreDocument("meta", "p1", []byte(`{"owner": "pay`))
+	_ = provider.AppendHistory("p1", []byte(`{"vers`))
+	namespaced, _ := version.Namespace("team")
+	_, _ = namespaced.Set("p2", "2.0")
+
+	problems, err := version.Fsck(false)
+	stored, _ := provider.ReadVersion("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(problems).To(Equal([]FsckProblem{
+		{Project: "p1", Kind: "version", Problem: `invalid version "\x00"`},
+		{Project: "p1", Kind: "meta", Problem: "invalid JSON document"},
+		{Project: "p1", Kind: "history", Problem: "invalid JSON in line 2"},
+	}))
+	Ω.Expect(stored).To(Equal("\x00"))
+
+	repaired, err := version.Fsck(true)
+	stored, _ = provider.ReadVersion("p1")
+	meta, _ := provider.ReadDocument("meta", "p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(repaired[0]).To(Equal(FsckProblem{Project: "p1", Kind: "version", Problem: `invalid version "\x00", restored "1.0.1"`, Repaired: true}))
+	Ω.Expect(repaired[1].Repaired).To(BeTrue())
+	Ω.Expect(repaired[2].Repaired).To(BeFalse())
+	Ω.Expect(stored).To(Equal("1.0.1"))
+	Ω.Expect(meta).To(BeNil())
+}
+
+func Test_Fsck_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	tokens := NewTokenStore()
+	tokens.Add("ci", "ci-secret", "ns:team")
+	tokens.Add("ops", "admin-secret", "*", "admin")
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetTokenStore(tokens)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/fsck?repair=true", nil)
+	req.Header.Set("Authorization", "Bearer ci-secret")
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusForbidden))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/fsck", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"repair": false, "problems": []}`))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var recordedChangesDesc = prometheus.NewDesc(
+	"vbump_recorded_changes_total",
+	"Number of changes recorded in the history of all projects, labelled with namespace, projectname and element, equal on all instances sharing the storage",
+	[]string{"namespace", "project", "element"},
+	nil,
+)
+
+//HistoryCollector derives change counters from the stored history, so they survive restarts and agree across replicas
+type HistoryCollector struct {
+	mutex     sync.Mutex
+	version   *Version
+	refresh   time.Duration
+	now       func() time.Time
+	collected time.Time
+	counts    map[[3]string]float64
+}
+
+//NewHistoryCollector constructs a collector counting the history at most once per refresh interval
+func NewHistoryCollector(version *Version, refresh time.Duration) *HistoryCollector {
+	return &HistoryCollector{version: version, refresh: refresh, now: time.Now}
+}
+
+//Describe sends the description of the recorded changes
+func (collector *HistoryCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- recordedChangesDesc
+}
+
+//Collect sends the recorded changes, counted again once the refresh interval passed
+func (collector *HistoryCollector) Collect(metrics chan<- prometheus.Metric) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	if collector.counts == nil || collector.now().Sub(collector.collected) >= collector.refresh {
+		counts, err := collector.count()
+		if err != nil {
+			metrics <- prometheus.NewInvalidMetric(recordedChangesDesc, err)
+			return
+		}
+		collector.counts, collector.collected = counts, collector.now()
+	}
+
+	for labels, count := range collector.counts {
+		metrics <- prometheus.MustNewConstMetric(recordedChangesDesc, prometheus.CounterValue, count, labels[0], labels[1], labels[2])
+	}
+}
+
+//count returns the number of changes per namespace, project and element of all projects
+func (collector *HistoryCollector) count() (map[[3]string]float64, error) {
+	counts := map[[3]string]float64{}
+	_, err := collector.version.checkNamespaces(func(service *Version, namespace string) ([]FsckProblem, error) {
+		projects, err := service.Projects()
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			history, err := service.History(project)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range history {
+				counts[[3]string{namespace, projectLabels.Of(namespace, project), entry.Element}]++
+			}
+		}
+
+		return nil, nil
+	})
+
+	return counts, err
+}
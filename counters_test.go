@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_Recorded_Changes_Are_Counted_From_History(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	_, _ = version.BumpPatch("p1")
+	_, _ = version.BumpPatch("p1")
+	_, _ = version.BumpMinor("p1")
+	namespaced, _ := version.Namespace("team")
+	_, _ = namespaced.SetVersion("p2", "1.0.0")
+	collector := NewHistoryCollector(version, time.Minute)
+
+	err := testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP vbump_recorded_changes_total Number of changes recorded in the history of all projects, labelled with namespace, projectname and element, equal on all instances sharing the storage
+# TYPE vbump_recorded_changes_total counter
+vbump_recorded_changes_total{element="minor",namespace="",project="p1"} 1
+vbump_recorded_changes_total{element="patch",namespace="",project="p1"} 2
+vbump_recorded_changes_total{element="set",namespace="team",project="p2"} 1
+`))
+
+	Ω.Expect(err).To(BeNil())
+}
+
+func Test_Recorded_Changes_Are_Refreshed(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	_, _ = version.BumpPatch("p1")
+	now := time.Now()
+	collector := NewHistoryCollector(version, time.Minute)
+	collector.now = func() time.Time { return now }
+
+	first := testutil.CollectAndCount(collector)
+	_, _ = version.BumpMajor("p1")
+	cached := testutil.CollectAndCount(collector)
+	now = now.Add(time.Minute)
+	refreshed := testutil.CollectAndCount(collector)
+
+	Ω.Expect(first).To(Equal(1))
+	Ω.Expect(cached).To(Equal(1))
+	Ω.Expect(refreshed).To(Equal(2))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@nightly":  "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+var cronBounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+//Cron is a parsed cron expression with minute, hour, day of month, month and day of week
+type Cron struct {
+	fields  [5]uint64
+	anyDay  bool
+	anyWeek bool
+}
+
+//ParseCron parses a cron expression with five fields or a descriptor like "@nightly"
+func ParseCron(expression string) (*Cron, error) {
+	if descriptor, exists := cronDescriptors[expression]; exists {
+		expression = descriptor
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("%v is not a valid cron expression", expression)
+	}
+
+	cron := &Cron{anyDay: fields[2] == "*", anyWeek: fields[4] == "*"}
+	for i, field := range fields {
+		bits, err := parseCronField(field, cronBounds[i][0], cronBounds[i][1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "%v is not a valid cron expression", expression)
+		}
+		cron.fields[i] = bits
+	}
+	// sunday is 0 and 7
+	if cron.fields[4]&(1<<7) != 0 {
+		cron.fields[4] |= 1
+	}
+
+	return cron, nil
+}
+
+func parseCronField(field string, min int, max int) (uint64, error) {
+	bits := uint64(0)
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			parsed, err := strconv.Atoi(item[i+1:])
+			if err != nil || parsed < 1 {
+				return 0, errors.Errorf("invalid step in %v", item)
+			}
+			item, step = item[:i], parsed
+		}
+
+		from, to := min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			parsed, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, errors.Errorf("invalid value %v", item)
+			}
+			from, to = parsed, parsed
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, errors.Errorf("invalid range %v", item)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, errors.Errorf("%v is out of range %v-%v", item, min, max)
+		}
+
+		for value := from; value <= to; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+//Matches returns true, if the cron expression is due in the minute of the given time
+func (cron *Cron) Matches(t time.Time) bool {
+	matches := func(field int, value int) bool {
+		return cron.fields[field]&(1<<uint(value)) != 0
+	}
+
+	day, week := matches(2, t.Day()), matches(4, int(t.Weekday()))
+	dayMatches := day && week
+	if !cron.anyDay && !cron.anyWeek {
+		// like cron, restricted day of month and day of week match either
+		dayMatches = day || week
+	}
+
+	return matches(0, t.Minute()) && matches(1, t.Hour()) && matches(3, int(t.Month())) && dayMatches
+}
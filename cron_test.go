@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Parse_Cron(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	monday := time.Date(2026, 10, 12, 2, 30, 0, 0, time.UTC)
+	sunday := time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)
+
+	for _, example := range []struct {
+		expression string
+		time       time.Time
+		matches    bool
+	}{
+		{"30 2 * * *", monday, true},
+		{"*/15 * * * *", monday, true},
+		{"*/20 * * * *", monday, false},
+		{"0-29 2 * * *", monday, false},
+		{"30 1,2 * * 1-5", monday, true},
+		{"30 2 * * 1-5", sunday, false},
+		{"0 0 * * 7", sunday, true},
+		{"@nightly", sunday, true},
+		{"30 2 1 * 1", monday, true},
+		{"30 2 1 * 2", monday, false},
+	} {
+		cron, err := ParseCron(example.expression)
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(cron.Matches(example.time)).To(Equal(example.matches), example.expression)
+	}
+}
+
+func Test_Parse_Invalid_Cron(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for _, expression := range []string{"* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@often"} {
+		_, err := ParseCron(expression)
+		Ω.Expect(err).NotTo(BeNil(), expression)
+	}
+}
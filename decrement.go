@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	tokenKey   = "token"
+	adminScope = "admin"
+)
+
+//Decrement decrements the given element (major, minor or patch) for given project to correct an accidental bump
+func (v *Version) Decrement(project string, element string) (*HistoryEntry, error) {
+	entry, err := v.change(project, "decrement-"+element, func(currentVersion string) (string, error) {
+		if err := checkStoredVersion(project, currentVersion); err != nil {
+			return "", err
+		}
+		return previousVersion(currentVersion, element)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot decrement %v version on project %v", element, project)
+	}
+
+	return entry, nil
+}
+
+//previousVersion decrements the given element of the version and resets the lower parts
+func previousVersion(version string, element string) (string, error) {
+	major, minor, patch := extractVersionParts(releaseOf(version))
+	switch element {
+	case "major":
+		major = convertAndDec(major)
+		minor = resetPart(minor)
+		patch = resetPart(patch)
+	case "minor":
+		minor = convertAndDec(minor)
+		patch = resetPart(patch)
+	case "patch":
+		patch = convertAndDec(patch)
+	default:
+		return "", invalidVersionf("%v is not a valid version element", element)
+	}
+	if major == "" || (element == "minor" && minor == "") || (element == "patch" && patch == "") {
+		return "", invalidVersionf("Cannot decrement %v of version %v", element, version)
+	}
+
+	return formatVersion(major, minor, patch), nil
+}
+
+//convertAndDec returns "" for missing or zero parts, which cannot be decremented
+func convertAndDec(part string) string {
+	versionToDec, err := strconv.Atoi(part)
+	if err != nil || versionToDec <= 0 {
+		return ""
+	}
+
+	return strconv.Itoa(versionToDec - 1)
+}
+
+//AdminMiddleware rejects requests without a token granted the admin scope
+func (handler *Handler) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c) {
+			_ = c.AbortWithError(http.StatusForbidden, errors.Errorf("%v requires a token with admin scope", c.Request.URL.Path))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+//isAdmin returns true, if the request carries a token granted the admin scope
+func isAdmin(context *gin.Context) bool {
+	token, _ := context.Get(tokenKey)
+	if token, ok := token.(*Token); ok {
+		return token.HasScope(adminScope)
+	}
+
+	return false
+}
+
+//OnDecrementMajor is a handler for decrementing the major version of a given project
+func (handler *Handler) OnDecrementMajor(context *gin.Context) {
+	handler.decrement(context, "major")
+}
+
+//OnDecrementMinor is a handler for decrementing the minor version of a given project
+func (handler *Handler) OnDecrementMinor(context *gin.Context) {
+	handler.decrement(context, "minor")
+}
+
+//OnDecrementPatch is a handler for decrementing the patch version of a given project
+func (handler *Handler) OnDecrementPatch(context *gin.Context) {
+	handler.decrement(context, "patch")
+}
+
+func (handler *Handler) decrement(context *gin.Context, element string) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	entry, err := service.Decrement(context.Param("project"), element)
+	if err != nil {
+		abortChange(context, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	handler.changed(context, service, entry)
+	handler.changeLog(context).Infof("decrement %v version to %v on project %v", element, entry.Version, projectKey(context))
+	context.String(http.StatusOK, "%s", service.Display(context.Param("project"), entry.Version))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Previous_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for _, example := range []struct{ version, element, expected string }{
+		{"1.4.2", "patch", "1.4.1"},
+		{"1.4.2", "minor", "1.3.0"},
+		{"2.4.2", "major", "1.0.0"},
+		{"2.4", "major", "1.0"},
+		{"3", "major", "2"},
+	} {
+		version, err := previousVersion(example.version, example.element)
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(version).To(Equal(example.expected))
+	}
+}
+
+func Test_Previous_Version_Of_Zero_Part(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for _, example := range []struct{ version, element string }{
+		{"1.4.0", "patch"},
+		{"1.0.3", "minor"},
+		{"0.4.3", "major"},
+		{"1.4", "patch"},
+		{"1", "minor"},
+	} {
+		_, err := previousVersion(example.version, example.element)
+		Ω.Expect(err).NotTo(BeNil())
+	}
+}
+
+func Test_Decrement_Records_History(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+
+	entry, err := version.Decrement("p1", "patch")
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Element).To(Equal("decrement-patch"))
+	Ω.Expect(entry.Previous).To(Equal("1.4.2"))
+	Ω.Expect(current).To(Equal("1.4.1"))
+}
+
+func Test_Decrement_Requires_Admin_Scope(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	tokens := NewTokenStore()
+	tokens.Add("ci", "ci-secret", "ns:team")
+	tokens.Add("ops", "admin-secret", "*", "admin")
+	handler := NewHandler(NewVersion(adapter.NewMock("1.4.2", "p1")), nil)
+	handler.SetTokenStore(tokens)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/decrement/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer ci-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/decrement/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("1.4.1"))
+}
+
+func Test_Decrement_Without_Authentication(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.4.2", "p1")), nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/decrement/patch/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(403))
+}
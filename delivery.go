@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	deliveryDocument = "delivery"
+	deliveryHeader   = "X-Vbump-Delivery"
+	signatureHeader  = "X-Vbump-Signature-256"
+
+	//maxDeliveries is the number of recent successful deliveries kept per project
+	maxDeliveries = 20
+	//maxFailedDeliveries bounds the failed deliveries kept per project until they are delivered
+	maxFailedDeliveries = 500
+)
+
+//Delivery is a webhook call sending an event, it can be delivered again by its id
+type Delivery struct {
+	ID       string    `json:"id"`
+	URL      string    `json:"url"`
+	Event    Event     `json:"event"`
+	Payload  string    `json:"payload"`
+	Attempts int       `json:"attempts"`
+	Status   int       `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+//Failed returns true, if the last attempt of the delivery failed
+func (delivery *Delivery) Failed() bool {
+	return delivery.Error != ""
+}
+
+func newDeliveryID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+//signPayload returns the HMAC SHA-256 signature of the payload like github webhooks, "sha256=<hex>"
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookSecretOf(meta *Metadata) string {
+	if meta == nil {
+		return ""
+	}
+
+	return meta.WebhookSecret
+}
+
+//Deliveries returns the recent webhook deliveries of the given project, the oldest first
+func (v *Version) Deliveries(project string) ([]Delivery, error) {
+	deliveries := []Delivery{}
+	document, err := v.fileProvider.ReadDocument(deliveryDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get deliveries for project %v", project)
+	}
+	if document == nil {
+		return deliveries, nil
+	}
+
+	if err := json.Unmarshal(document, &deliveries); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse deliveries for project %v", project)
+	}
+
+	return deliveries, nil
+}
+
+//Delivery returns the delivery of the given project with the id, nil if it is unknown
+func (v *Version) Delivery(project string, id string) (*Delivery, error) {
+	deliveries, err := v.Deliveries(project)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deliveries {
+		if deliveries[i].ID == id {
+			return &deliveries[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+//recordDelivery stores a new or repeated delivery of the given project, failed deliveries are kept until they are delivered
+func (v *Version) recordDelivery(project string, delivery *Delivery) error {
+	// deliveries finish in the background, so they are serialized apart from the changes of the project
+	unlock, err := v.locks.Lock(v.namespace + "/" + project + "/" + deliveryDocument)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	deliveries, err := v.Deliveries(project)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range deliveries {
+		if deliveries[i].ID == delivery.ID {
+			deliveries[i], replaced = *delivery, true
+		}
+	}
+	if !replaced {
+		deliveries = append(deliveries, *delivery)
+	}
+	deliveries = recentDeliveries(deliveries)
+
+	document, err := json.Marshal(deliveries)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode deliveries for project %v", project)
+	}
+	if err := v.fileProvider.StoreDocument(deliveryDocument, project, document); err != nil {
+		return errors.Wrapf(err, "Cannot store deliveries for project %v", project)
+	}
+
+	return nil
+}
+
+//recentDeliveries drops the oldest successful and failed deliveries beyond their limits
+func recentDeliveries(deliveries []Delivery) []Delivery {
+	delivered, failed := 0, 0
+	kept := []Delivery{}
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		switch {
+		case deliveries[i].Failed() && failed < maxFailedDeliveries:
+			failed++
+		case !deliveries[i].Failed() && delivered < maxDeliveries:
+			delivered++
+		default:
+			continue
+		}
+		kept = append([]Delivery{deliveries[i]}, kept...)
+	}
+
+	return kept
+}
+
+//countDelivery counts the result of a delivery attempt
+func countDelivery(delivery *Delivery) {
+	result := "delivered"
+	if delivery.Failed() {
+		result = "failed"
+	}
+	webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+//OnListDeliveries is a handler for listing the recent deliveries of a given project, only the failed ones with ?failed=true
+func (handler *Handler) OnListDeliveries(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	deliveries, err := service.Deliveries(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if context.Query("failed") == "true" {
+		failed := []Delivery{}
+		for _, delivery := range deliveries {
+			if delivery.Failed() {
+				failed = append(failed, delivery)
+			}
+		}
+		deliveries = failed
+	}
+
+	context.JSON(http.StatusOK, deliveries)
+}
+
+//OnRedeliverFailed is a handler for sending all failed deliveries of a given project again
+func (handler *Handler) OnRedeliverFailed(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+	project := context.Param("project")
+
+	deliveries, err := service.Deliveries(project)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	redelivered := []Delivery{}
+	for i := range deliveries {
+		if !deliveries[i].Failed() {
+			continue
+		}
+		if err := handler.redeliver(service, project, &deliveries[i]); err != nil {
+			_ = context.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		redelivered = append(redelivered, deliveries[i])
+	}
+
+	handler.logger.Infof("redeliver %v failed deliveries of project %v", len(redelivered), projectKey(context))
+	context.JSON(http.StatusOK, redelivered)
+}
+
+//redeliver sends the delivery again signed with the current secret of the project and records the result
+func (handler *Handler) redeliver(service *Version, project string, delivery *Delivery) error {
+	meta, err := service.GetMetadata(project)
+	if err != nil {
+		return err
+	}
+
+	notifier := handler.notifier
+	if notifier == nil {
+		notifier = NewNotifier("", nil, handler.logger)
+	}
+	secret, err := notifier.secretOf(meta)
+	if err != nil {
+		return err
+	}
+	if err := notifier.Deliver(delivery, secret); err != nil {
+		handler.logger.Error(err)
+	}
+	countDelivery(delivery)
+
+	return service.recordDelivery(project, delivery)
+}
+
+//OnRedeliver is a handler for sending a delivery of a given project again, signed with the current secret of the project
+func (handler *Handler) OnRedeliver(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+	project := context.Param("project")
+
+	delivery, err := service.Delivery(project, context.Param("id"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if delivery == nil {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No delivery %v for project %v", context.Param("id"), projectKey(context)))
+		return
+	}
+	if err := handler.redeliver(service, project, delivery); err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.logger.Infof("redeliver %v of project %v with status %v", delivery.ID, projectKey(context), delivery.Status)
+	context.JSON(http.StatusOK, delivery)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+type receivedDelivery struct {
+	id        string
+	signature string
+	payload   []byte
+}
+
+func newSigningWebhook(status *int) (*httptest.Server, chan receivedDelivery) {
+	received := make(chan receivedDelivery, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := ioutil.ReadAll(r.Body)
+		received <- receivedDelivery{id: r.Header.Get(deliveryHeader), signature: r.Header.Get(signatureHeader), payload: payload}
+		w.WriteHeader(*status)
+	}))
+
+	return server, received
+}
+
+func Test_Deliveries_Are_Signed_And_Recorded(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	status := http.StatusOK
+	webhook, received := newSigningWebhook(&status)
+	defer webhook.Close()
+	datadir, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(datadir)
+	// deliveries are recorded in the background, so the test needs a storage safe for concurrent use
+	version := NewVersion(adapter.New(datadir))
+	_, _ = version.SetVersion("p1", "1.0.0")
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Webhook: webhook.URL, WebhookSecret: "s3cr3t"}})
+	handler := NewHandler(version, nil)
+	handler.SetNotifier(NewNotifier("", nil, nil))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	delivery := receivedDelivery{}
+	Ω.Eventually(received, time.Second).Should(Receive(&delivery))
+	Ω.Expect(delivery.id).To(HaveLen(32))
+	Ω.Expect(delivery.signature).To(Equal(signPayload("s3cr3t", delivery.payload)))
+	Ω.Eventually(func() *Delivery {
+		recorded, _ := version.Delivery("p1", delivery.id)
+		return recorded
+	}, time.Second).ShouldNot(BeNil())
+	recorded, _ := version.Delivery("p1", delivery.id)
+	Ω.Expect(recorded.Status).To(Equal(http.StatusOK))
+	Ω.Expect(recorded.Attempts).To(Equal(1))
+}
+
+func Test_Redeliver_Failed_Delivery(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	status := http.StatusBadGateway
+	webhook, received := newSigningWebhook(&status)
+	defer webhook.Close()
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	notifier := NewNotifier(webhook.URL, nil, nil)
+	delivery, err := notifier.send(Event{Project: "p1", Version: "1.0.1"}, nil)
+	_ = version.recordDelivery("p1", delivery)
+	<-received
+	status = http.StatusOK
+	handler := NewHandler(version, nil)
+	handler.SetNotifier(notifier)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/webhooks/p1/deliveries/"+delivery.ID+"/redeliver", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	redelivered := Delivery{}
+	_ = json.Unmarshal(res.Body.Bytes(), &redelivered)
+	deliveries, _ := version.Deliveries("p1")
+
+	Ω.Expect(err).NotTo(BeNil())
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(redelivered.Status).To(Equal(http.StatusOK))
+	Ω.Expect(redelivered.Attempts).To(Equal(2))
+	Ω.Expect((<-received).id).To(Equal(delivery.ID))
+	Ω.Expect(deliveries).To(HaveLen(1))
+	Ω.Expect(deliveries[0].Error).To(BeEmpty())
+}
+
+func Test_Redeliver_Unknown_Delivery(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/webhooks/p1/deliveries/unknown/redeliver", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusNotFound))
+}
+
+func Test_Only_Recent_Deliveries_Are_Kept(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	for i := 0; i < maxDeliveries+5; i++ {
+		_ = version.recordDelivery("p1", &Delivery{ID: newDeliveryID()})
+	}
+
+	deliveries, err := version.Deliveries("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(deliveries).To(HaveLen(maxDeliveries))
+}
+
+func Test_Failed_Deliveries_Are_Kept(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.recordDelivery("p1", &Delivery{ID: "failed", Error: "connection refused"})
+	for i := 0; i < maxDeliveries+5; i++ {
+		_ = version.recordDelivery("p1", &Delivery{ID: newDeliveryID()})
+	}
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/webhooks/p1/deliveries?failed=true", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	failed := []Delivery{}
+	_ = json.Unmarshal(res.Body.Bytes(), &failed)
+	deliveries, _ := version.Deliveries("p1")
+
+	Ω.Expect(deliveries).To(HaveLen(maxDeliveries + 1))
+	Ω.Expect(failed).To(HaveLen(1))
+	Ω.Expect(failed[0].ID).To(Equal("failed"))
+}
+
+func Test_Redeliver_All_Failed_Deliveries(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	status := http.StatusOK
+	webhook, received := newSigningWebhook(&status)
+	defer webhook.Close()
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.recordDelivery("p1", &Delivery{ID: "delivered", URL: webhook.URL, Payload: "{}", Attempts: 1})
+	_ = version.recordDelivery("p1", &Delivery{ID: "failed-1", URL: webhook.URL, Payload: "{}", Attempts: 1, Error: "timeout"})
+	_ = version.recordDelivery("p1", &Delivery{ID: "failed-2", URL: webhook.URL, Payload: "{}", Attempts: 3, Error: "status 502"})
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/webhooks/p1/redeliver", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	redelivered := []Delivery{}
+	_ = json.Unmarshal(res.Body.Bytes(), &redelivered)
+	deliveries, _ := version.Deliveries("p1")
+
+	Ω.Expect(redelivered).To(HaveLen(2))
+	Ω.Expect(received).To(HaveLen(2))
+	for _, delivery := range deliveries {
+		Ω.Expect(delivery.Failed()).To(BeFalse())
+	}
+	Ω.Expect(deliveries[2].Attempts).To(Equal(4))
+}
+
+func Test_Webhook_Secret_Is_Write_Only(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Owner: "payments", Config: Config{WebhookSecret: "s3cr3t"}})
+	router := NewHandler(version, nil).GetRouter()
+
+	for _, path := range []string{"/project/p1/meta", "/config/p1", "/projects", "/export"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(res, req)
+
+		Ω.Expect(res.Code).To(Equal(http.StatusOK), path)
+		Ω.Expect(res.Body.String()).NotTo(ContainSubstring("s3cr3t"), path)
+		Ω.Expect(res.Body.String()).To(ContainSubstring(maskedSecret), path)
+	}
+
+	// a config read and sent back unchanged keeps the secret
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/config/p1", nil)
+	router.ServeHTTP(res, req)
+	req, _ = http.NewRequest("PUT", "/config/p1", res.Body)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	meta, _ := version.GetMetadata("p1")
+
+	Ω.Expect(meta.WebhookSecret).To(Equal("s3cr3t"))
+}
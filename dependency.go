@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+//ErrDependencyCycle is returned, if the dependents of a project would depend on the project again
+var ErrDependencyCycle = errors.New("dependents form a cycle")
+
+//PropagatedChange is the patch bump of a dependent project triggered by a bump of its dependency
+type PropagatedChange struct {
+	Project string
+	Entry   *HistoryEntry
+	Err     error
+}
+
+//dependentsOf returns the projects depending on the given project
+func (v *Version) dependentsOf(project string) ([]string, error) {
+	meta, err := v.GetMetadata(project)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+
+	return meta.Dependents, nil
+}
+
+//checkDependents returns an error, if one of the dependents leads back to the project
+func (v *Version) checkDependents(project string, dependents []string) error {
+	var visit func(path []string, dependents []string) error
+	visited := map[string]bool{}
+	visit = func(path []string, dependents []string) error {
+		for _, dependent := range dependents {
+			if dependent == project {
+				return errors.Wrapf(ErrDependencyCycle, "Invalid dependents %v", strings.Join(append(path, dependent), " -> "))
+			}
+			if visited[dependent] {
+				continue
+			}
+			visited[dependent] = true
+
+			next, err := v.dependentsOf(dependent)
+			if err != nil {
+				return err
+			}
+			if err := visit(append(path, dependent), next); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return visit([]string{project}, dependents)
+}
+
+//Propagate bumps the patch of all projects depending on the bumped project and their dependents in turn
+func (v *Version) Propagate(project string, entry *HistoryEntry) []PropagatedChange {
+	changes := []PropagatedChange{}
+	versions := map[string]string{project: entry.Version}
+	for queue := []string{project}; len(queue) > 0; queue = queue[1:] {
+		dependency := queue[0]
+		dependents, err := v.dependentsOf(dependency)
+		if err != nil {
+			changes = append(changes, PropagatedChange{Project: dependency, Err: err})
+			continue
+		}
+
+		for _, dependent := range dependents {
+			if _, visited := versions[dependent]; visited {
+				continue
+			}
+			versions[dependent] = ""
+
+			annotated := v.WithAnnotation(Annotation{
+				Reason: fmt.Sprintf("dependency %v bumped to %v", dependency, versions[dependency]),
+				Actor:  v.annotation.Actor,
+				Client: v.annotation.Client,
+			})
+			bumped, err := annotated.Bump(dependent, "patch")
+			changes = append(changes, PropagatedChange{Project: dependent, Entry: bumped, Err: err})
+			if err == nil {
+				versions[dependent] = bumped.Version
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	return changes
+}
+
+//propagate bumps the dependents of the project of the request, if requested with ?propagate=true, and lists them in the X-Vbump-Propagated header
+func (handler *Handler) propagate(context *gin.Context, service *Version, entry *HistoryEntry) {
+	if context.Query("propagate") != "true" {
+		return
+	}
+
+	propagated := handler.propagateBump(context.Param("namespace"), context.Param("project"), service, entry, handler.changeLog(context))
+	if len(propagated) > 0 {
+		context.Header("X-Vbump-Propagated", strings.Join(propagated, ","))
+	}
+}
+
+//propagateBump bumps the dependents of the project and returns them as project=version
+func (handler *Handler) propagateBump(namespace string, project string, service *Version, entry *HistoryEntry, logger log.FieldLogger) []string {
+	key := project
+	if namespace != "" {
+		key = namespace + "/" + project
+	}
+
+	propagated := []string{}
+	for _, change := range service.Propagate(project, entry) {
+		if change.Err != nil {
+			countFailure(namespace, change.Project, "bump", change.Err)
+			logger.Errorf("cannot propagate bump of %v to %v: %v", key, change.Project, change.Err)
+			continue
+		}
+
+		countBump(namespace, change.Project, "patch")
+		countClientChange(change.Entry)
+		handler.publish(namespace, change.Project, service, change.Entry)
+		logger.Infof("propagate bump of %v to %v on project %v", key, change.Entry.Version, change.Project)
+		propagated = append(propagated, change.Project+"="+change.Entry.Version)
+	}
+
+	return propagated
+}
+
+//dependencyStatus maps errors of setting the dependents of a project to a http status
+func dependencyStatus(err error) int {
+	if errors.Cause(err) == ErrDependencyCycle {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ErrEpochChange) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, ErrHookNotAllowed) {
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
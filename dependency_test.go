@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Bump_Propagates_To_Dependents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "library-x"))
+	_, _ = version.SetVersion("service-a", "2.0.0")
+	_, _ = version.SetVersion("service-b", "3.0.0")
+	_ = version.SetMetadata("service-a", &Metadata{Config: Config{Dependents: []string{"service-b"}}})
+	_ = version.SetMetadata("library-x", &Metadata{Config: Config{Dependents: []string{"service-a", "service-b"}}})
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/library-x?propagate=true", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	a, _ := version.GetVersion("service-a")
+	b, _ := version.GetVersion("service-b")
+	history, _ := version.History("service-a")
+
+	Ω.Expect(res.Body.String()).To(Equal("1.1.0"))
+	Ω.Expect(res.Header().Get("X-Vbump-Propagated")).To(Equal("service-a=2.0.1,service-b=3.0.1"))
+	Ω.Expect(a).To(Equal("2.0.1"))
+	Ω.Expect(b).To(Equal("3.0.1"))
+	Ω.Expect(history[len(history)-1].Reason).To(Equal("dependency library-x bumped to 1.1.0"))
+}
+
+func Test_Bump_Without_Propagation(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "library-x"))
+	_, _ = version.SetVersion("service-a", "2.0.0")
+	_ = version.SetMetadata("library-x", &Metadata{Config: Config{Dependents: []string{"service-a"}}})
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/library-x", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	a, _ := version.GetVersion("service-a")
+
+	Ω.Expect(a).To(Equal("2.0.0"))
+}
+
+func Test_Propagation_Reports_Failed_Dependents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "library-x"))
+	_, _ = version.SetVersion("archived", "2.0.0")
+	_ = version.Archive("archived")
+	_ = version.SetMetadata("library-x", &Metadata{Config: Config{Dependents: []string{"archived"}}})
+	entry, _ := version.Bump("library-x", "patch")
+
+	changes := version.Propagate("library-x", entry)
+
+	Ω.Expect(changes).To(HaveLen(1))
+	Ω.Expect(changes[0].Err).NotTo(BeNil())
+}
+
+func Test_Dependency_Cycles_Are_Rejected(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "library-x"))
+	_, _ = version.SetVersion("service-a", "2.0.0")
+	_ = version.SetMetadata("library-x", &Metadata{Config: Config{Dependents: []string{"service-a"}}})
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/config/service-a", strings.NewReader(`{"schemaVersion": 1, "dependents": ["library-x"]}`))
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusBadRequest))
+	Ω.Expect(version.SetMetadata("library-x", &Metadata{Config: Config{Dependents: []string{"library-x"}}})).NotTo(BeNil())
+}
+
+func Test_Dependents_Must_Be_Valid_Project_Names(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "library-x"))
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/config/library-x", strings.NewReader(`{"schemaVersion": 1, "dependents": ["../dep"]}`))
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusBadRequest))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+//ErrReadOnly is returned for writes, while the free space of the datadir is below the minimum
+var ErrReadOnly = newError(ErrStorage, "Storage is read-only, the free space of the datadir is below the minimum")
+
+//DiskGuard switches the storage to read-only, when the free space of the datadir drops below the minimum
+type DiskGuard struct {
+	path     string
+	minFree  uint64
+	readOnly int32
+	usage    func(path string) (free uint64, total uint64, err error)
+}
+
+//NewDiskGuard constructs a guard for the free space of the datadir
+func NewDiskGuard(path string, minFree uint64) *DiskGuard {
+	return &DiskGuard{path: path, minFree: minFree, usage: diskUsage}
+}
+
+//Check measures the free space of the datadir and updates the read-only state and the metrics
+func (guard *DiskGuard) Check() error {
+	free, total, err := guard.usage(guard.path)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot get free space of %v", guard.path)
+	}
+
+	datadirFreeBytes.Set(float64(free))
+	datadirSizeBytes.Set(float64(total))
+	readOnly := int32(0)
+	if free < guard.minFree {
+		readOnly = 1
+	}
+	atomic.StoreInt32(&guard.readOnly, readOnly)
+	return nil
+}
+
+//ReadOnly returns true, if the free space was below the minimum at the last check
+func (guard *DiskGuard) ReadOnly() bool {
+	return atomic.LoadInt32(&guard.readOnly) == 1
+}
+
+//Start checks the free space in the background
+func (guard *DiskGuard) Start(interval time.Duration, logger *log.Logger) {
+	go func() {
+		for range time.Tick(interval) {
+			readOnly := guard.ReadOnly()
+			if err := guard.Check(); err != nil {
+				logger.Error(err)
+				continue
+			}
+			if guard.ReadOnly() != readOnly {
+				logger.Warnf("datadir %v switched to read-only %v", guard.path, guard.ReadOnly())
+			}
+		}
+	}()
+}
+
+//fencedProvider rejects writes, while the disk guard is read-only
+type fencedProvider struct {
+	adapter.IFileProvider
+	guard *DiskGuard
+}
+
+//FenceWrites rejects all writes to the storage, while the free space of the datadir is below the minimum of the guard
+func (v *Version) FenceWrites(guard *DiskGuard) {
+	v.fileProvider = &fencedProvider{IFileProvider: v.fileProvider, guard: guard}
+}
+
+func (f *fencedProvider) fence() error {
+	if f.guard.ReadOnly() {
+		return ErrReadOnly
+	}
+
+	return nil
+}
+
+func (f *fencedProvider) StoreVersion(project string, version string) error {
+	if err := f.fence(); err != nil {
+		return err
+	}
+
+	return f.IFileProvider.StoreVersion(project, version)
+}
+
+func (f *fencedProvider) StoreDocument(kind string, project string, document []byte) error {
+	if err := f.fence(); err != nil {
+		return err
+	}
+
+	return f.IFileProvider.StoreDocument(kind, project, document)
+}
+
+func (f *fencedProvider) DeleteDocument(kind string, project string) error {
+	if err := f.fence(); err != nil {
+		return err
+	}
+
+	return f.IFileProvider.DeleteDocument(kind, project)
+}
+
+func (f *fencedProvider) AppendHistory(project string, entry []byte) error {
+	if err := f.fence(); err != nil {
+		return err
+	}
+
+	return f.IFileProvider.AppendHistory(project, entry)
+}
+
+func (f *fencedProvider) ReplaceHistory(project string, entries [][]byte) error {
+	if err := f.fence(); err != nil {
+		return err
+	}
+
+	return f.IFileProvider.ReplaceHistory(project, entries)
+}
+
+func (f *fencedProvider) Namespace(namespace string) (adapter.IFileProvider, error) {
+	provider, err := f.IFileProvider.Namespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fencedProvider{IFileProvider: provider, guard: f.guard}, nil
+}
+
+//SetDiskGuard rejects changing requests with 507, while the datadir is read-only
+func (handler *Handler) SetDiskGuard(guard *DiskGuard) {
+	handler.diskGuard = guard
+}
+
+//ReadOnlyMiddleware rejects requests, which change projects, with 507 while the datadir is read-only
+func (handler *Handler) ReadOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch {
+		case c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions:
+		case strings.HasPrefix(c.FullPath(), "/transient/"), strings.HasSuffix(c.FullPath(), "/render/:project"):
+		case handler.diskGuard.ReadOnly():
+			_ = c.AbortWithError(http.StatusInsufficientStorage, errors.Wrapf(ErrReadOnly, "Cannot serve %v", c.Request.URL.Path))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func Test_Disk_Guard_Fences_Writes(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	free := uint64(50)
+	guard := NewDiskGuard("data", 100)
+	guard.usage = func(string) (uint64, uint64, error) { return free, 1000, nil }
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.FenceWrites(guard)
+
+	errCheck := guard.Check()
+	_, errBump := version.BumpPatch("p1")
+	current, errGet := version.GetVersion("p1")
+	free = 200
+	_ = guard.Check()
+	bumped, errAfter := version.BumpPatch("p1")
+
+	Ω.Expect(errCheck).To(BeNil())
+	Ω.Expect(errors.Cause(errBump)).To(Equal(ErrReadOnly))
+	Ω.Expect(errGet).To(BeNil())
+	Ω.Expect(current).To(Equal("1.0.0"))
+	Ω.Expect(errAfter).To(BeNil())
+	Ω.Expect(bumped).To(Equal("1.0.1"))
+}
+
+func Test_Read_Only_Requests(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	guard := NewDiskGuard("data", 100)
+	guard.usage = func(string) (uint64, uint64, error) { return 0, 1000, nil }
+	_ = guard.Check()
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.FenceWrites(guard)
+	handler := NewHandler(version, nil)
+	handler.SetDiskGuard(guard)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusInsufficientStorage))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/transient/patch/1.0.0", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"ready": true, "storage": "closed", "readOnly": true}`))
+}
+
+func Test_Disk_Usage(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(dir)
+
+	free, total, err := diskUsage(dir)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(total).To(BeNumerically(">=", free))
+}
@@ -0,0 +1,15 @@
+// +build !windows
+
+package main
+
+import "syscall"
+
+//diskUsage returns the free space available to vbump and the size of the file system of the path
+func diskUsage(path string) (uint64, uint64, error) {
+	stat := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,10 @@
+// +build windows
+
+package main
+
+import "github.com/pkg/errors"
+
+//diskUsage is not supported on windows
+func diskUsage(path string) (uint64, uint64, error) {
+	return 0, 0, errors.New("Free space monitoring is not supported on windows")
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//DefaultDrainTimeout is the time a drain waits for pending webhook deliveries
+const DefaultDrainTimeout = 30 * time.Second
+
+//Snapshot is the export of all projects of all namespaces with their history
+type Snapshot struct {
+	Time       time.Time          `json:"time"`
+	Projects   *Export            `json:"projects"`
+	Namespaces map[string]*Export `json:"namespaces,omitempty"`
+}
+
+//DrainResult reports the steps of a drain before shutdown
+type DrainResult struct {
+	Deliveries  string `json:"deliveries"`
+	Replication int    `json:"failedReplications,omitempty"`
+	Snapshot    string `json:"snapshot,omitempty"`
+}
+
+//SetSnapshotDir enables a final snapshot of all projects into the directory on drain
+func (handler *Handler) SetSnapshotDir(dir string) {
+	handler.snapshotDir = dir
+}
+
+//Snapshot exports all projects of all namespaces with their history
+func (v *Version) Snapshot() (*Snapshot, error) {
+	snapshot := &Snapshot{Time: v.now().UTC(), Namespaces: map[string]*Export{}}
+	_, err := v.checkNamespaces(func(service *Version, namespace string) ([]FsckProblem, error) {
+		export, err := service.Export(true)
+		if err != nil {
+			return nil, err
+		}
+		if namespace == "" {
+			snapshot.Projects = export
+		} else {
+			snapshot.Namespaces[namespace] = export
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+//writeSnapshot writes the snapshot of all projects into the directory and returns the file
+func (handler *Handler) writeSnapshot() (string, error) {
+	snapshot, err := handler.version.Snapshot()
+	if err != nil {
+		return "", err
+	}
+	document, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", errors.Wrap(err, "Cannot encode snapshot")
+	}
+
+	if err := os.MkdirAll(handler.snapshotDir, 0755); err != nil {
+		return "", errors.Wrapf(err, "Cannot create snapshot directory %v", handler.snapshotDir)
+	}
+	filename := filepath.Join(handler.snapshotDir, "vbump-"+snapshot.Time.Format("20060102T150405Z")+".json")
+	if err := ioutil.WriteFile(filename, document, 0644); err != nil {
+		return "", errors.Wrapf(err, "Cannot write snapshot %v", filename)
+	}
+
+	return filename, nil
+}
+
+//Drain marks vbump as not ready, waits for pending webhook deliveries, sends pending replications and audited reads and writes the final snapshot
+func (handler *Handler) Drain(timeout time.Duration) (*DrainResult, error) {
+	atomic.StoreInt32(&handler.draining, 1)
+
+	result := &DrainResult{Deliveries: "completed"}
+	// cached versions are written through, so there is nothing to flush
+	if !handler.waitForDeliveries(timeout) {
+		result.Deliveries = "timeout"
+	}
+	if handler.replicator != nil {
+		result.Replication = handler.replicator.Flush()
+	}
+	if handler.reads != nil {
+		handler.reads.Flush(handler.auditLog())
+	}
+	if handler.snapshotDir != "" {
+		snapshot, err := handler.writeSnapshot()
+		if err != nil {
+			return result, err
+		}
+		result.Snapshot = snapshot
+	}
+
+	return result, nil
+}
+
+//waitForDeliveries returns false, if webhook deliveries and outbox dispatches are still running after the timeout
+func (handler *Handler) waitForDeliveries(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		handler.deliveries.Wait()
+		if handler.notifier != nil {
+			handler.notifier.pending.Wait()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+//OnDrain is a handler for draining vbump before it is stopped, ?timeout=30s limits the wait for webhook deliveries
+func (handler *Handler) OnDrain(context *gin.Context) {
+	timeout := DefaultDrainTimeout
+	if text := context.Query("timeout"); text != "" {
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, errors.Wrapf(err, "Invalid timeout %v", text))
+			return
+		}
+		timeout = parsed
+	}
+
+	result, err := handler.Drain(timeout)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.auditLog().Infof("drained before shutdown, deliveries %v, snapshot %v", result.Deliveries, result.Snapshot)
+	context.JSON(http.StatusOK, result)
+}
+
+//drainingStatus answers the readiness check with 503 after a drain
+func (handler *Handler) drainingStatus(context *gin.Context) bool {
+	if atomic.LoadInt32(&handler.draining) == 0 {
+		return false
+	}
+
+	context.JSON(http.StatusServiceUnavailable, gin.H{"ready": false, "draining": true})
+	return true
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func drainedHandler(webhook string) *Handler {
+	tokens := NewTokenStore()
+	tokens.Add("deploy", "secret", "*")
+	mock := adapter.NewMock("1.0.0", "p1")
+	namespaced, _ := mock.Namespace("team")
+	_ = namespaced.StoreVersion("p2", "2.0.0")
+	handler := NewHandler(NewVersion(mock), nil)
+	handler.SetTokenStore(tokens)
+	handler.SetNotifier(NewNotifier(webhook, nil, nil))
+	return handler
+}
+
+func Test_Drain_Waits_For_Deliveries_And_Writes_Snapshot(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	delivered := int32(0)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&delivered, 1)
+	}))
+	defer webhook.Close()
+	dir, _ := ioutil.TempDir("", "snapshots")
+	defer os.RemoveAll(dir)
+	handler := drainedHandler(webhook.URL)
+	handler.SetSnapshotDir(dir)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/drain", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(atomic.LoadInt32(&delivered)).To(Equal(int32(1)))
+	result := DrainResult{}
+	_ = json.Unmarshal(res.Body.Bytes(), &result)
+	Ω.Expect(result.Deliveries).To(Equal("completed"))
+	Ω.Expect(result.Snapshot).To(HavePrefix(dir))
+	document, _ := ioutil.ReadFile(result.Snapshot)
+	snapshot := Snapshot{}
+	Ω.Expect(json.Unmarshal(document, &snapshot)).To(Succeed())
+	Ω.Expect(snapshot.Projects.Projects).To(HaveLen(1))
+	Ω.Expect(snapshot.Projects.Projects[0].Version).To(Equal("1.0.1"))
+	Ω.Expect(snapshot.Projects.Projects[0].History).To(HaveLen(1))
+	Ω.Expect(snapshot.Namespaces["team"].Projects[0].Version).To(Equal("2.0.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"ready": false, "draining": true}`))
+}
+
+func Test_Drain_Stops_Waiting_After_Timeout(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	release := make(chan struct{})
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer webhook.Close()
+	defer close(release)
+	handler := drainedHandler(webhook.URL)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/drain?timeout=20ms", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"deliveries": "timeout"}`))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultEpochSeparator = "!"
+	epochElement          = "epoch"
+)
+
+var (
+	//ErrEpochChange is returned when setting the epoch of a project by its metadata or config
+	ErrEpochChange = newError(ErrConflict, "the epoch is only raised by POST /epoch")
+
+	epochNumber    = regexp.MustCompile(`^\d+$`)
+	epochSeparator = regexp.MustCompile(`^[^0-9A-Za-z.+_ -]$`)
+)
+
+//validEpoch returns true for epochs, which are not negative, and separators of one character, which is no part of versions
+func validEpoch(epoch int, separator string) bool {
+	return epoch >= 0 && (separator == "" || epochSeparator.MatchString(separator))
+}
+
+//epochSeparatorOf returns the separator of the epoch of the config
+func epochSeparatorOf(config *Config) string {
+	if config.EpochSeparator == "" {
+		return defaultEpochSeparator
+	}
+
+	return config.EpochSeparator
+}
+
+//epochPrefix returns the epoch with its separator as shown before the version, "" for the epoch 0
+func epochPrefix(meta *Metadata) string {
+	if meta == nil || meta.Epoch == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(meta.Epoch) + epochSeparatorOf(&meta.Config)
+}
+
+//stripEpoch removes the epoch of the project from the version, a version of another epoch is rejected
+func stripEpoch(meta *Metadata, version string) (string, error) {
+	separator, epoch := defaultEpochSeparator, 0
+	if meta != nil {
+		separator, epoch = epochSeparatorOf(&meta.Config), meta.Epoch
+	}
+
+	parts := strings.SplitN(version, separator, 2)
+	if len(parts) != 2 || !epochNumber.MatchString(parts[0]) {
+		return version, nil
+	}
+	if given, _ := strconv.Atoi(parts[0]); given != epoch {
+		return "", invalidVersionf("%v has epoch %v, but the project is in epoch %v, use POST /epoch to start a new epoch", version, given, epoch)
+	}
+
+	return parts[1], nil
+}
+
+//StartEpoch increments the epoch of the project and sets the version, which may be lower than the version of the previous epoch
+func (v *Version) StartEpoch(project string, version string) (*HistoryEntry, error) {
+	// the epoch is read and raised under the lock of the metadata, so concurrent calls raise it one after the other
+	unlock, err := v.locks.Lock(v.namespace + "/" + project + "/" + metadataDocument)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	meta, err := v.GetMetadata(project)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		meta = &Metadata{}
+	}
+	if epoch, _ := stripEpoch(meta, version); epoch != version {
+		return nil, invalidVersionf("%v must be given without epoch", version)
+	}
+	version, err = v.parseVersion(project, version)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := v.change(project, epochElement, func(string) (string, error) {
+		return version, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot start a new epoch with version %v for project %v", version, project)
+	}
+
+	// the epoch is raised after the version is stored, so a rejected change keeps the epoch
+	meta.Epoch++
+	if err := v.storeMetadata(project, meta); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+//epochOf returns the epoch of the version set by the element, the epoch element sets the first version of the next epoch
+func (v *Version) epochOf(project string, element string) int {
+	epoch := 0
+	if meta, err := v.GetMetadata(project); err == nil && meta != nil {
+		epoch = meta.Epoch
+	}
+	if element == epochElement {
+		epoch++
+	}
+
+	return epoch
+}
+
+//displayInEpoch returns the version of the project with its prefix and the given epoch
+func (v *Version) displayInEpoch(project string, version string, epoch int) string {
+	meta, err := v.GetMetadata(project)
+	if err != nil || meta == nil {
+		return version
+	}
+
+	other := *meta
+	other.Epoch = epoch
+	return other.Prefix + epochPrefix(&other) + other.Padding.pad(version)
+}
+
+//displayInPreviousEpoch returns the version of the project with its prefix and the epoch before the current one
+func (v *Version) displayInPreviousEpoch(project string, version string) string {
+	meta, err := v.GetMetadata(project)
+	if err != nil || meta == nil {
+		return version
+	}
+
+	previous := *meta
+	previous.Epoch--
+	return previous.Prefix + epochPrefix(&previous) + previous.Padding.pad(version)
+}
+
+//OnStartEpoch is a handler for starting a new epoch of a project with a new version
+func (handler *Handler) OnStartEpoch(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	entry, err := service.StartEpoch(context.Param("project"), context.Param("version"))
+	if err != nil {
+		abortChange(context, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	handler.changed(context, service, entry)
+	if entry.Previous != "" {
+		context.Header("X-Vbump-Previous-Version", service.displayInPreviousEpoch(context.Param("project"), entry.Previous))
+	}
+	version := service.Display(context.Param("project"), entry.Version)
+	handler.changeLog(context).Infof("start new epoch with version %v on project %v", version, projectKey(context))
+	context.String(http.StatusOK, "%s", version)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Display_Version_With_Epoch(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.0", "p1"))
+	_ = version.storeMetadata("p1", &Metadata{Config: Config{Prefix: "v", Epoch: 2}})
+
+	Ω.Expect(version.Display("p1", "1.4.0")).To(Equal("v2!1.4.0"))
+
+	entry, err := version.Set("p1", "v2!1.5.0")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Version).To(Equal("1.5.0"))
+	entry, err = version.Set("p1", "1.6.0")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Version).To(Equal("1.6.0"))
+
+	_, err = version.Set("p1", "3!1.0.0")
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Epoch_With_Separator(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.0", "p1"))
+	_ = version.storeMetadata("p1", &Metadata{Config: Config{Epoch: 1, EpochSeparator: ":"}})
+
+	entry, err := version.Set("p1", "1:1.4.1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(version.Display("p1", entry.Version)).To(Equal("1:1.4.1"))
+	Ω.Expect((&Config{Epoch: 1, EpochSeparator: "."}).Validate()).NotTo(BeNil())
+	Ω.Expect((&Config{Epoch: 1, EpochSeparator: "x"}).Validate()).NotTo(BeNil())
+	Ω.Expect((&Config{Epoch: -1}).Validate()).NotTo(BeNil())
+}
+
+func Test_Start_Epoch(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	tokens := NewTokenStore()
+	tokens.Add("admin", "admin-secret", "*")
+	tokens.Add("ci", "ci-secret", "ns:team")
+	version := NewVersion(adapter.NewMock("5.2.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Owner: "team", Config: Config{Policy: &Policy{Monotonic: true}}})
+	handler := NewHandler(version, nil)
+	handler.SetTokenStore(tokens)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/epoch/p1/1.0.0", nil)
+	req.Header.Set("Authorization", "Bearer ci-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/epoch/p1/1.0.0", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("1!1.0.0"))
+	Ω.Expect(res.Header().Get("X-Vbump-Previous-Version")).To(Equal("5.2.0"))
+	meta, _ := version.GetMetadata("p1")
+	Ω.Expect(meta.Owner).To(Equal("team"))
+	Ω.Expect(meta.Epoch).To(Equal(1))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1!1.0.1"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/epoch/p1/1!2.0.0", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(422))
+}
+
+func Test_Epoch_Is_Kept_By_Config_And_Metadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.0", "p1"))
+	_ = version.storeMetadata("p1", &Metadata{Config: Config{Epoch: 2}})
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/config/p1", bytes.NewBufferString(`{"schemaVersion": 1, "prefix": "v"}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+	meta, _ := version.GetMetadata("p1")
+	Ω.Expect(meta.Epoch).To(Equal(2))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/project/p1/meta", bytes.NewBufferString(`{"owner": "team", "epoch": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(409))
+	meta, _ = version.GetMetadata("p1")
+	Ω.Expect(meta.Epoch).To(Equal(2))
+	Ω.Expect(meta.Owner).To(BeEmpty())
+}
+
+func Test_Concurrent_Epochs_Are_Raised_One_After_The_Other(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("5.2.0", "p1"))
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := version.StartEpoch("p1", "1.0.0")
+			errs <- err
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		Ω.Expect(<-errs).To(BeNil())
+	}
+
+	meta, _ := version.GetMetadata("p1")
+	Ω.Expect(meta.Epoch).To(Equal(5))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+//redactedHeaders are not sent with error reports, they carry credentials
+var redactedHeaders = map[string]bool{"Authorization": true, "Cookie": true, "X-Slack-Signature": true, "X-Hub-Signature-256": true}
+
+//ErrorReport is an event about a panic or a 5xx response, it is sent in the format of the sentry store api
+type ErrorReport struct {
+	EventID   string            `json:"event_id"`
+	Timestamp time.Time         `json:"timestamp"`
+	Level     string            `json:"level"`
+	Logger    string            `json:"logger"`
+	Platform  string            `json:"platform"`
+	Message   string            `json:"message"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Request   ReportedRequest   `json:"request"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+//ReportedRequest is the context of the request, which failed
+type ReportedRequest struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+//ErrorReporter sends error reports to sentry, if the dsn has a key, or posts them to any other url
+type ErrorReporter struct {
+	endpoint string
+	auth     string
+	client   *http.Client
+	logger   *log.Logger
+}
+
+//NewErrorReporter constructs a reporter for a sentry dsn like https://<key>@sentry.example.com/<project> or a generic url
+func NewErrorReporter(dsn string, logger *log.Logger) (*ErrorReporter, error) {
+	if logger == nil {
+		logger = log.New()
+	}
+
+	target, err := url.Parse(dsn)
+	if err != nil || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+		return nil, errors.Errorf("Invalid error reporting dsn %v", dsn)
+	}
+
+	reporter := &ErrorReporter{endpoint: target.String(), client: &http.Client{Timeout: 10 * time.Second}, logger: logger}
+	if target.User != nil {
+		project := path.Base(target.Path)
+		if _, err := strconv.Atoi(project); err != nil {
+			return nil, errors.Errorf("Invalid error reporting dsn %v, the path has to end with the sentry project id", dsn)
+		}
+		reporter.auth = fmt.Sprintf("Sentry sentry_version=7, sentry_client=vbump, sentry_key=%v", target.User.Username())
+		reporter.endpoint = (&url.URL{Scheme: target.Scheme, Host: target.Host, Path: path.Join(path.Dir(target.Path), "api", project, "store") + "/"}).String()
+	}
+
+	return reporter, nil
+}
+
+//Send posts the report, errors are logged only
+func (reporter *ErrorReporter) Send(report ErrorReport) {
+	if err := reporter.post(report); err != nil {
+		reporter.logger.Warn(err)
+	}
+}
+
+func (reporter *ErrorReporter) post(report ErrorReport) error {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "Cannot report error")
+	}
+
+	request, err := http.NewRequest(http.MethodPost, reporter.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "Cannot report error")
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if reporter.auth != "" {
+		request.Header.Set("X-Sentry-Auth", reporter.auth)
+	}
+
+	res, err := reporter.client.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "Cannot report error")
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("Report error failed with status %v", res.StatusCode)
+	}
+
+	return nil
+}
+
+//SetErrorReporter enables reporting of panics and 5xx responses
+func (handler *Handler) SetErrorReporter(reporter *ErrorReporter) {
+	handler.reporter = reporter
+}
+
+//ErrorReportingMiddleware recovers panics with a 500 and reports them and all 5xx responses in the background
+func (handler *Handler) ErrorReportingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err := errors.Errorf("panic: %v", recovered)
+				report := handler.errorReport(c, "fatal", err.Error(), http.StatusInternalServerError)
+				report.Extra = map[string]string{"stacktrace": string(debug.Stack())}
+				go handler.reporter.Send(report)
+				_ = c.AbortWithError(http.StatusInternalServerError, err)
+			}
+		}()
+
+		c.Next()
+
+		if status := c.Writer.Status(); status >= http.StatusInternalServerError {
+			message := http.StatusText(status)
+			if err := c.Errors.Last(); err != nil {
+				message = err.Error()
+			}
+			go handler.reporter.Send(handler.errorReport(c, "error", message, status))
+		}
+	}
+}
+
+//errorReport collects the request context, the headers are read before the request is done
+func (handler *Handler) errorReport(c *gin.Context, level string, message string, status int) ErrorReport {
+	headers := map[string]string{}
+	for name, values := range c.Request.Header {
+		if !redactedHeaders[name] {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+
+	tags := map[string]string{"status": strconv.Itoa(status)}
+	if route := c.FullPath(); route != "" {
+		tags["route"] = route
+	}
+	if project := projectKey(c); project != "" {
+		tags["project"] = project
+	}
+	if client := handler.client(c); client != "" {
+		tags["client"] = client
+	}
+
+	return ErrorReport{
+		EventID:   newDeliveryID(),
+		Timestamp: time.Now().UTC(),
+		Level:     level,
+		Logger:    "vbump",
+		Platform:  "go",
+		Message:   message,
+		Tags:      tags,
+		Request: ReportedRequest{
+			Method:      c.Request.Method,
+			URL:         c.Request.URL.Path,
+			QueryString: c.Request.URL.RawQuery,
+			Headers:     headers,
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+)
+
+func reportServer(reports chan<- ErrorReport, auth chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := ErrorReport{}
+		_ = json.NewDecoder(r.Body).Decode(&report)
+		auth <- r.URL.Path + " " + r.Header.Get("X-Sentry-Auth")
+		reports <- report
+	}))
+}
+
+func Test_Report_5xx_To_Sentry(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	reports, auth := make(chan ErrorReport, 1), make(chan string, 1)
+	server := reportServer(reports, auth)
+	defer server.Close()
+	reporter, err := NewErrorReporter("http://public@"+server.Listener.Addr().String()+"/42", nil)
+	Ω.Expect(err).NotTo(HaveOccurred())
+	handler := NewHandler(NewVersion(&unavailableProvider{adapter.NewMock("1.0.0", "p1")}), nil)
+	handler.SetErrorReporter(reporter)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1?reason=hotfix", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Vbump-Client", "jenkins")
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+	Ω.Eventually(auth, time.Second).Should(Receive(Equal("/api/42/store/ Sentry sentry_version=7, sentry_client=vbump, sentry_key=public")))
+	report := ErrorReport{}
+	Ω.Expect(reports).To(Receive(&report))
+	Ω.Expect(report.Level).To(Equal("error"))
+	Ω.Expect(report.Message).To(ContainSubstring("input/output error"))
+	Ω.Expect(report.Tags).To(Equal(map[string]string{"status": "503", "route": "/patch/:project", "project": "p1", "client": "jenkins"}))
+	Ω.Expect(report.Request.URL).To(Equal("/patch/p1"))
+	Ω.Expect(report.Request.QueryString).To(Equal("reason=hotfix"))
+	Ω.Expect(report.Request.Headers).NotTo(HaveKey("Authorization"))
+}
+
+func Test_Report_Panic_As_500(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	reports, auth := make(chan ErrorReport, 1), make(chan string, 1)
+	server := reportServer(reports, auth)
+	defer server.Close()
+	reporter, _ := NewErrorReporter(server.URL+"/errors", nil)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetErrorReporter(reporter)
+	router := handler.GetRouter().(*gin.Engine)
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusInternalServerError))
+	Ω.Eventually(auth, time.Second).Should(Receive(Equal("/errors ")))
+	report := ErrorReport{}
+	Ω.Expect(reports).To(Receive(&report))
+	Ω.Expect(report.Level).To(Equal("fatal"))
+	Ω.Expect(report.Message).To(Equal("panic: boom"))
+	Ω.Expect(report.Extra["stacktrace"]).To(ContainSubstring("errorreport_test.go"))
+}
+
+func Test_Do_Not_Report_4xx(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	reports, auth := make(chan ErrorReport, 1), make(chan string, 1)
+	server := reportServer(reports, auth)
+	defer server.Close()
+	reporter, _ := NewErrorReporter(server.URL, nil)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetErrorReporter(reporter)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/version/p1/invalid", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(BeNumerically("<", 500))
+	Ω.Consistently(auth, 100*time.Millisecond).ShouldNot(Receive())
+}
+
+func Test_Invalid_Error_Reporting_DSN(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for _, dsn := range []string{"sentry.example.com", "ftp://sentry.example.com", "https://key@sentry.example.com/project"} {
+		_, err := NewErrorReporter(dsn, nil)
+		Ω.Expect(err).To(HaveOccurred(), dsn)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+//categories of the errors returned by the version service, test them with errors.Is
+var (
+	//ErrNotFound is the category of errors about missing projects and reservations
+	ErrNotFound = errors.New("not found")
+	//ErrInvalidVersion is the category of errors about versions and elements the client got wrong
+	ErrInvalidVersion = errors.New("invalid version")
+	//ErrFrozen is the category of errors about projects, which must not change
+	ErrFrozen = errors.New("frozen")
+	//ErrConflict is the category of errors about changes conflicting with the state of the project
+	ErrConflict = errors.New("conflict")
+	//ErrStorage is the category of errors of the storage backend
+	ErrStorage = errors.New("storage failure")
+)
+
+//categorizedError is an error belonging to one of the error categories
+type categorizedError struct {
+	category error
+	message  string
+}
+
+func (err *categorizedError) Error() string {
+	return err.message
+}
+
+//Is returns true for the category of the error, so errors.Is(err, ErrNotFound) holds for all missing things
+func (err *categorizedError) Is(target error) bool {
+	return target == err.category
+}
+
+//newError returns a sentinel error of the category
+func newError(category error, message string) error {
+	return &categorizedError{category: category, message: message}
+}
+
+//invalidVersionf formats an error of the ErrInvalidVersion category
+func invalidVersionf(format string, args ...interface{}) error {
+	return errors.WithStack(&categorizedError{category: ErrInvalidVersion, message: fmt.Sprintf(format, args...)})
+}
+
+//StoredVersionError is returned for bumps of a project, whose stored version cannot be bumped
+type StoredVersionError struct {
+	Project string `json:"project"`
+	Version string `json:"version"`
+	Message string `json:"error"`
+}
+
+func (err *StoredVersionError) Error() string {
+	return err.Message
+}
+
+//Is returns true for the ErrConflict category, the client cannot fix the stored version by retrying
+func (err *StoredVersionError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+//checkStoredVersion returns a StoredVersionError, if the stored version of the project has no numeric release to bump
+func checkStoredVersion(project string, version string) error {
+	if version == "" || validateVersion(releaseOf(version)) {
+		return nil
+	}
+
+	return errors.WithStack(&StoredVersionError{
+		Project: project,
+		Version: version,
+		Message: fmt.Sprintf("Stored version %v of project %v is not a valid version, set a valid one to bump it again", version, project),
+	})
+}
+
+//changeStatus maps errors of changing a project to a http status
+func changeStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, ErrInvalidProjectName):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrHookNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, ErrSchemeHook), errors.Is(err, ErrAnalyzerHook):
+		return http.StatusBadGateway
+	case errors.Is(err, ErrReadOnly), errors.Is(err, ErrProjectTooLarge):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, ErrTooManyProjects):
+		return http.StatusForbidden
+	case errors.Is(err, ErrStorageUnavailable), errors.Is(err, ErrPolicyAgent):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidVersion):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrFrozen), errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrStorage):
+		return http.StatusInternalServerError
+	}
+
+	return fallback
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func Test_Errors_Belong_To_Categories(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(errors.Is(errors.Wrap(ErrUnknownProject, "Cannot bump"), ErrNotFound)).To(BeTrue())
+	Ω.Expect(errors.Is(ErrNoReservation, ErrNotFound)).To(BeTrue())
+	Ω.Expect(errors.Is(ErrArchived, ErrFrozen)).To(BeTrue())
+	Ω.Expect(errors.Is(ErrProjectExists, ErrConflict)).To(BeTrue())
+	Ω.Expect(errors.Is(ErrReservationOutdated, ErrConflict)).To(BeTrue())
+	Ω.Expect(errors.Is(ErrReadOnly, ErrStorage)).To(BeTrue())
+	Ω.Expect(errors.Is(&StorageError{Operation: "store_version", Err: errors.New("disk full")}, ErrStorage)).To(BeTrue())
+	Ω.Expect(errors.Is(invalidVersionf("%v is not a valid version", "x.y"), ErrInvalidVersion)).To(BeTrue())
+	Ω.Expect(errors.Is(ErrUnknownProject, ErrConflict)).To(BeFalse())
+	Ω.Expect(errors.Cause(errors.Wrap(ErrArchived, "Cannot bump"))).To(Equal(ErrArchived))
+}
+
+func Test_Change_Status_Of_Categories(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(changeStatus(errors.Wrap(ErrUnknownProject, "Cannot bump"), http.StatusInternalServerError)).To(Equal(http.StatusNotFound))
+	Ω.Expect(changeStatus(invalidVersionf("x is not a valid version"), http.StatusInternalServerError)).To(Equal(http.StatusUnprocessableEntity))
+	Ω.Expect(changeStatus(ErrArchived, http.StatusInternalServerError)).To(Equal(http.StatusConflict))
+	Ω.Expect(changeStatus(ErrProjectExists, http.StatusInternalServerError)).To(Equal(http.StatusConflict))
+	Ω.Expect(changeStatus(ErrStorageUnavailable, http.StatusInternalServerError)).To(Equal(http.StatusServiceUnavailable))
+	Ω.Expect(changeStatus(ErrReadOnly, http.StatusInternalServerError)).To(Equal(http.StatusInsufficientStorage))
+	Ω.Expect(changeStatus(errors.New("unexpected"), http.StatusInternalServerError)).To(Equal(http.StatusInternalServerError))
+}
+
+func Test_Invalid_Transient_Version_Is_Client_Error(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/transient/patch/x.y", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusUnprocessableEntity))
+}
+
+type unreadableProvider struct {
+	adapter.IFileProvider
+}
+
+func (provider *unreadableProvider) ReadVersion(project string) (string, error) {
+	return "", errors.New("permission denied")
+}
+
+func Test_Read_Failure_Is_Server_Error(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(&unreadableProvider{adapter.NewMock("1.0.0", "p1")}), nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusInternalServerError))
+}
+
+func Test_Bump_Of_Unknown_Project_In_Strict_Mode_Is_Not_Found(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	service := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	service.StrictProjects()
+	handler := NewHandler(service, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p2", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusNotFound))
+}
+
+func Test_Bump_Of_Invalid_Stored_Version_Is_Conflict_With_Detail(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.x", "p1")), nil)
+
+	for _, path := range []string{"/patch/p1", "/bump/p1/minor+patch"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		handler.GetRouter().ServeHTTP(res, req)
+
+		Ω.Expect(res.Code).To(Equal(http.StatusConflict), path)
+		Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "p1", "version": "1.x", "error": "Stored version 1.x of project p1 is not a valid version, set a valid one to bump it again"}`), path)
+	}
+}
+
+type unavailableProvider struct {
+	adapter.IFileProvider
+}
+
+func (provider *unavailableProvider) StoreVersion(project string, version string) error {
+	return &os.PathError{Op: "write", Path: project, Err: syscall.EIO}
+}
+
+func Test_Bump_With_Unavailable_Storage_Is_Retryable(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(&unavailableProvider{adapter.NewMock("1.0.0", "p1")}), nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+}
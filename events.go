@@ -0,0 +1,48 @@
+package main
+
+import "sync"
+
+//EventHub distributes the events of all changes to subscribers within this instance
+type EventHub struct {
+	sync.Mutex
+	subscribers map[chan Event]bool
+}
+
+//NewEventHub constructs a hub without subscribers
+func NewEventHub() *EventHub {
+	return &EventHub{subscribers: map[chan Event]bool{}}
+}
+
+//Subscribe returns a channel receiving all following events
+func (hub *EventHub) Subscribe() chan Event {
+	hub.Lock()
+	defer hub.Unlock()
+
+	events := make(chan Event, 16)
+	hub.subscribers[events] = true
+	return events
+}
+
+//Unsubscribe stops sending events to the channel and closes it
+func (hub *EventHub) Unsubscribe(events chan Event) {
+	hub.Lock()
+	defer hub.Unlock()
+
+	if hub.subscribers[events] {
+		delete(hub.subscribers, events)
+		close(events)
+	}
+}
+
+//Publish sends the event to all subscribers, slow subscribers miss events instead of blocking changes
+func (hub *EventHub) Publish(event Event) {
+	hub.Lock()
+	defer hub.Unlock()
+
+	for events := range hub.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+)
+
+const exportSchemaVersion = 1
+
+//Export is the state of all projects as a single document
+type Export struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	Projects      []ExportedProject `json:"projects"`
+}
+
+//ExportedProject is the state of a single project
+type ExportedProject struct {
+	Name     string            `json:"name"`
+	Version  string            `json:"version"`
+	Archived bool              `json:"archived,omitempty"`
+	Metadata *Metadata         `json:"metadata,omitempty"`
+	Aliases  map[string]string `json:"aliases,omitempty"`
+	History  []HistoryEntry    `json:"history,omitempty"`
+}
+
+//ImportResult lists the projects changed by an import
+type ImportResult struct {
+	Imported []string `json:"imported"`
+	Archived []string `json:"archived"`
+}
+
+//Export returns the state of all projects, optionally with their history
+func (v *Version) Export(withHistory bool) (*Export, error) {
+	projects, err := v.Projects()
+	if err != nil {
+		return nil, err
+	}
+
+	export := &Export{SchemaVersion: exportSchemaVersion, Projects: []ExportedProject{}}
+	for _, project := range projects {
+		exported, err := v.exportProject(project, withHistory)
+		if err != nil {
+			return nil, err
+		}
+		export.Projects = append(export.Projects, *exported)
+	}
+
+	return export, nil
+}
+
+func (v *Version) exportProject(project string, withHistory bool) (*ExportedProject, error) {
+	version, err := v.readVersion(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get version for project %v", project)
+	}
+	exported := &ExportedProject{Name: project, Version: version}
+
+	if exported.Archived, err = v.IsArchived(project); err != nil {
+		return nil, err
+	}
+	if exported.Metadata, err = v.GetMetadata(project); err != nil {
+		return nil, err
+	}
+	if exported.Aliases, err = v.Aliases(project); err != nil {
+		return nil, err
+	}
+	if withHistory {
+		if exported.History, err = v.History(project); err != nil {
+			return nil, err
+		}
+	}
+
+	return exported, nil
+}
+
+//Import applies the state of the document, replace also clears settings missing in the document and archives missing projects
+func (v *Version) Import(document *Export, replace bool) (*ImportResult, error) {
+	if document.SchemaVersion != exportSchemaVersion {
+		return nil, errors.Errorf("Unsupported export schema version %v, expected %v", document.SchemaVersion, exportSchemaVersion)
+	}
+	for _, project := range document.Projects {
+		if project.Name == "" || !schemeVersion.MatchString(project.Version) {
+			return nil, errors.Errorf("Invalid project %q with version %q", project.Name, project.Version)
+		}
+		if project.Metadata != nil {
+			if err := project.Metadata.Validate(); err != nil {
+				return nil, errors.Wrapf(err, "Invalid project %v", project.Name)
+			}
+			if err := v.checkHooks(&project.Metadata.Config); err != nil {
+				return nil, errors.Wrapf(err, "Invalid project %v", project.Name)
+			}
+		}
+	}
+
+	result := &ImportResult{Imported: []string{}, Archived: []string{}}
+	imported := map[string]bool{}
+	for _, project := range document.Projects {
+		if err := v.importProject(project, replace); err != nil {
+			return nil, err
+		}
+		imported[project.Name] = true
+		result.Imported = append(result.Imported, project.Name)
+	}
+
+	if replace {
+		projects, err := v.Projects()
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			if imported[project] {
+				continue
+			}
+			if err := v.Archive(project); err != nil {
+				return nil, err
+			}
+			result.Archived = append(result.Archived, project)
+		}
+	}
+
+	return result, nil
+}
+
+func (v *Version) importProject(project ExportedProject, replace bool) error {
+	if err := v.fileProvider.StoreVersion(project.Name, project.Version); err != nil {
+		return errors.Wrapf(err, "Cannot import version for project %v", project.Name)
+	}
+
+	if project.Metadata != nil {
+		stored, err := v.GetMetadata(project.Name)
+		if err != nil {
+			return err
+		}
+		project.Metadata.keepSecret(stored)
+		// an import restores the epoch of the exported project
+		if err := v.storeMetadata(project.Name, project.Metadata); err != nil {
+			return err
+		}
+	} else if replace {
+		if err := v.fileProvider.DeleteDocument(metadataDocument, project.Name); err != nil {
+			return errors.Wrapf(err, "Cannot delete metadata for project %v", project.Name)
+		}
+	}
+	if project.Aliases == nil && replace {
+		project.Aliases = map[string]string{}
+	}
+	if project.Aliases != nil {
+		if err := v.storeAliases(project.Name, project.Aliases); err != nil {
+			return err
+		}
+	}
+	if project.Archived {
+		if err := v.Archive(project.Name); err != nil {
+			return err
+		}
+	} else if err := v.Unarchive(project.Name); err != nil {
+		return err
+	}
+
+	// history is append only, so it is imported for projects without history only
+	existing, err := v.History(project.Name)
+	if err != nil || len(existing) > 0 {
+		return err
+	}
+	for _, entry := range project.History {
+		if err := v.recordHistory(project.Name, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//OnExport is a handler for exporting all projects as JSON or as YAML with ?format=yaml
+func (handler *Handler) OnExport(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	export, err := service.Export(context.Query("history") == "true")
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if !isAdmin(context) {
+		// webhook secrets are only exported for backups by admins
+		for i := range export.Projects {
+			export.Projects[i].Metadata = export.Projects[i].Metadata.masked()
+		}
+	}
+
+	if context.Query("format") == "yaml" {
+		// the json field names are the names of the document in yaml as well
+		document, err := jsonDocument(export)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		context.YAML(http.StatusOK, document)
+		return
+	}
+	context.JSON(http.StatusOK, export)
+}
+
+//OnImport is a handler for importing a JSON or YAML document, ?mode=replace replaces instead of merging
+func (handler *Handler) OnImport(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	mode := context.DefaultQuery("mode", "merge")
+	if mode != "merge" && mode != "replace" {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Errorf("%v is not a valid import mode", mode))
+		return
+	}
+
+	document, err := readExport(context)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := service.Import(document, mode == "replace")
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	handler.auditLog().Infof("imported projects %v, archived projects %v", result.Imported, result.Archived)
+	context.JSON(http.StatusOK, result)
+}
+
+func readExport(context *gin.Context) (*Export, error) {
+	document := &Export{}
+	if !strings.Contains(context.ContentType(), "yaml") {
+		if err := context.ShouldBindBodyWith(document, binding.JSON); err != nil {
+			return nil, errors.Wrap(err, "Invalid document")
+		}
+		return document, nil
+	}
+
+	var parsed interface{}
+	if err := context.ShouldBindBodyWith(&parsed, binding.YAML); err != nil {
+		return nil, errors.Wrap(err, "Invalid document")
+	}
+	encoded, err := json.Marshal(jsonCompatible(parsed))
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid document")
+	}
+	if err := json.Unmarshal(encoded, document); err != nil {
+		return nil, errors.Wrap(err, "Invalid document")
+	}
+
+	return document, nil
+}
+
+//jsonDocument converts a value to the generic json representation
+func jsonDocument(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot encode document")
+	}
+
+	var document interface{}
+	err = json.Unmarshal(encoded, &document)
+	return document, errors.Wrap(err, "Cannot encode document")
+}
+
+//jsonCompatible converts the maps of a parsed yaml document to maps with string keys
+func jsonCompatible(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range value {
+			converted[toString(key)] = jsonCompatible(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonCompatible(item)
+		}
+	}
+
+	return value
+}
+
+func toString(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Export_And_Import(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	source := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_, _ = source.Bump("p1", "minor")
+	_ = source.SetMetadata("p1", &Metadata{Owner: "payments", Config: Config{Prefix: "v"}})
+	_ = source.SetAlias("p1", "stable", "1.0.0")
+	_, _ = source.Set("p2", "3.0")
+	_ = source.Archive("p2")
+
+	export, err := source.Export(true)
+	target := NewVersion(adapter.NewMock("", ""))
+	result, errImport := target.Import(export, false)
+	imported, _ := target.Export(true)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(export.Projects).To(HaveLen(2))
+	Ω.Expect(export.Projects[0].History).To(HaveLen(1))
+	Ω.Expect(errImport).To(BeNil())
+	Ω.Expect(result.Imported).To(Equal([]string{"p1", "p2"}))
+	Ω.Expect(imported).To(Equal(export))
+}
+
+func Test_Import_Replace(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Owner: "payments"})
+	_, _ = version.Set("p2", "2.0.0")
+
+	result, err := version.Import(&Export{SchemaVersion: 1, Projects: []ExportedProject{{Name: "p1", Version: "1.1.0"}}}, true)
+	meta, _ := version.GetMetadata("p1")
+	archived, _ := version.IsArchived("p2")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(result.Archived).To(Equal([]string{"p2"}))
+	Ω.Expect(meta).To(BeNil())
+	Ω.Expect(archived).To(BeTrue())
+}
+
+func Test_Import_Invalid_Document(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+
+	_, errSchema := version.Import(&Export{SchemaVersion: 2}, false)
+	_, errVersion := version.Import(&Export{SchemaVersion: 1, Projects: []ExportedProject{{Name: "p1", Version: "1 0"}}}, false)
+	_, errConfig := version.Import(&Export{SchemaVersion: 1, Projects: []ExportedProject{{Name: "p1", Version: "1.0", Metadata: &Metadata{Config: Config{Prefix: "x"}}}}}, false)
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(errSchema).NotTo(BeNil())
+	Ω.Expect(errVersion).NotTo(BeNil())
+	Ω.Expect(errConfig).NotTo(BeNil())
+	Ω.Expect(current).To(Equal("1.0.0"))
+}
+
+func Test_Export_And_Import_Yaml_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	tokens := NewTokenStore()
+	tokens.Add("ops", "admin-secret", "*", "admin")
+	source := NewHandler(NewVersion(adapter.NewMock("1.0", "p1")), nil)
+	source.SetTokenStore(tokens)
+	targetVersion := NewVersion(adapter.NewMock("", ""))
+	target := NewHandler(targetVersion, nil)
+	target.SetTokenStore(tokens)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export?format=yaml", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	source.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`version: "1.0"`))
+
+	yaml := res.Body.String()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/import", bytes.NewBufferString(yaml))
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	req.Header.Set("Content-Type", "application/yaml")
+	target.GetRouter().ServeHTTP(res, req)
+	result := ImportResult{}
+	_ = json.Unmarshal(res.Body.Bytes(), &result)
+	current, _ := targetVersion.GetVersion("p1")
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(result.Imported).To(Equal([]string{"p1"}))
+	Ω.Expect(current).To(Equal("1.0"))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//MavenMetadata is the maven-metadata.xml of a project
+type MavenMetadata struct {
+	XMLName    xml.Name        `xml:"metadata"`
+	GroupID    string          `xml:"groupId,omitempty"`
+	ArtifactID string          `xml:"artifactId"`
+	Versioning MavenVersioning `xml:"versioning"`
+}
+
+//MavenVersioning lists the versions of a project in the maven-metadata.xml
+type MavenVersioning struct {
+	Latest      string   `xml:"latest"`
+	Release     string   `xml:"release,omitempty"`
+	Versions    []string `xml:"versions>version"`
+	LastUpdated string   `xml:"lastUpdated,omitempty"`
+}
+
+//KnownVersions returns all versions the given project ever had in the order they were set, the current version last
+func (v *Version) KnownVersions(project string) ([]string, error) {
+	history, err := v.History(project)
+	if err != nil {
+		return nil, err
+	}
+	current, err := v.readVersion(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get version for project %v", project)
+	}
+
+	versions, seen := []string{}, map[string]bool{current: true}
+	for _, entry := range history {
+		for _, version := range []string{entry.Previous, entry.Version} {
+			if version != "" && !seen[version] {
+				seen[version] = true
+				versions = append(versions, version)
+			}
+		}
+	}
+	if current != "" {
+		// the current version is the latest, even if the project had it before
+		versions = append(versions, current)
+	}
+
+	return versions, nil
+}
+
+//MavenMetadata returns the maven-metadata.xml of the given project, nil if the project has no version
+func (v *Version) MavenMetadata(project string, groupID string) (*MavenMetadata, error) {
+	versions, err := v.KnownVersions(project)
+	if err != nil || len(versions) == 0 {
+		return nil, err
+	}
+	history, err := v.History(project)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &MavenMetadata{GroupID: groupID, ArtifactID: project}
+	meta.Versioning.Versions = versions
+	meta.Versioning.Latest = versions[len(versions)-1]
+	for _, version := range versions {
+		if !strings.Contains(version, "-") {
+			meta.Versioning.Release = version
+		}
+	}
+	if len(history) > 0 {
+		meta.Versioning.LastUpdated = history[len(history)-1].Time.UTC().Format("20060102150405")
+	}
+
+	return meta, nil
+}
+
+//DistTags returns the npm dist-tags of the given project, the current version as latest and all aliases, nil if the project has no version
+func (v *Version) DistTags(project string) (map[string]string, error) {
+	current, err := v.readVersion(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get version for project %v", project)
+	}
+	if current == "" {
+		return nil, nil
+	}
+
+	tags, err := v.Aliases(project)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := tags[latestAlias]; !exists {
+		tags[latestAlias] = current
+	}
+
+	return tags, nil
+}
+
+//formattedVersion renders the versions of the project of the request for package tooling, "maven-metadata" or "npm"
+func (handler *Handler) formattedVersion(context *gin.Context, service *Version, format string) {
+	project := context.Param("project")
+
+	var document []byte
+	var contentType string
+	var err error
+	switch format {
+	case "maven-metadata":
+		var meta *MavenMetadata
+		meta, err = service.MavenMetadata(project, context.Query("groupId"))
+		if meta != nil {
+			document, err = xml.MarshalIndent(meta, "", "  ")
+			document = append([]byte(xml.Header), document...)
+			contentType = "application/xml; charset=utf-8"
+		}
+	case "npm":
+		var tags map[string]string
+		tags, err = service.DistTags(project)
+		if tags != nil {
+			document, err = json.Marshal(tags)
+			contentType = "application/json; charset=utf-8"
+		}
+	default:
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Errorf("%v is not a valid format, use maven-metadata or npm", format))
+		return
+	}
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if document == nil {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No version for project %v", projectKey(context)))
+		return
+	}
+
+	if notModified(context, string(document)) {
+		return
+	}
+
+	// tracking reads is best effort and must not fail the read itself
+	_ = service.touch(project, false)
+	handler.logger.Infof("get %v versions from project %v", format, projectKey(context))
+	context.Data(http.StatusOK, contentType, document)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Maven_Metadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	_ = version.SetMetadata("myservice", &Metadata{Config: Config{ParseMode: strictParsing}})
+	_, _ = version.SetVersion("myservice", "1.0.0")
+	_, _ = version.BumpMinor("myservice")
+	_, _ = version.SetVersion("myservice", "1.2.0-rc.1")
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/myservice?format=maven-metadata&groupId=com.example", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Header().Get("Content-Type")).To(HavePrefix("application/xml"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("<groupId>com.example</groupId>"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("<artifactId>myservice</artifactId>"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("<latest>1.2.0-rc.1</latest>"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("<release>1.1.0</release>"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("<versions>\n      <version>1.0.0</version>\n      <version>1.1.0</version>\n      <version>1.2.0-rc.1</version>\n    </versions>"))
+}
+
+func Test_Known_Versions_End_With_Current(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	_, _ = version.SetVersion("p1", "1.0.0")
+	_, _ = version.SetVersion("p1", "2.0.0")
+	_, _ = version.SetVersion("p1", "1.0.0")
+
+	versions, err := version.KnownVersions("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(versions).To(Equal([]string{"2.0.0", "1.0.0"}))
+}
+
+func Test_Npm_Dist_Tags(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.3.0", "myservice"))
+	_ = version.SetAlias("myservice", "stable", "1.2.0")
+	handler := NewHandler(version, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/myservice?format=npm", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"latest": "1.3.0", "stable": "1.2.0"}`))
+}
+
+func Test_Format_Of_Unknown_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.3.0", "myservice")), nil)
+
+	unknown := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/other?format=npm", nil)
+	handler.GetRouter().ServeHTTP(unknown, req)
+	invalid := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/myservice?format=pypi", nil)
+	handler.GetRouter().ServeHTTP(invalid, req)
+
+	Ω.Expect(unknown.Code).To(Equal(http.StatusNotFound))
+	Ω.Expect(invalid.Code).To(Equal(http.StatusBadRequest))
+}
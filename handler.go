@@ -1,18 +1,60 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 )
 
 //Handler for handling http routes
 type Handler struct {
-	version *Version
-	logger  *log.Logger
+	version  *Version
+	logger   *log.Logger
+	shards   *ShardMap
+	tokens   *TokenStore
+	quotas   *Quotas
+	notifier *Notifier
+	gcAge    string
+
+	reservationTTL time.Duration
+	clientHeader   string
+	requestTimeout time.Duration
+	routeTimeouts  map[string]time.Duration
+	maxBodySize    int64
+	breaker        *Breaker
+	diskGuard      *DiskGuard
+	chaos          *Chaos
+	events         *EventHub
+	slackSecret    *Secret
+	pushRules      []PushRule
+	pushSecret     *Secret
+	traceExemplars bool
+	openMetrics    bool
+
+	trailingSlash     bool
+	lowercaseProjects bool
+	audit             *log.Logger
+	reporter          *ErrorReporter
+	preloading        int32
+	replicator        *Replicator
+	snapshotDir       string
+	draining          int32
+	deliveries        sync.WaitGroup
+	signer            Signer
+	signerKeyID       string
+	attestor          *Attestor
+	policyAgent       *PolicyAgent
+	reads             *ReadAudit
+	auditChain        string
+	breakGlass        *BreakGlass
 }
 
 //NewHandler constructs a new handler
@@ -22,17 +64,48 @@ func NewHandler(version *Version, logger *log.Logger) *Handler {
 	}
 
 	return &Handler{
-		version: version,
-		logger:  logger,
+		version:      version,
+		logger:       logger,
+		clientHeader: defaultClientHeader,
+		events:       NewEventHub(),
 	}
 }
 
+//SetShardMap enables proxying of requests for projects owned by other instances
+func (handler *Handler) SetShardMap(shards *ShardMap) {
+	handler.shards = shards
+}
+
+//SetTokenStore enables token authentication for all project routes
+func (handler *Handler) SetTokenStore(tokens *TokenStore) {
+	handler.tokens = tokens
+}
+
+//SetQuotas enables quota enforcement for namespaces
+func (handler *Handler) SetQuotas(quotas *Quotas) {
+	handler.quotas = quotas
+}
+
+//SetNotifier enables notifications about changed versions
+func (handler *Handler) SetNotifier(notifier *Notifier) {
+	handler.notifier = notifier
+}
+
+//SetGarbageCollectionAge sets the default age of projects archived by the garbage collection
+func (handler *Handler) SetGarbageCollectionAge(age string) {
+	handler.gcAge = age
+}
+
 //LoggerMiddleware logs the last error
 func (handler *Handler) LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 		err := c.Errors.Last()
 		if err != nil {
+			if client := handler.client(c); client != "" {
+				handler.logger.WithField("client", client).Error(err)
+				return
+			}
 			handler.logger.Error(err)
 		}
 	}
@@ -42,19 +115,199 @@ func (handler *Handler) LoggerMiddleware() gin.HandlerFunc {
 func (handler *Handler) GetRouter() http.Handler {
 	r := gin.New()
 	r.Use(handler.LoggerMiddleware())
+	if handler.reporter != nil {
+		r.Use(handler.ErrorReportingMiddleware())
+	}
+	r.Use(handler.LatencyMiddleware())
+	if handler.lowercaseProjects {
+		r.Use(handler.NormalizeMiddleware())
+	}
+	if handler.chaos != nil {
+		r.Use(handler.ChaosMiddleware())
+	}
+	if handler.maxBodySize > 0 {
+		r.Use(handler.BodyLimitMiddleware())
+	}
+	if handler.tokens != nil {
+		r.Use(handler.AuthMiddleware())
+	}
+	if handler.reads != nil {
+		r.Use(handler.ReadAuditMiddleware())
+	}
+	if handler.policyAgent != nil {
+		r.Use(handler.PolicyAgentMiddleware())
+	}
+	if handler.shards != nil {
+		r.Use(handler.ShardMiddleware())
+	}
+	if handler.breaker != nil {
+		r.Use(handler.BreakerMiddleware())
+	}
+	if handler.diskGuard != nil {
+		r.Use(handler.ReadOnlyMiddleware())
+	}
 	gin.SetMode(gin.ReleaseMode)
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(handler.OnMethodNotAllowed(r))
 
-	r.POST("/major/:project", handler.OnMajor)
-	r.POST("/minor/:project", handler.OnMinor)
-	r.POST("/patch/:project", handler.OnPatch)
+	handler.projectRoutes(r)
+	if handler.quotas != nil {
+		handler.projectRoutes(r.Group("/ns/:namespace"), handler.QuotaMiddleware())
+	} else {
+		handler.projectRoutes(r.Group("/ns/:namespace"))
+	}
 	r.POST("/transient/minor/:version", handler.OnTransientMinor)
 	r.POST("/transient/patch/:version", handler.OnTransientPatch)
-	r.POST("/version/:project/:version", handler.OnSetVersion)
-	r.GET("/version/:project", handler.OnGetVersion)
+	r.POST("/admin/gc", handler.AdminMiddleware(), handler.OnGarbageCollection)
+	r.POST("/admin/fsck", handler.AdminMiddleware(), handler.OnFsck)
+	r.POST("/admin/purge", handler.AdminMiddleware(), handler.OnPurge)
+	r.POST("/admin/replicate", handler.AdminMiddleware(), handler.OnReplicate)
+	r.POST("/admin/drain", handler.AdminMiddleware(), handler.OnDrain)
+	if handler.auditChain != "" {
+		r.GET("/admin/audit/verify", handler.AdminMiddleware(), handler.OnVerifyAuditLog)
+	}
 	r.GET("/", handler.OnHealth)
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/readyz", handler.OnReady)
+	r.GET("/ws", handler.OnWebSocket(r))
+	if handler.slackSecret.Value() != "" {
+		r.POST("/slack/command", handler.OnSlackCommand)
+	}
+	if len(handler.pushRules) > 0 {
+		r.POST("/hooks/push", handler.OnPush)
+	}
+	r.GET("/metrics", handler.metricsHandler())
 
-	return r
+	var router http.Handler = r
+	if handler.requestTimeout > 0 || len(handler.routeTimeouts) > 0 {
+		router = &timeoutHandler{next: router, timeout: handler.timeoutOf}
+	}
+	if handler.trailingSlash {
+		router = &trailingSlashHandler{next: router}
+	}
+	return router
+}
+
+//projectRoutes configures all routes of a project, the middleware only applies to routes changing the version
+func (handler *Handler) projectRoutes(r gin.IRoutes, changeMiddleware ...gin.HandlerFunc) {
+	change := func(h gin.HandlerFunc) []gin.HandlerFunc {
+		return append(append([]gin.HandlerFunc{}, changeMiddleware...), h)
+	}
+
+	r.POST("/major/:project", change(handler.OnMajor)...)
+	r.POST("/minor/:project", change(handler.OnMinor)...)
+	r.POST("/patch/:project", change(handler.OnPatch)...)
+	r.POST("/version/:project/:version", change(handler.OnSetVersion)...)
+	r.PUT("/version/:project", change(handler.OnPutVersion)...)
+	r.POST("/project/:project", change(handler.OnCreateProject)...)
+	r.POST("/confirm/:project/:version", change(handler.OnConfirm)...)
+	r.POST("/bump/:project", change(handler.OnBump)...)
+	r.POST("/bump/:project/*elements", change(handler.OnBump)...)
+	r.POST("/reserve/:element/:project", change(handler.OnReserve)...)
+	r.DELETE("/reserve/:project/:version", handler.OnRelease)
+	r.GET("/reservations/:project", handler.OnListReservations)
+	r.POST("/channel/:project/:channel", change(handler.OnNextBuild)...)
+	r.POST("/promote/:project/:version", handler.OnPromote)
+	r.GET("/channels/:project", handler.OnListChannels)
+	r.POST("/hotfix/:project/:baseversion", change(handler.OnHotfix)...)
+	r.GET("/hotfixes/:project", handler.OnListHotfixes)
+	r.GET("/version/:project", handler.OnGetVersion)
+	r.HEAD("/version/:project", handler.OnHeadVersion)
+	r.DELETE("/version/:project", handler.OnDelete)
+	r.GET("/trash", handler.OnListTrash)
+	r.POST("/trash/:project/restore", change(handler.OnRestore)...)
+	r.PUT("/project/:project/meta", handler.OnSetMetadata)
+	r.GET("/project/:project/meta", handler.OnGetMetadata)
+	r.GET("/config/:project", handler.OnGetConfig)
+	r.PUT("/config/:project", handler.OnSetConfig)
+	r.GET("/projects", handler.OnListProjects)
+	r.POST("/projects/bulk", change(handler.OnCreateProjects)...)
+	r.GET("/export", handler.OnExport)
+	r.POST("/import", handler.AdminMiddleware(), handler.OnImport)
+	r.POST("/sync", handler.AdminMiddleware(), handler.OnSync)
+	r.GET("/history/:project", handler.OnGetHistory)
+	r.GET("/history/:project/export", handler.OnExportHistory)
+	r.GET("/stats", handler.OnStats)
+	r.POST("/archive/:project", handler.OnArchive)
+	r.POST("/unarchive/:project", handler.OnUnarchive)
+	r.POST("/changelog/:project", handler.OnChangelog)
+	r.GET("/releasenotes/:project", handler.OnReleaseNotes)
+	r.GET("/resolve/:project", handler.OnResolve)
+	r.GET("/tags/:project", handler.OnTags)
+	r.POST("/render/:project", handler.OnRender)
+	r.GET("/version/:project/:alias", handler.OnGetAlias)
+	r.GET("/alias/:project", handler.OnListAliases)
+	r.PUT("/alias/:project/:alias/:version", handler.OnSetAlias)
+	r.DELETE("/alias/:project/:alias", handler.OnDeleteAlias)
+	r.GET("/webhooks/:project/deliveries", handler.OnListDeliveries)
+	r.POST("/webhooks/:project/deliveries/:id/redeliver", handler.OnRedeliver)
+	r.POST("/webhooks/:project/redeliver", handler.OnRedeliverFailed)
+	r.POST("/decrement/major/:project", handler.AdminMiddleware(), handler.OnDecrementMajor)
+	r.POST("/decrement/minor/:project", handler.AdminMiddleware(), handler.OnDecrementMinor)
+	r.POST("/decrement/patch/:project", handler.AdminMiddleware(), handler.OnDecrementPatch)
+	r.POST("/epoch/:project/:version", handler.AdminMiddleware(), handler.OnStartEpoch)
+}
+
+//versionFor returns the version service for the namespace of the request
+func (handler *Handler) versionFor(context *gin.Context) (*Version, bool) {
+	service := handler.version.WithContext(context.Request.Context())
+	namespace := context.Param("namespace")
+	if namespace == "" {
+		return service, true
+	}
+
+	version, err := service.Namespace(namespace)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	return version, true
+}
+
+//projectKey returns the project of the request qualified by its namespace
+func projectKey(context *gin.Context) string {
+	project := context.Param("project")
+	if namespace := context.Param("namespace"); namespace != "" {
+		return namespace + "/" + project
+	}
+
+	return project
+}
+
+var lastChangeLabels = struct {
+	sync.Mutex
+	labels map[string]prometheus.Labels
+}{labels: map[string]prometheus.Labels{}}
+
+//recordLastChange replaces the last change info of the project
+func recordLastChange(namespace string, project string, entry *HistoryEntry) {
+	project = projectLabels.Of(namespace, project)
+	labels := prometheus.Labels{
+		"namespace": namespace,
+		"project":   project,
+		"element":   entry.Element,
+		"version":   entry.Version,
+		"actor":     entry.Actor,
+	}
+
+	key := namespace + "/" + project
+	lastChangeLabels.Lock()
+	defer lastChangeLabels.Unlock()
+	if previous, exists := lastChangeLabels.labels[key]; exists {
+		lastChange.Delete(previous)
+	}
+	lastChangeLabels.labels[key] = labels
+	lastChange.With(labels).Set(1)
+}
+
+func countBump(namespace string, project string, element string) {
+	project = projectLabels.Of(namespace, project)
+	if namespace != "" {
+		numberOfNamespaceBumps.With(prometheus.Labels{"namespace": namespace, "project": project, "element": element}).Inc()
+		return
+	}
+
+	numberOfBumps.With(prometheus.Labels{"project": project, "element": element}).Inc()
 }
 
 //OnHealth is a handler for a health check
@@ -64,79 +317,172 @@ func (handler *Handler) OnHealth(context *gin.Context) {
 
 //OnMajor is a handler for bumping the major part for a given project
 func (handler *Handler) OnMajor(context *gin.Context) {
-	project := context.Param("project")
-	version, err := handler.version.BumpMajor(project)
-	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
-		return
-	}
-
-	numberOfBumps.With(prometheus.Labels{"project": project, "element": "major"}).Inc()
-	handler.logger.Infof("bump major version to %v on project %v", version, project)
-	context.String(http.StatusOK, "%s", version)
+	handler.bump(context, "major")
 }
 
 //OnMinor is a handler for bumping the minor part for a given project
 func (handler *Handler) OnMinor(context *gin.Context) {
-	project := context.Param("project")
-	version, err := handler.version.BumpMinor(project)
+	handler.bump(context, "minor")
+}
+
+//OnPatch is a handler for bumping the patch part for a given project
+func (handler *Handler) OnPatch(context *gin.Context) {
+	handler.bump(context, "patch")
+}
+
+func (handler *Handler) bump(context *gin.Context, element string) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	step, err := stepOf(context)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		_ = context.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	numberOfBumps.With(prometheus.Labels{"project": project, "element": "minor"}).Inc()
-	handler.logger.Infof("bump minor version to %v on project %v", version, project)
-	context.String(http.StatusOK, "%s", version)
-}
+	gapFree, err := service.isGapFree(context.Param("project"))
+	if err != nil {
+		abortChange(context, err, http.StatusInternalServerError)
+		return
+	}
+	if gapFree && step == 1 {
+		// bumps of gap-free projects are finalized by confirming the reservation
+		ttl, err := handler.ttlOf(context)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		handler.reserve(context, service, element, ttl, http.StatusAccepted)
+		return
+	}
 
-//OnPatch is a handler for bumping the patch part for a given project
-func (handler *Handler) OnPatch(context *gin.Context) {
-	project := context.Param("project")
-	version, err := handler.version.BumpPatch(project)
+	entry, err := service.BumpBy(context.Param("project"), element, step)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		countFailure(context.Param("namespace"), context.Param("project"), "bump", err)
+		abortChange(context, err, http.StatusInternalServerError)
 		return
 	}
 
-	numberOfBumps.With(prometheus.Labels{"project": project, "element": "patch"}).Inc()
-	handler.logger.Infof("bump patch version to %v on project %v", version, project)
-	context.String(http.StatusOK, "%s", version)
+	if entry.Analysis != "" {
+		handler.changeLog(context).Infof("diff analyzer %v on project %v", entry.Analysis, projectKey(context))
+	}
+	countBump(context.Param("namespace"), context.Param("project"), entry.Element)
+	handler.changed(context, service, entry)
+	handler.changeLog(context).Infof("bump %v version to %v on project %v", entry.Element, entry.Version, projectKey(context))
+	handler.propagate(context, service, entry)
+	context.String(http.StatusOK, "%s", service.Display(context.Param("project"), entry.Version))
 }
 
 //OnSetVersion is a handler for setting the version for a given project
 func (handler *Handler) OnSetVersion(context *gin.Context) {
-	project := context.Param("project")
-	version := context.Param("version")
-	_, err := handler.version.SetVersion(project, version)
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	handler.set(context, service, context.Param("version"))
+}
+
+//SetVersionRequest sets the version of a project from a JSON body
+type SetVersionRequest struct {
+	Version string `json:"version" binding:"required"`
+}
+
+//OnPutVersion is a handler for setting the version for a given project from a JSON body
+func (handler *Handler) OnPutVersion(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	request := SetVersionRequest{}
+	if err := context.ShouldBindBodyWith(&request, binding.JSON); err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid version request"))
+		return
+	}
+
+	handler.set(context, service, request.Version)
+}
+
+func (handler *Handler) set(context *gin.Context, service *Version, version string) {
+	entry, err := service.Set(context.Param("project"), version)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusUnprocessableEntity, err)
+		countFailure(context.Param("namespace"), context.Param("project"), "set", err)
+		abortChange(context, err, http.StatusUnprocessableEntity)
 		return
 	}
 
-	handler.logger.Infof("set version explicitly to %v on project %v", version, project)
-	context.String(http.StatusOK, "%s", version)
+	handler.changed(context, service, entry)
+	handler.changeLog(context).Infof("set version explicitly to %v on project %v", entry.Version, projectKey(context))
+	context.String(http.StatusOK, "%s", service.Display(context.Param("project"), entry.Version))
+}
+
+//changed publishes a change of the project of the request and tells the previous version, the element and the signature in the response headers
+func (handler *Handler) changed(context *gin.Context, service *Version, entry *HistoryEntry) {
+	if entry.Previous != "" {
+		context.Header("X-Vbump-Previous-Version", service.Display(context.Param("project"), entry.Previous))
+	}
+	context.Header("X-Vbump-Element", entry.Element)
+	handler.sign(context, service.Display(context.Param("project"), entry.Version))
+	countClientChange(entry)
+	handler.publish(context.Param("namespace"), context.Param("project"), service, entry)
 }
 
 //OnGetVersion is a handler for getting the version for a given project
 func (handler *Handler) OnGetVersion(context *gin.Context) {
-	project := context.Param("project")
-	version, err := handler.version.GetVersion(project)
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+	if format := context.Query("format"); format != "" {
+		handler.formattedVersion(context, service, format)
+		return
+	}
+	if at := context.Query("at"); at != "" {
+		handler.versionAt(context, service, at)
+		return
+	}
+
+	version, err := service.GetVersion(context.Param("project"))
 	if err != nil {
-		_ = context.AbortWithError(http.StatusNotFound, err)
+		_ = context.AbortWithError(changeStatus(err, http.StatusInternalServerError), err)
 		return
 	}
+	version = service.Display(context.Param("project"), version)
 
-	handler.logger.Infof("get version from project %v", project)
+	if notModified(context, version) {
+		return
+	}
+
+	handler.logger.Infof("get version from project %v", projectKey(context))
 	context.String(http.StatusOK, "%s", version)
 }
 
-//OnTransientPatch is a handler for a transient patch bump
+//notModified sets the ETag of the version and responds with 304, if the client already has it
+func notModified(context *gin.Context, version string) bool {
+	etag := versionETag(version)
+	context.Header("ETag", etag)
+	if context.GetHeader("If-None-Match") == etag {
+		context.Status(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
+
+func versionETag(version string) string {
+	hash := sha1.Sum([]byte(version))
+	return "\"" + hex.EncodeToString(hash[:]) + "\""
+}
+
+//OnTransientPatch is a handler for a transient patch bump, ?prerelease=next counts up the prerelease of the version
 func (handler *Handler) OnTransientPatch(context *gin.Context) {
 	version := context.Param("version")
-	bumpedVersion, err := handler.version.BumpTransientPatch(version)
+	bumpedVersion, err := handler.version.BumpTransient(version, "patch", context.Query("prerelease"))
 	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		_ = context.AbortWithError(changeStatus(err, http.StatusInternalServerError), err)
 		return
 	}
 
@@ -144,12 +490,12 @@ func (handler *Handler) OnTransientPatch(context *gin.Context) {
 	context.String(http.StatusOK, "%s", bumpedVersion)
 }
 
-//OnTransientMinor is a handler for a transient minor bump
+//OnTransientMinor is a handler for a transient minor bump, ?prerelease=next counts up the prerelease of the version
 func (handler *Handler) OnTransientMinor(context *gin.Context) {
 	version := context.Param("version")
-	bumpedVersion, err := handler.version.BumpTransientMinor(version)
+	bumpedVersion, err := handler.version.BumpTransient(version, "minor", context.Query("prerelease"))
 	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		_ = context.AbortWithError(changeStatus(err, http.StatusInternalServerError), err)
 		return
 	}
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -117,6 +118,38 @@ func Test_Bumb_Transient_Minor(t *testing.T) {
 	Ω.Expect(res.Body.String()).To(Equal("1.1"))
 }
 
+func Test_Bump_Transient_Prerelease(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+
+	for path, expected := range map[string]string{
+		"/transient/patch/1.2.3-rc.2":                          "1.2.3",
+		"/transient/patch/1.2.3-rc.2?prerelease=finalize":      "1.2.3",
+		"/transient/minor/1.2.3-rc.2":                          "1.3.0",
+		"/transient/minor/1.3.0-rc.2":                          "1.3.0",
+		"/transient/patch/1.2.3-rc.2?prerelease=next":          "1.2.3-rc.3",
+		"/transient/minor/v1.2.3-rc.2+build.7?prerelease=next": "v1.2.3-rc.3",
+		"/transient/patch/1.2.3-alpha.1.beta?prerelease=next":  "1.2.3-alpha.2.beta",
+		"/transient/patch/1.2.3-rc?prerelease=next":            "1.2.3-rc.0",
+		"/transient/patch/1.2.3+build.7":                       "1.2.4",
+	} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+
+		Ω.Expect(res.Code).To(Equal(200), path)
+		Ω.Expect(res.Body.String()).To(Equal(expected), path)
+	}
+
+	for _, path := range []string{"/transient/patch/1.2.3?prerelease=next", "/transient/patch/1.2.3-rc.1?prerelease=latest", "/transient/patch/1.2.3-rc..1"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+
+		Ω.Expect(res.Code).To(Equal(http.StatusUnprocessableEntity), path)
+	}
+}
+
 func Test_Set_Version(t *testing.T) {
 	Ω := NewGomegaWithT(t)
 	fileProvider := adapter.NewMock("1.0.0", "p1")
@@ -131,6 +164,38 @@ func Test_Set_Version(t *testing.T) {
 	Ω.Expect(res.Body.String()).To(Equal("3.1.2"))
 }
 
+func Test_Put_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	fileProvider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(fileProvider)
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{ParseMode: strictParsing}})
+	handler := NewHandler(version, nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("PUT", "/version/p1", bytes.NewBufferString(`{"version": "1.2.3+meta", "reason": "sync"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+	history, _ := version.History("p1")
+
+	Ω.Expect(res.Body.String()).To(Equal("1.2.3+meta"))
+	Ω.Expect(history[0].Reason).To(Equal("sync"))
+}
+
+func Test_Put_Version_Without_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	fileProvider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(fileProvider)
+	handler := NewHandler(version, nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("PUT", "/version/p1", bytes.NewBufferString(`{"reason": "sync"}`))
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
 func Test_Get_Version_With_Handler(t *testing.T) {
 	Ω := NewGomegaWithT(t)
 	fileProvider := adapter.NewMock("1.0.0", "p1")
@@ -215,3 +280,115 @@ func Test_Get_Health(t *testing.T) {
 
 	Ω.Expect(res.Body.String()).To(Equal("hello from vbump!"))
 }
+
+func Test_Get_Version_Returns_ETag(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	fileProvider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(fileProvider)
+	handler := NewHandler(version, nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Header().Get("ETag")).To(Equal(versionETag("1.0.0")))
+}
+
+func Test_Get_Version_With_Matching_ETag(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	fileProvider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(fileProvider)
+	handler := NewHandler(version, nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	req.Header.Set("If-None-Match", versionETag("1.0.0"))
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(304))
+	Ω.Expect(res.Body.String()).To(Equal(""))
+}
+
+func Test_Get_Version_With_Outdated_ETag(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	fileProvider := adapter.NewMock("1.0.1", "p1")
+	version := NewVersion(fileProvider)
+	handler := NewHandler(version, nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	req.Header.Set("If-None-Match", versionETag("1.0.0"))
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+}
+
+func Test_Bump_In_Namespace_Is_Isolated(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	fileProvider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(fileProvider)
+	handler := NewHandler(version, nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/ns/team/minor/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(Equal("0.1"))
+}
+
+func Test_Bump_With_Invalid_Namespace(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	fileProvider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(fileProvider)
+	handler := NewHandler(version, nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/ns/..team/minor/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
+func Test_Namespace_Bump_Metric(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	fileProvider := adapter.NewMock("1.0.0", "p1")
+	version := NewVersion(fileProvider)
+	handler := NewHandler(version, nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	bump, _ := http.NewRequest("POST", "/ns/metricteam/patch/p1", nil)
+	metrics, _ := http.NewRequest("GET", "/metrics", nil)
+	router.ServeHTTP(res, bump)
+	router.ServeHTTP(res, metrics)
+
+	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_namespace_bumps_total{element=\"patch\",namespace=\"metricteam\",project=\"p1\"} 1"))
+}
+
+func Test_Bump_Returns_Previous_Version_And_Element_In_Headers(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	Ω.Expect(version.SetMetadata("p1", &Metadata{Config: Config{Prefix: "v"}})).To(Succeed())
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(Equal("v1.1.0"))
+	Ω.Expect(res.Header().Get("X-Vbump-Previous-Version")).To(Equal("v1.0.0"))
+	Ω.Expect(res.Header().Get("X-Vbump-Element")).To(Equal("minor"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p2", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Header().Values("X-Vbump-Previous-Version")).To(BeEmpty())
+	Ω.Expect(res.Header().Get("X-Vbump-Element")).To(Equal("patch"))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//HistoryEntry records a single change of a project version
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Element  string    `json:"element"`
+	Previous string    `json:"previous"`
+	Version  string    `json:"version"`
+	Reason   string    `json:"reason,omitempty"`
+	Actor    string    `json:"actor,omitempty"`
+	Client   string    `json:"client,omitempty"`
+	Analysis string    `json:"analysis,omitempty"`
+	Epoch    int       `json:"epoch,omitempty"`
+}
+
+//History returns all changes of the given project, oldest first
+func (v *Version) History(project string) ([]HistoryEntry, error) {
+	lines, err := v.fileProvider.ReadHistory(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get history for project %v", project)
+	}
+
+	entries := []HistoryEntry{}
+	for _, line := range lines {
+		entry := HistoryEntry{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, errors.Wrapf(err, "Cannot parse history for project %v", project)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (v *Version) recordHistory(project string, entry HistoryEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode history for project %v", project)
+	}
+
+	return v.fileProvider.AppendHistory(project, line)
+}
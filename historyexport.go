@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//historyFlushSize is the number of history entries written, before the response is flushed to the client
+const historyFlushSize = 100
+
+var historyColumns = []string{"time", "element", "previous", "version", "reason", "actor", "client"}
+
+//OnExportHistory is a handler for streaming the history of a given project as csv or json lines
+func (handler *Handler) OnExportHistory(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	project := context.Param("project")
+	format := context.DefaultQuery("format", "csv")
+	if format != "csv" && format != "jsonl" {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Errorf("%v is not a valid history format, expected csv or jsonl", format))
+		return
+	}
+
+	history, err := service.History(project)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	context.Header("Content-Disposition", `attachment; filename="`+project+`-history.`+format+`"`)
+	if format == "csv" {
+		context.Header("Content-Type", "text/csv; charset=utf-8")
+		err = writeHistoryCSV(context.Writer, history)
+	} else {
+		context.Header("Content-Type", "application/x-ndjson")
+		err = writeHistoryJSONLines(context.Writer, history)
+	}
+	if err != nil {
+		// the status is already sent, the client sees a truncated export
+		_ = context.Error(errors.Wrapf(err, "Cannot export history of project %v", projectKey(context)))
+	}
+}
+
+func writeHistoryCSV(writer gin.ResponseWriter, history []HistoryEntry) error {
+	records := csv.NewWriter(writer)
+	if err := records.Write(historyColumns); err != nil {
+		return err
+	}
+	for i, entry := range history {
+		record := []string{entry.Time.Format(time.RFC3339Nano), entry.Element, entry.Previous, entry.Version, entry.Reason, entry.Actor, entry.Client}
+		for j := range record {
+			record[j] = spreadsheetSafe(record[j])
+		}
+		if err := records.Write(record); err != nil {
+			return err
+		}
+		if (i+1)%historyFlushSize == 0 {
+			records.Flush()
+			writer.Flush()
+		}
+	}
+	records.Flush()
+
+	return records.Error()
+}
+
+func writeHistoryJSONLines(writer gin.ResponseWriter, history []HistoryEntry) error {
+	encoder := json.NewEncoder(writer)
+	for i, entry := range history {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+		if (i+1)%historyFlushSize == 0 {
+			writer.Flush()
+		}
+	}
+
+	return nil
+}
+
+//spreadsheetSafe quotes values, which spreadsheets would evaluate as formula
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@") {
+		return "'" + value
+	}
+
+	return value
+}
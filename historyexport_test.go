@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func newExportedHistory() http.Handler {
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	_, _ = version.WithAnnotation(Annotation{Reason: "fix, \"quoted\"", Actor: "ci"}).BumpPatch("p1")
+	_, _ = version.WithAnnotation(Annotation{Reason: "=HYPERLINK(\"x\")"}).BumpMinor("p1")
+
+	return NewHandler(version, nil).GetRouter()
+}
+
+func Test_Export_History_As_CSV(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/history/p1/export?format=csv", nil)
+	newExportedHistory().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Header().Get("Content-Type")).To(Equal("text/csv; charset=utf-8"))
+	Ω.Expect(res.Header().Get("Content-Disposition")).To(Equal(`attachment; filename="p1-history.csv"`))
+	Ω.Expect(strings.Split(res.Body.String(), "\n")).To(Equal([]string{
+		"time,element,previous,version,reason,actor,client",
+		`2024-05-01T12:00:00Z,patch,1.0.0,1.0.1,"fix, ""quoted""",ci,`,
+		`2024-05-01T12:00:00Z,minor,1.0.1,1.1.0,"'=HYPERLINK(""x"")",,`,
+		"",
+	}))
+}
+
+func Test_Export_History_As_JSON_Lines(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/history/p1/export?format=jsonl", nil)
+	newExportedHistory().ServeHTTP(res, req)
+
+	lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+	Ω.Expect(res.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+	Ω.Expect(lines).To(HaveLen(2))
+	Ω.Expect(lines[1]).To(MatchJSON(`{"time": "2024-05-01T12:00:00Z", "element": "minor", "previous": "1.0.1", "version": "1.1.0", "reason": "=HYPERLINK(\"x\")"}`))
+}
+
+func Test_Export_History_In_Unknown_Format(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/history/p1/export?format=xlsx", nil)
+	newExportedHistory().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusBadRequest))
+}
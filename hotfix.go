@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const hotfixDocument = "hotfix"
+
+//ErrHotfixLine is returned when allocating a hotfix on the minor line of the current version or ahead of it
+var ErrHotfixLine = newError(ErrConflict, "hotfixes are only allocated on minor lines behind the current version")
+
+//Hotfixes returns the last allocated hotfix of the older minor lines of the given project by line, e.g. "1.8"
+func (v *Version) Hotfixes(project string) (map[string]string, error) {
+	hotfixes := map[string]string{}
+	document, err := v.fileProvider.ReadDocument(hotfixDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get hotfixes for project %v", project)
+	}
+	if document == nil {
+		return hotfixes, nil
+	}
+
+	if err := json.Unmarshal(document, &hotfixes); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse hotfixes for project %v", project)
+	}
+
+	return hotfixes, nil
+}
+
+//Hotfix allocates the next patch on the minor line of the base version without changing the project,
+//the first hotfix of a line continues after the highest version the project had on it
+func (v *Version) Hotfix(project string, base string) (string, error) {
+	parsed, err := v.parseVersion(project, base)
+	if err != nil {
+		return "", err
+	}
+	requested, err := parseSemver(releaseOf(parsed))
+	if err != nil {
+		return "", invalidVersionf("%v is not a valid version", base)
+	}
+	line := fmt.Sprintf("%v.%v", requested[0], requested[1])
+
+	// hotfixes are handed out apart from the changes of the project, which stays on its own line
+	unlock, err := v.locks.Lock(v.namespace + "/" + project + "/" + hotfixDocument)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	current, err := v.readForChange(project)
+	if err != nil {
+		return "", errors.Wrapf(err, "Cannot allocate hotfix on line %v of project %v", line, project)
+	}
+	if current == "" {
+		return "", errors.Wrapf(ErrUnknownProject, "Cannot allocate hotfix on line %v of project %v", line, project)
+	}
+	if err := checkStoredVersion(project, current); err != nil {
+		return "", errors.Wrapf(err, "Cannot allocate hotfix on line %v of project %v", line, project)
+	}
+	released, err := parseSemver(releaseOf(current))
+	if err != nil {
+		return "", errors.Wrapf(err, "Cannot allocate hotfix on line %v of project %v", line, project)
+	}
+	if (semver{requested[0], requested[1]}).compare(semver{released[0], released[1]}) >= 0 {
+		return "", errors.Wrapf(ErrHotfixLine, "Cannot allocate hotfix on line %v of project %v at version %v", line, project, current)
+	}
+
+	hotfixes, err := v.Hotfixes(project)
+	if err != nil {
+		return "", err
+	}
+	last, err := v.lastOfLine(project, line, hotfixes[line])
+	if err != nil {
+		return "", err
+	}
+	patch := requested[2]
+	if last[2] > patch {
+		patch = last[2]
+	}
+
+	next := fmt.Sprintf("%v.%v", line, patch+1)
+	hotfixes[line] = next
+
+	return next, v.storeHotfixes(project, hotfixes)
+}
+
+//lastOfLine returns the highest version of the minor line, either allocated as hotfix or in the history of the project
+func (v *Version) lastOfLine(project string, line string, allocated string) (semver, error) {
+	constraint, err := ParseConstraint("~" + line)
+	if err != nil {
+		return semver{}, err
+	}
+	resolved, err := v.Resolve(project, constraint)
+	if err != nil {
+		return semver{}, err
+	}
+
+	last := semver{}
+	for _, version := range []string{allocated, resolved} {
+		parsed, err := parseSemver(releaseOf(version))
+		if err == nil && parsed.compare(last) > 0 {
+			last = parsed
+		}
+	}
+
+	return last, nil
+}
+
+func (v *Version) storeHotfixes(project string, hotfixes map[string]string) error {
+	document, err := json.Marshal(hotfixes)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode hotfixes for project %v", project)
+	}
+
+	err = v.fileProvider.StoreDocument(hotfixDocument, project, document)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot store hotfixes for project %v", project)
+	}
+
+	return nil
+}
+
+//OnHotfix is a handler for allocating the next hotfix on an older minor line of a given project
+func (handler *Handler) OnHotfix(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	version, err := service.Hotfix(context.Param("project"), context.Param("baseversion"))
+	if err != nil {
+		abortChange(context, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	version = service.Display(context.Param("project"), version)
+	handler.changeLog(context).Infof("allocate hotfix %v on project %v", version, projectKey(context))
+	context.String(http.StatusOK, "%s", version)
+}
+
+//OnListHotfixes is a handler for listing the last allocated hotfix per minor line of a given project
+func (handler *Handler) OnListHotfixes(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	hotfixes, err := service.Hotfixes(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, hotfixes)
+}
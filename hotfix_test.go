@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Hotfix_On_Older_Line(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.8.2", "p1"))
+	_, _ = version.Set("p1", "2.3.0")
+
+	hotfix, err := version.Hotfix("p1", "1.8")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(hotfix).To(Equal("1.8.3"))
+	hotfix, _ = version.Hotfix("p1", "1.8.0")
+	Ω.Expect(hotfix).To(Equal("1.8.4"))
+	hotfix, _ = version.Hotfix("p1", "1.7")
+	Ω.Expect(hotfix).To(Equal("1.7.1"))
+
+	current, _ := version.GetVersion("p1")
+	Ω.Expect(current).To(Equal("2.3.0"))
+	hotfixes, _ := version.Hotfixes("p1")
+	Ω.Expect(hotfixes).To(Equal(map[string]string{"1.8": "1.8.4", "1.7": "1.7.1"}))
+
+	_, err = version.Hotfix("p1", "2.3")
+	Ω.Expect(err).To(MatchError(ErrHotfixLine))
+	_, err = version.Hotfix("p1", "3.0")
+	Ω.Expect(err).To(MatchError(ErrHotfixLine))
+}
+
+func Test_Hotfix_Route(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("2.3.0", "p1"))
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/hotfix/p1/1.8", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("1.8.1"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/hotfix/p1/2.3", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(409))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/hotfixes/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal(`{"1.8":"1.8.1"}`))
+}
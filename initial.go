@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//InitialVersion is the first version of new projects matching the pattern
+type InitialVersion struct {
+	Pattern string
+	Version string
+}
+
+//AddInitialVersion sets the first version of new projects matching the pattern, "*" matches all projects
+func (v *Version) AddInitialVersion(pattern string, version string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.Wrapf(err, "%v is not a valid project pattern", pattern)
+	}
+	if !validateVersion(version) {
+		return errors.Errorf("%v is not a valid initial version", version)
+	}
+
+	v.initialVersions = append(v.initialVersions, InitialVersion{Pattern: pattern, Version: version})
+	return nil
+}
+
+//initialVersionOf returns the first version of the given new project, the most specific pattern wins
+func (v *Version) initialVersionOf(project string) string {
+	initial, specificity := "", -1
+	for _, candidate := range v.initialVersions {
+		if matches, _ := path.Match(candidate.Pattern, project); matches {
+			if length := len(strings.Replace(candidate.Pattern, "*", "", -1)); length > specificity {
+				initial, specificity = candidate.Version, length
+			}
+		}
+	}
+
+	return initial
+}
+
+//startsWithInitialVersion returns true, if the change of the element to a new project yields its initial version
+func startsWithInitialVersion(element string) bool {
+	return element != "set" && element != createElement && !strings.HasPrefix(element, "decrement-")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Initial_Version_Of_New_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.0", "p1"))
+	_ = version.AddInitialVersion("*", "1.0.0")
+	_ = version.AddInitialVersion("web-*", "0.1.0")
+
+	first, _ := version.Bump("api", "patch")
+	second, _ := version.Bump("api", "patch")
+	web, _ := version.Bump("web-shop", "minor")
+	existing, _ := version.Bump("p1", "patch")
+	set, _ := version.Set("other", "3.0.0")
+	created, _ := version.Create("web-admin", "", nil)
+	reserved, _ := version.Reserve("web-blog", "patch", time.Minute)
+
+	Ω.Expect(first.Version).To(Equal("1.0.0"))
+	Ω.Expect(second.Version).To(Equal("1.0.1"))
+	Ω.Expect(web.Version).To(Equal("0.1.0"))
+	Ω.Expect(existing.Version).To(Equal("1.4.1"))
+	Ω.Expect(set.Version).To(Equal("3.0.0"))
+	Ω.Expect(created.Version).To(Equal("0.1.0"))
+	Ω.Expect(reserved.Version).To(Equal("0.1.0"))
+}
+
+func Test_Invalid_Initial_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+
+	Ω.Expect(version.AddInitialVersion("*", "1.0.x")).NotTo(BeNil())
+	Ω.Expect(version.AddInitialVersion("[", "1.0.0")).NotTo(BeNil())
+}
+
+func Test_Without_Initial_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+
+	entry, _ := version.Bump("p1", "patch")
+
+	Ω.Expect(entry.Version).To(Equal("0.0.1"))
+}
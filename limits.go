@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//SetRequestTimeout limits the time of every request (0 is unlimited), routes override it for paths starting with the route, e.g. /export
+func (handler *Handler) SetRequestTimeout(timeout time.Duration, routes map[string]time.Duration) {
+	handler.requestTimeout = timeout
+	handler.routeTimeouts = routes
+}
+
+//SetMaxBodySize limits the size of request bodies in bytes (0 is unlimited)
+func (handler *Handler) SetMaxBodySize(size int64) {
+	handler.maxBodySize = size
+}
+
+//timeoutOf returns the timeout of the request path, namespaced paths have the timeouts of their project routes
+func (handler *Handler) timeoutOf(path string) time.Duration {
+	if path == "/ws" {
+		// websocket connections are long-lived
+		return 0
+	}
+	if strings.HasPrefix(path, "/ns/") {
+		parts := strings.SplitN(path, "/", 4)
+		path = "/"
+		if len(parts) == 4 {
+			path += parts[3]
+		}
+	}
+
+	timeout, matched := handler.requestTimeout, ""
+	for route, routeTimeout := range handler.routeTimeouts {
+		if strings.HasPrefix(path, route) && len(route) > len(matched) {
+			timeout, matched = routeTimeout, route
+		}
+	}
+
+	return timeout
+}
+
+func timeoutValues(timeouts map[string]time.Duration) []time.Duration {
+	values := []time.Duration{}
+	for _, timeout := range timeouts {
+		values = append(values, timeout)
+	}
+
+	return values
+}
+
+//BodyLimitMiddleware rejects request bodies larger than the maximum body size with 413
+func (handler *Handler) BodyLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, handler.maxBodySize))
+		if err != nil {
+			_ = c.AbortWithError(http.StatusRequestEntityTooLarge, errors.Errorf("Request body of %v exceeds %v bytes", c.Request.URL.Path, handler.maxBodySize))
+			return
+		}
+
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+//ErrRequestTimedOut is returned when a change is about to be stored after its request was answered with 408
+var ErrRequestTimedOut = errors.New("request timed out")
+
+type commitGuardKey struct{}
+
+//commitGuard decides between storing the change of a request and answering it with 408, whichever comes first
+type commitGuard struct {
+	sync.Mutex
+	timedOut  bool
+	committed bool
+}
+
+//commitChange marks the change of the request as stored, it fails once the request was answered with 408
+func commitChange(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	guard, ok := ctx.Value(commitGuardKey{}).(*commitGuard)
+	if !ok {
+		return nil
+	}
+
+	guard.Lock()
+	defer guard.Unlock()
+	if guard.timedOut {
+		return ErrRequestTimedOut
+	}
+	guard.committed = true
+	return nil
+}
+
+//WithContext returns the version service storing changes only while the request isn't answered by its timeout
+func (v *Version) WithContext(ctx context.Context) *Version {
+	bound := *v
+	bound.ctx = ctx
+	return &bound
+}
+
+//timeoutHandler answers requests, which take longer than their timeout, with 408 and cancels their context
+type timeoutHandler struct {
+	next    http.Handler
+	timeout func(path string) time.Duration
+}
+
+func (h *timeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	timeout := h.timeout(r.URL.Path)
+	if timeout <= 0 {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	guard := &commitGuard{}
+	ctx, cancel := context.WithTimeout(context.WithValue(r.Context(), commitGuardKey{}, guard), timeout)
+	defer cancel()
+
+	tw := &timeoutWriter{header: http.Header{}}
+	done := make(chan struct{})
+	panics := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panics <- p
+			}
+		}()
+		h.next.ServeHTTP(tw, r.WithContext(ctx))
+		close(done)
+	}()
+
+	select {
+	case p := <-panics:
+		panic(p)
+	case <-done:
+		tw.flush(w)
+	case <-ctx.Done():
+		guard.Lock()
+		if guard.committed {
+			// a change already being stored is answered, so a client doesn't retry it
+			guard.Unlock()
+			select {
+			case p := <-panics:
+				panic(p)
+			case <-done:
+				tw.flush(w)
+			}
+			return
+		}
+		guard.timedOut = true
+		guard.Unlock()
+
+		tw.mutex.Lock()
+		defer tw.mutex.Unlock()
+		tw.timedOut = true
+		http.Error(w, "Request timed out after "+timeout.String(), http.StatusRequestTimeout)
+	}
+}
+
+//timeoutWriter buffers the response until the request is finished in time
+type timeoutWriter struct {
+	mutex    sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+//flush writes the buffered response of a request finished in time
+func (tw *timeoutWriter) flush(w http.ResponseWriter) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	for key, values := range tw.header {
+		w.Header()[key] = values
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	w.WriteHeader(tw.code)
+	_, _ = w.Write(tw.body.Bytes())
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+
+	return tw.body.Write(data)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+
+	tw.code = code
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+type slowProvider struct {
+	adapter.IFileProvider
+	delay time.Duration
+}
+
+func (provider *slowProvider) ReadVersion(project string) (string, error) {
+	time.Sleep(provider.delay)
+	return provider.IFileProvider.ReadVersion(project)
+}
+
+func Test_Request_Timeout(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(&slowProvider{adapter.NewMock("1.0.0", "p1"), 200 * time.Millisecond}), nil)
+	handler.SetRequestTimeout(20*time.Millisecond, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusRequestTimeout))
+}
+
+func Test_Timed_Out_Bump_Is_Not_Stored(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	provider := adapter.NewMock("1.0.0", "p1")
+	handler := NewHandler(NewVersion(&slowProvider{provider, 100 * time.Millisecond}), nil)
+	handler.SetRequestTimeout(20*time.Millisecond, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusRequestTimeout))
+
+	// the bump goes on in the background until it is about to store the version
+	time.Sleep(300 * time.Millisecond)
+	current, _ := provider.ReadVersion("p1")
+	history, _ := provider.ReadHistory("p1")
+	Ω.Expect(current).To(Equal("1.0.0"))
+	Ω.Expect(history).To(BeEmpty())
+}
+
+func Test_Route_Timeout(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(&slowProvider{adapter.NewMock("1.0.0", "p1"), 50 * time.Millisecond}), nil)
+	handler.SetRequestTimeout(10*time.Millisecond, map[string]time.Duration{"/version": time.Second})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/ns/payments/version/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).NotTo(Equal(http.StatusRequestTimeout))
+	Ω.Expect(handler.timeoutOf("/version/p1")).To(Equal(time.Second))
+	Ω.Expect(handler.timeoutOf("/ns/payments/version/p1")).To(Equal(time.Second))
+	Ω.Expect(handler.timeoutOf("/export")).To(Equal(10 * time.Millisecond))
+}
+
+func Test_Request_Within_Timeout(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetRequestTimeout(time.Second, nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+}
+
+func Test_Body_Size_Limit(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetMaxBodySize(32)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/version/p1", bytes.NewBufferString(`{"version": "1.1.0", "reason": "`+strings.Repeat("x", 64)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusRequestEntityTooLarge))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/version/p1", bytes.NewBufferString(`{"version": "1.1.0"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.GetRouter().ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+}
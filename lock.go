@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//Locker serializes the changes of a project, the returned function unlocks it
+type Locker interface {
+	Lock(key string) (func(), error)
+}
+
+//projectLocks serializes the changes of a project within this instance
+type projectLocks struct {
+	mutex   sync.Mutex
+	entries map[string]*projectLock
+}
+
+type projectLock struct {
+	sync.Mutex
+	users int
+}
+
+func newProjectLocks() *projectLocks {
+	return &projectLocks{entries: map[string]*projectLock{}}
+}
+
+//UseLocker serializes the changes of projects with the locker instead of within this instance only
+func (v *Version) UseLocker(locker Locker) {
+	v.locks = timed(locker, v.slow)
+}
+
+//timedLocks observes the time waited for the locks of the locker
+type timedLocks struct {
+	locker Locker
+	slow   *slowStorage
+}
+
+func timed(locker Locker, slow *slowStorage) Locker {
+	return &timedLocks{locker: locker, slow: slow}
+}
+
+func (locks *timedLocks) Lock(key string) (func(), error) {
+	start := time.Now()
+	unlock, err := locks.locker.Lock(key)
+	wait := time.Since(start)
+	lockWait.Observe(wait.Seconds())
+	// keys of the default namespace start with the separator
+	locks.slow.log("lock", strings.TrimPrefix(key, "/"), wait)
+
+	return unlock, err
+}
+
+//Lock locks the given key and returns the unlock function, locks of keys nobody waits for are removed
+func (locks *projectLocks) Lock(key string) (func(), error) {
+	locks.mutex.Lock()
+	entry, exists := locks.entries[key]
+	if !exists {
+		entry = &projectLock{}
+		locks.entries[key] = entry
+	}
+	entry.users++
+	locks.mutex.Unlock()
+
+	entry.Lock()
+	return func() {
+		entry.Unlock()
+		locks.mutex.Lock()
+		defer locks.mutex.Unlock()
+		entry.users--
+		if entry.users == 0 {
+			delete(locks.entries, key)
+		}
+	}, nil
+}
+
+//fileLocks serializes the changes of a project across all processes sharing the lock directory with flock
+type fileLocks struct {
+	dir   string
+	local *projectLocks
+}
+
+//NewFileLocks constructs a locker with a lock file per project in the directory, which is created if missing
+func NewFileLocks(dir string) (Locker, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "Cannot create lock directory %v", dir)
+	}
+
+	return &fileLocks{dir: dir, local: newProjectLocks()}, nil
+}
+
+//Lock locks the given key within this instance first, so only one goroutine waits for the lock file
+func (locks *fileLocks) Lock(key string) (func(), error) {
+	unlock, _ := locks.local.Lock(key)
+
+	filename := filepath.Join(locks.dir, url.PathEscape(key)+".lock")
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		unlock()
+		return nil, errors.Wrapf(err, "Cannot open lock file %v", filename)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		unlock()
+		return nil, errors.Wrapf(err, "Cannot lock %v", filename)
+	}
+
+	return func() {
+		// closing the file releases the lock, the file is kept for the next lock
+		file.Close()
+		unlock()
+	}, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_File_Locks_Block_Other_Lockers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file locks are not supported on windows")
+	}
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "locks")
+	defer os.RemoveAll(dir)
+	first, _ := NewFileLocks(dir)
+	second, _ := NewFileLocks(dir)
+
+	unlock, err := first.Lock("team/p1")
+	Ω.Expect(err).NotTo(HaveOccurred())
+	locked := make(chan struct{})
+	go func() {
+		unlockSecond, _ := second.Lock("team/p1")
+		close(locked)
+		unlockSecond()
+	}()
+
+	Ω.Consistently(locked, 100*time.Millisecond).ShouldNot(BeClosed())
+	unlock()
+	Ω.Eventually(locked).Should(BeClosed())
+	Ω.Expect(filepath.Join(dir, "team%2Fp1.lock")).To(BeAnExistingFile())
+}
+
+func Test_Concurrent_Bumps_Of_Instances_Sharing_File_Locks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file locks are not supported on windows")
+	}
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+
+	results := make(chan string, concurrentBumpers*bumpsPerBumper)
+	bumpers := sync.WaitGroup{}
+	for instance := 0; instance < 2; instance++ {
+		// every instance has its own in-process locks like a separate process
+		version := NewVersion(adapter.New(basePath))
+		locks, err := NewFileLocks(filepath.Join(basePath, "_locks"))
+		Ω.Expect(err).NotTo(HaveOccurred())
+		version.UseLocker(locks)
+		for b := 0; b < concurrentBumpers/2; b++ {
+			bumpers.Add(1)
+			go func() {
+				defer bumpers.Done()
+				for i := 0; i < bumpsPerBumper; i++ {
+					bumped, err := version.BumpPatch("p1")
+					Ω.Expect(err).To(BeNil())
+					results <- bumped
+				}
+			}()
+		}
+	}
+	bumpers.Wait()
+	close(results)
+
+	seen := map[string]bool{}
+	for bumped := range results {
+		Ω.Expect(seen).NotTo(HaveKey(bumped), "duplicate version %v", bumped)
+		seen[bumped] = true
+	}
+	Ω.Expect(seen).To(HaveKey(fmt.Sprintf("0.0.%v", concurrentBumpers*bumpsPerBumper)))
+}
@@ -0,0 +1,18 @@
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+//lockFile blocks until the exclusive lock of the file is acquired
+func lockFile(file *os.File) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
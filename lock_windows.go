@@ -0,0 +1,14 @@
+// +build windows
+
+package main
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+//lockFile is not supported on windows, use the in-process locks
+func lockFile(file *os.File) error {
+	return errors.New("file locks are not supported on windows")
+}
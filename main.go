@@ -3,6 +3,8 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
 	"maibornwolff/vbump/adapter"
@@ -20,10 +22,127 @@ var (
 		},
 		[]string{"project", "element"},
 	)
+	numberOfNamespaceBumps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_namespace_bumps_total",
+			Help: "Number of bumps on namespaced projects tracked by vbump, labelled with namespace, projectname and semVer element",
+		},
+		[]string{"namespace", "project", "element"},
+	)
+	lastChange = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "vbump_last_change_info",
+			Help: "Last change of every project, labelled with namespace, projectname, semVer element, version and actor",
+		},
+		[]string{"namespace", "project", "element", "version", "actor"},
+	)
+	scheduledRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_scheduled_runs_total",
+			Help: "Number of scheduled bump runs, labelled with the result bumped, skipped or failed",
+		},
+		[]string{"result"},
+	)
+	failedChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_failed_changes_total",
+			Help: "Number of failed bumps and sets, labelled with namespace, projectname, operation and error class",
+		},
+		[]string{"namespace", "project", "operation", "class"},
+	)
+	storageErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_storage_errors_total",
+			Help: "Number of errors of the storage backend, labelled with the storage operation",
+		},
+		[]string{"operation"},
+	)
+	storageRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_storage_retries_total",
+			Help: "Number of retried storage calls after transient errors, labelled with the storage operation",
+		},
+		[]string{"operation"},
+	)
+	breakerState = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vbump_storage_breaker_state",
+			Help: "State of the circuit breaker around the storage backend: 0 closed, 1 half-open, 2 open",
+		},
+	)
+	datadirFreeBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vbump_datadir_free_bytes",
+			Help: "Free space of the file system of the datadir available to vbump",
+		},
+	)
+	datadirSizeBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vbump_datadir_size_bytes",
+			Help: "Size of the file system of the datadir",
+		},
+	)
+	corruptedEntries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_corrupted_entries_total",
+			Help: "Number of corrupted stored entries found, labelled with the kind of entry",
+		},
+		[]string{"kind"},
+	)
+	clientChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_client_changes_total",
+			Help: "Number of changes by identified clients, labelled with the client identifier and semVer element",
+		},
+		[]string{"client", "element"},
+	)
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "vbump_request_duration_seconds",
+			Help:    "Duration of requests, labelled with route, method and status, with the trace id as exemplar if enabled",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"route", "method", "status"},
+	)
+	purgedHistoryEntries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "vbump_history_purged_entries_total",
+			Help: "Number of history entries removed by the compaction, as they were beyond the retention",
+		},
+	)
+	replicatedChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_replication_changes_total",
+			Help: "Number of changes replicated between regions, labelled with the result (sent, failed, applied or conflict)",
+		},
+		[]string{"result"},
+	)
+	storageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "vbump_storage_duration_seconds",
+			Help:    "Duration of the calls of the storage backend, labelled with the operation",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		},
+		[]string{"operation"},
+	)
+	lockWait = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "vbump_lock_wait_seconds",
+			Help:    "Time waited for the lock of a project, before changing it",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		},
+	)
+	webhookDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_webhook_deliveries_total",
+			Help: "Number of webhook delivery attempts, labelled with the result (delivered or failed)",
+		},
+		[]string{"result"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(numberOfBumps)
+	prometheus.MustRegister(numberOfBumps, numberOfNamespaceBumps, lastChange, scheduledRuns, failedChanges, storageErrors, clientChanges, breakerState, storageRetries, datadirFreeBytes, datadirSizeBytes, corruptedEntries, webhookDeliveries, requestDuration, purgedHistoryEntries, replicatedChanges, storageDuration, lockWait)
 }
 
 func main() {
@@ -34,15 +153,384 @@ func main() {
 
 	log.SetOutput(w)
 
+	kingpin.Command("serve", "Serve the api (default).").Default()
+	checkCommand := kingpin.Command("check", "Check versions, configs and permissions of the datadir, exits non-zero if there are problems.")
+	benchCommand := kingpin.Command("bench", "Load test a running vbump with patch bumps and gets, reports throughput and latency percentiles.")
+	verifyCommand := kingpin.Command("verify-attestations", "Verify the chain of an attestation log, exits non-zero if statements were changed or removed.")
+	verifyLog := verifyCommand.Arg("log", "Attestation log written with --attestation-log.").Required().String()
+	benchServer := benchCommand.Flag("server", "URL of the vbump to test.").Default("http://localhost:8080").String()
+	benchConcurrency := benchCommand.Flag("concurrency", "Number of concurrent clients.").Default("10").Int()
+	benchRequests := benchCommand.Flag("requests", "Total number of requests.").Default("1000").Int()
+	benchProjects := benchCommand.Flag("projects", "Number of projects bumped (default one per client).").Default("0").Int()
+	benchPrefix := benchCommand.Flag("prefix", "Name prefix of the bumped projects.").Default("vbump-bench-").String()
+	benchToken := benchCommand.Flag("token", "Api token for servers with authentication.").String()
 	listenAddr := kingpin.Flag("listen", "Address to listen on.").Short('l').Default(":8080").String()
-	datadir := kingpin.Flag("datadir", "Directory path for storing version files (must exist).").Short('d').Required().String()
+	reusePort := kingpin.Flag("reuse-port", "Listen with SO_REUSEPORT, so a new process can listen on the same port, before the old one is stopped (linux only, requires --lock-provider flock).").Bool()
+	shutdownTimeout := kingpin.Flag("shutdown-timeout", "Time to wait for running requests after SIGTERM, before the process stops.").Default("30s").Duration()
+	datadir := kingpin.Flag("datadir", "Directory path for storing version files (must exist), required except for bench.").Short('d').String()
+	shardSelf := kingpin.Flag("shard-self", "URL of this instance as reachable by its shard peers.").String()
+	tokenFile := kingpin.Flag("token-file", "File with api tokens, one \"name token scope[,scope]\" entry per line. Enables authentication.").String()
+	nsMaxProjects := kingpin.Flag("ns-max-projects", "Maximum number of projects per namespace (0 is unlimited).").Default("0").Int()
+	nsMaxBumps := kingpin.Flag("ns-max-bumps-per-hour", "Maximum number of bumps per namespace and hour (0 is unlimited).").Default("0").Int()
+	nsQuotas := kingpin.Flag("ns-quota", "Quota for a single namespace as namespace:maxprojects:maxbumpsperhour[:maxhistoryentries[:maxhistoryage]] (repeatable), the history limits replace --history-max-entries and --history-max-age.").Strings()
+	notifyURL := kingpin.Flag("notify-url", "Default webhook url for notifications about changed versions (e.g. a Slack incoming webhook).").String()
+	notifyRoutes := kingpin.Flag("notify-route", "Webhook url for projects of an owner as owner=url (repeatable).").StringMap()
+	deletionRetention := kingpin.Flag("deletion-retention", "Time deleted projects are kept for restoring, before POST /admin/purge removes them, e.g. 30d.").Default("30d").String()
+	historyMaxAge := kingpin.Flag("history-max-age", "Remove history entries older than the age, the latest entry of a project is always kept, e.g. 365d.").String()
+	historyMaxEntries := kingpin.Flag("history-max-entries", "Keep at most the number of latest history entries per project (0 is unlimited).").Default("0").Int()
+	historyCompaction := kingpin.Flag("history-compaction-interval", "Interval for removing history entries beyond --history-max-age and --history-max-entries.").Default("1h").Duration()
+	auditLog := kingpin.Flag("audit-log", "Log changes of projects as json to syslog (syslog, syslog://host:514 over udp, syslog+tcp://host:514) or to a file instead of the application log.").String()
+	auditMaxSize := kingpin.Flag("audit-log-max-size", "Rotate the audit log file, when it exceeds the size, e.g. 100MB (0 is unlimited).").Default("0").Bytes()
+	auditMaxAge := kingpin.Flag("audit-log-max-age", "Rotate the audit log file, when it is older than the age, e.g. 24h (0 is unlimited).").Default("0").Duration()
+	auditKeep := kingpin.Flag("audit-log-keep", "Number of rotated audit log files to keep (0 keeps all).").Default("0").Int()
+	auditChain := kingpin.Flag("audit-log-chain", "Chain the entries of the audit log by hash to detect changed or removed entries, a file is verified on GET /admin/audit/verify.").Bool()
+	auditReads := kingpin.Flag("audit-reads", "Interval to write the number of reads per project, actor and client to the audit log (0 disables it).").Default("0").Duration()
+	errorReportingDSN := kingpin.Flag("error-reporting-dsn", "Report panics and 5xx responses to sentry (https://<key>@sentry.example.com/<project>) or post them as json to any other url.").String()
+	cacheVersions := kingpin.Flag("cache-versions", "Keep the versions of all projects in memory, only if no one else changes the datadir.").Bool()
+	preloadVersions := kingpin.Flag("preload-versions", "Load the versions of all projects into memory at startup, vbump is not ready until they are loaded (implies --cache-versions).").Bool()
+	replicateTo := kingpin.Flag("replicate-to", "Base url of the vbump instance of the peer region, all changes are replicated to it asynchronously.").String()
+	replicationToken := kingpin.Flag("replication-token", "Token with admin scope at the peer region, may be file:<path> or vault:<path>#<field>.").String()
+	region := kingpin.Flag("region", "Name of the region of this instance, sent with replicated changes.").Default("default").String()
+	replicationInterval := kingpin.Flag("replication-interval", "Interval to retry changes, which failed to replicate.").Default("10s").Duration()
+	lockProvider := kingpin.Flag("lock-provider", "Serialize the changes of a project within the process or with a lock file per project (flock), which works across processes sharing the lock directory.").Default("process").Enum("process", "flock")
+	lockDir := kingpin.Flag("lock-dir", "Directory of the lock files of the flock provider, defaults to _locks in the datadir.").String()
+	slowStorageThreshold := kingpin.Flag("slow-storage-threshold", "Log storage operations and lock waits taking at least the duration with project and operation, e.g. 100ms (0 disables it).").Default("0").Duration()
+	snapshotDir := kingpin.Flag("snapshot-dir", "Directory to write a snapshot of all projects with their history to on POST /admin/drain.").String()
+	vaultAddress := kingpin.Flag("vault-address", "Address of hashicorp vault, secrets given as vault:<path>#<field> are read from it.").String()
+	vaultTokenFile := kingpin.Flag("vault-token-file", "File with the vault token, e.g. the sink of the vault agent.").String()
+	secretRefresh := kingpin.Flag("secret-refresh", "Interval to read secrets given as file:<path> or vault:<path>#<field> and the token file again (0 disables it).").Default("5m").Duration()
+	signingKey := kingpin.Flag("signing-key", "PEM file with an ed25519 private key or vault:<mount>/<key> of a transit key to sign the versions of changes.").String()
+	signingKeyID := kingpin.Flag("signing-key-id", "Key id told in the signatures.").Default("vbump").String()
+	attestationLog := kingpin.Flag("attestation-log", "Append-only file to write a SLSA provenance statement of every change to, each line is chained to the previous one by its hash.").String()
+	attestationSink := kingpin.Flag("attestation-sink", "URL to post the SLSA provenance statement of every change to.").String()
+	policyAgentURL := kingpin.Flag("policy-agent", "URL of the decision of an open policy agent evaluating every mutating request, e.g. http://localhost:8181/v1/data/vbump/allow.").String()
+	policyAgentTimeout := kingpin.Flag("policy-agent-timeout", "Time to wait for a decision of the policy agent, requests are rejected with 503 after it.").Default("2s").Duration()
+	gcAge := kingpin.Flag("gc-age", "Age after which untouched projects are archived by the garbage collection, e.g. 180d.").String()
+	gcInterval := kingpin.Flag("gc-interval", "Interval for archiving stale projects in the background (0 disables it).").Default("0").Duration()
+	noImplicitCreate := kingpin.Flag("no-implicit-create", "Reject changes of unknown projects, projects must be created by POST /project/:project.").Bool()
+	metricsFromHistory := kingpin.Flag("metrics-from-history", "Expose vbump_recorded_changes_total counted from the stored history, consistent across restarts and replicas.").Bool()
+	metricsHistoryRefresh := kingpin.Flag("metrics-history-refresh", "Interval to count the history again for vbump_recorded_changes_total.").Default("1m").Duration()
+	trailingSlash := kingpin.Flag("trailing-slash", "Serve paths with a trailing slash like /version/foo/ like the ones without, instead of redirecting them.").Bool()
+	lowercaseProjects := kingpin.Flag("lowercase-projects", "Lowercase project names of requests, so Foo and foo are the same project.").Bool()
+	traceExemplars := kingpin.Flag("trace-exemplars", "Attach the trace id of traced requests (traceparent or X-B3-TraceId header) as exemplar to vbump_request_duration_seconds, exposes /metrics as OpenMetrics to scrapers asking for it.").Bool()
+	outbox := kingpin.Flag("outbox", "Record the event of every change in an outbox next to the version and deliver it from there, so events survive a crash right after a change.").Bool()
+	denyProjects := kingpin.Flag("deny-projects", "Reject creating projects with names matching the pattern, \"\" allows all names.").Default(DefaultDeniedProjects).String()
+	strictProjects := kingpin.Flag("strict-projects", "Reject bumps of unknown projects with 404 instead of creating them.").Bool()
+	initial := kingpin.Flag("initial-version", "First version of new projects, e.g. 1.0.0 (by default the first bump starts from nothing).").String()
+	projectInitial := kingpin.Flag("project-initial-version", "First version of new projects matching a pattern as pattern=version, e.g. web-*=0.1.0 (repeatable).").StringMap()
+	projectTemplates := kingpin.Flag("project-templates", "JSON file with named templates of version and config for creating projects by POST /project/:project?template=<name>.").String()
+	reservationTTL := kingpin.Flag("reservation-ttl", "Default time a reserved version is held until it is confirmed.").Default("15m").Duration()
+	hookURLs := kingpin.Flag("allow-hook", "URL, below which projects may call versioning scheme, diff analyzer and schedule check hooks, e.g. https://hooks.example.com/vbump (repeatable), projects cannot use hooks without.").Strings()
+	schedule := kingpin.Flag("schedule", "Run the bump schedules of all projects. Enable it on a single instance only.").Bool()
+	metricsMaxClients := kingpin.Flag("metrics-max-clients", "Maximum number of distinct client labels on metrics, further clients are counted as \"other\" (0 is unlimited).").Default("100").Int()
+	metricsMaxProjects := kingpin.Flag("metrics-max-projects", "Maximum number of distinct project labels on metrics, further projects are counted as \"other\" (0 is unlimited).").Default("0").Int()
+	metricsHashProjects := kingpin.Flag("metrics-hash-projects", "Replace the project label on metrics by a short hash of the project name.").Bool()
+	metricsNoProjects := kingpin.Flag("metrics-no-project-label", "Remove the project label from metrics, all projects are counted together.").Bool()
+	openMetrics := kingpin.Flag("metrics-openmetrics", "Expose /metrics as OpenMetrics to scrapers asking for it.").Bool()
+	clientHeader := kingpin.Flag("client-header", "Header identifying the client of a change, e.g. the pipeline, recorded in logs, history and metrics (empty disables it).").Default(defaultClientHeader).String()
+	pushRules := kingpin.Flag("push-rule", "Bump a project on pushes changing files matching a pattern as pattern=project, e.g. services/payments/**=payments (repeatable).").StringMap()
+	pushSecret := kingpin.Flag("push-secret", "Secret of the github or gitlab push webhook, pushes are then accepted without api token, may be file:<path> or vault:<path>#<field>.").String()
+	slackSecret := kingpin.Flag("slack-signing-secret", "Signing secret of the slack app, enables the slash command on /slack/command, may be file:<path> or vault:<path>#<field>.").String()
+	requestTimeout := kingpin.Flag("request-timeout", "Maximum time of a request, slower requests are answered with 408 (0 is unlimited).").Default("0").Duration()
+	routeTimeouts := kingpin.Flag("route-timeout", "Maximum time of requests to paths starting with a route as route=duration, e.g. /export=60s (repeatable).").StringMap()
+	maxBodySize := kingpin.Flag("max-body-size", "Maximum size of request bodies, larger bodies are rejected with 413, e.g. 1MB (0 is unlimited).").Default("0").Bytes()
+	retries := kingpin.Flag("storage-retries", "Retries of storage calls failing with transient errors like EIO or timeouts (0 disables them).").Default("0").Int()
+	storageBackoff := kingpin.Flag("storage-retry-backoff", "Wait before the first retry of a storage call, doubled on every further retry.").Default("50ms").Duration()
+	breakerFailures := kingpin.Flag("breaker-failures", "Consecutive failed or slow storage calls, which open the circuit breaker (0 disables it).").Default("0").Int()
+	breakerSlow := kingpin.Flag("breaker-slow", "Duration after which a storage call counts as failed for the circuit breaker (0 never).").Default("2s").Duration()
+	breakerCooldown := kingpin.Flag("breaker-cooldown", "Time the open circuit breaker rejects requests before trying the storage again.").Default("30s").Duration()
+	maxProjects := kingpin.Flag("max-projects", "Maximum number of projects in all namespaces, creating more projects is rejected with 403 (0 is unlimited).").Default("0").Int()
+	maxProjectSize := kingpin.Flag("max-project-size", "Maximum size of the version, documents and history of a project, changes of larger projects are rejected with 507, e.g. 1MB (0 is unlimited).").Default("0").Bytes()
+	minFreeSpace := kingpin.Flag("min-free-space", "Minimum free space of the datadir, below it vbump is read-only and rejects changes with 507, e.g. 100MB (0 disables it).").Default("0").Bytes()
+	diskCheckInterval := kingpin.Flag("disk-check-interval", "Interval for checking the free space of the datadir.").Default("10s").Duration()
+	chaos := kingpin.Flag("chaos", "Developer mode: inject latency or 5xx errors into the given percentage of requests to test the retries of clients.").Default("0").Int()
+	chaosLatency := kingpin.Flag("chaos-latency", "Maximum latency injected by --chaos (0 only injects errors).").Default("2s").Duration()
+	breakGlassFile := kingpin.Flag("break-glass-file", "File with a break-glass token, which is accepted as admin token while the file exists, requires --token-file.").String()
+	breakGlassTTL := kingpin.Flag("break-glass-ttl", "Time the break-glass token is valid after the file was written.").Default("1h").Duration()
+	shardPeers := kingpin.Flag("shard-peer", "URL of another instance sharing the project space (repeatable).").Strings()
 
-	kingpin.Parse()
+	command := kingpin.Parse()
+	if command == benchCommand.FullCommand() {
+		os.Exit(runBench(BenchOptions{Server: *benchServer, Concurrency: *benchConcurrency, Requests: *benchRequests, Projects: *benchProjects, Prefix: *benchPrefix, Token: *benchToken}, os.Stdout))
+	}
+	if command == verifyCommand.FullCommand() {
+		os.Exit(runVerifyAttestations(*verifyLog, os.Stdout))
+	}
+	if *datadir == "" {
+		kingpin.Fatalf("required flag --datadir not provided")
+	}
+	if command == checkCommand.FullCommand() {
+		os.Exit(runCheck(*datadir, os.Stdout))
+	}
+	// while both processes of an upgrade listen, both change the datadir
+	if *reusePort && *lockProvider != "flock" {
+		kingpin.Fatalf("--reuse-port requires --lock-provider flock")
+	}
+	if *reusePort && (*cacheVersions || *preloadVersions) {
+		kingpin.Fatalf("--reuse-port cannot be combined with --cache-versions or --preload-versions")
+	}
 	logger.Info("Server is starting...")
 
+	projectLabels = NewProjectLabels(*metricsMaxProjects, *metricsHashProjects)
+	clientLabels = NewProjectLabels(*metricsMaxClients, false)
+	if *metricsNoProjects {
+		projectLabels.Disable()
+	}
 	fileProvider := adapter.New(*datadir)
 	version := NewVersion(fileProvider)
+	version.LogSlowStorage(*slowStorageThreshold, logger)
+	if *lockProvider == "flock" {
+		dir := *lockDir
+		if dir == "" {
+			dir = filepath.Join(*datadir, "_locks")
+		}
+		locks, err := NewFileLocks(dir)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		version.UseLocker(locks)
+	}
+	if *noImplicitCreate {
+		version.RequireExplicitCreation()
+	}
+	if *strictProjects {
+		version.StrictProjects()
+	}
+	if err := version.DenyProjectNames(*denyProjects); err != nil {
+		logger.Fatal(err)
+	}
+	if err := version.AllowHookURLs(*hookURLs); err != nil {
+		logger.Fatal(err)
+	}
+	if *outbox {
+		version.UseOutbox()
+	}
+	if *metricsFromHistory {
+		prometheus.MustRegister(NewHistoryCollector(version, *metricsHistoryRefresh))
+	}
+	if *initial != "" {
+		if err := version.AddInitialVersion("*", *initial); err != nil {
+			logger.Fatal(err)
+		}
+	}
+	for pattern, initial := range *projectInitial {
+		if err := version.AddInitialVersion(pattern, initial); err != nil {
+			logger.Fatal(err)
+		}
+	}
+	if *projectTemplates != "" {
+		templates, err := LoadProjectTemplates(*projectTemplates)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		for name, template := range templates {
+			if err := version.AddProjectTemplate(name, template); err != nil {
+				logger.Fatal(err)
+			}
+		}
+	}
+	if *retries > 0 {
+		// retries are below the circuit breaker, which only sees the final result
+		version.RetryStorage(*retries, *storageBackoff)
+	}
 	handler := NewHandler(version, logger)
+	var vault *VaultClient
+	if *vaultAddress != "" {
+		vault = NewVaultClient(*vaultAddress, *vaultTokenFile)
+	}
+	secrets := NewSecrets(vault)
+	resolve := func(value string) *Secret {
+		secret, err := secrets.Resolve(value)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		return secret
+	}
+	if *signingKey != "" {
+		signer, err := NewSigner(*signingKey, vault)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		handler.SetSigner(signer, *signingKeyID)
+	}
+	if *policyAgentURL != "" {
+		handler.SetPolicyAgent(NewPolicyAgent(*policyAgentURL, *policyAgentTimeout))
+	}
+	if *attestationLog != "" || *attestationSink != "" {
+		attestor, err := NewAttestor("vbump/"+*region, *attestationLog, *attestationSink)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		handler.SetAttestor(attestor)
+	}
+	if *errorReportingDSN != "" {
+		reporter, err := NewErrorReporter(*errorReportingDSN, logger)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		handler.SetErrorReporter(reporter)
+	}
+	if *auditLog != "" {
+		audit, err := OpenAuditLog(*auditLog, AuditRotation{MaxSize: int64(*auditMaxSize), MaxAge: *auditMaxAge, Keep: *auditKeep})
+		if err != nil {
+			logger.Fatal(err)
+		}
+		if *auditChain {
+			file := *auditLog
+			if isSyslog(file) {
+				file = ""
+			}
+			if err := ChainAuditLog(audit, file); err != nil {
+				logger.Fatal(err)
+			}
+			handler.SetAuditChain(file)
+		}
+		handler.SetAuditLogger(audit)
+	}
+	if *auditReads > 0 {
+		handler.SetReadAudit(NewReadAudit())
+		handler.StartReadAudit(*auditReads)
+	}
+	if *breakerFailures > 0 {
+		breaker := NewBreaker(*breakerFailures, *breakerSlow, *breakerCooldown)
+		version.UseBreaker(breaker)
+		handler.SetBreaker(breaker)
+	}
+	if *minFreeSpace > 0 {
+		guard := NewDiskGuard(*datadir, uint64(*minFreeSpace))
+		if err := guard.Check(); err != nil {
+			logger.Fatal(err)
+		}
+		// writes are fenced above the circuit breaker, so rejected writes don't open it
+		version.FenceWrites(guard)
+		handler.SetDiskGuard(guard)
+		guard.Start(*diskCheckInterval, logger)
+	}
+	if *cacheVersions || *preloadVersions {
+		// the cache is above all other wrappers, so hits don't reach the storage backend
+		version.CacheVersions()
+	}
+	// projects are counted through the wrapped storage backend
+	version.LimitStorage(*maxProjects, int64(*maxProjectSize))
+	retention, err := parseAge(*deletionRetention)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	version.RetainDeleted(retention)
+	if *tokenFile != "" {
+		tokens, err := LoadTokens(*tokenFile)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		if *secretRefresh > 0 {
+			tokens.WatchTokens(*tokenFile, *secretRefresh, logger)
+		}
+		handler.SetTokenStore(tokens)
+		if *breakGlassFile != "" {
+			handler.SetBreakGlass(NewBreakGlass(*breakGlassFile, *breakGlassTTL))
+		}
+	} else if *breakGlassFile != "" {
+		logger.Fatal("--break-glass-file requires --token-file")
+	}
+	var quotas *Quotas
+	if *nsMaxProjects > 0 || *nsMaxBumps > 0 || len(*nsQuotas) > 0 {
+		quotas = NewQuotas(Quota{MaxProjects: *nsMaxProjects, MaxBumpsPerHour: *nsMaxBumps})
+		for _, text := range *nsQuotas {
+			namespace, quota, err := ParseQuota(text)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			quotas.Set(namespace, quota)
+		}
+		handler.SetQuotas(quotas)
+	}
+	// projects may configure their own webhook, so the notifier is always enabled
+	notifier := NewNotifier(*notifyURL, *notifyRoutes, logger)
+	notifier.SetSecrets(secrets)
+	handler.SetNotifier(notifier)
+	if *outbox {
+		go func() {
+			if err := handler.RecoverOutbox(); err != nil {
+				logger.Error(err)
+			}
+		}()
+	}
+	historyRetention := HistoryRetention{MaxEntries: *historyMaxEntries}
+	if *historyMaxAge != "" {
+		if historyRetention.MaxAge, err = parseAge(*historyMaxAge); err != nil {
+			logger.Fatal(err)
+		}
+	}
+	if (historyRetention.Enabled() || quotas.limitsHistory()) && *historyCompaction > 0 {
+		handler.StartHistoryCompaction(*historyCompaction, historyRetention)
+	}
+	if *preloadVersions {
+		handler.StartPreload()
+	}
+	if *replicateTo != "" {
+		replicator := NewReplicator(*replicateTo, resolve(*replicationToken), *region, logger)
+		replicator.Start(*replicationInterval)
+		handler.SetReplicator(replicator)
+	}
+	if *gcAge != "" {
+		age, err := parseAge(*gcAge)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		handler.SetGarbageCollectionAge(*gcAge)
+		if *gcInterval > 0 {
+			handler.StartGarbageCollection(*gcInterval, age)
+		}
+	}
+	handler.SetReservationTTL(*reservationTTL)
+	handler.SetClientHeader(*clientHeader)
+	handler.UseSlackSigningSecret(resolve(*slackSecret))
+	handler.SetTraceExemplars(*traceExemplars)
+	handler.SetOpenMetrics(*openMetrics)
+	handler.SetPathNormalization(*trailingSlash, *lowercaseProjects)
+	if err := handler.SetPushRules(*pushRules, ""); err != nil {
+		logger.Fatal(err)
+	}
+	handler.UsePushSecret(resolve(*pushSecret))
+	if *chaos > 0 {
+		logger.Warnf("Chaos mode injects faults into %v%% of the requests, never use it in production", *chaos)
+		handler.SetChaos(NewChaos(*chaos, *chaosLatency))
+	}
+	timeouts := map[string]time.Duration{}
+	for route, text := range *routeTimeouts {
+		timeout, err := time.ParseDuration(text)
+		if err != nil {
+			logger.Fatalf("Invalid timeout for route %v: %v", route, err)
+		}
+		timeouts[route] = timeout
+	}
+	handler.SetRequestTimeout(*requestTimeout, timeouts)
+	writeTimeout := 10 * time.Second
+	for _, timeout := range append([]time.Duration{*requestTimeout}, timeoutValues(timeouts)...) {
+		// the connection must outlive the request timeout, so the 408 reaches the client
+		if timeout+time.Second > writeTimeout {
+			writeTimeout = timeout + time.Second
+		}
+	}
+	handler.SetMaxBodySize(int64(*maxBodySize))
+	handler.SetSnapshotDir(*snapshotDir)
+	if *schedule {
+		handler.StartScheduler()
+	}
+	if len(*shardPeers) > 0 {
+		if *shardSelf == "" {
+			logger.Fatal("--shard-self is required when shard peers are configured")
+		}
+		shards, err := NewShardMap(*shardSelf, *shardPeers)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		handler.SetShardMap(shards)
+		logger.Infof("Sharding projects across %v and %v", *shardSelf, *shardPeers)
+	}
+	if *secretRefresh > 0 {
+		secrets.StartRefresh(*secretRefresh, logger)
+	}
 	router := handler.GetRouter()
 
 	server := &http.Server{
@@ -50,12 +538,16 @@ func main() {
 		Handler:      router,
 		ErrorLog:     log.New(w, "", 0),
 		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  15 * time.Second,
 	}
 
+	listener, err := listen(*listenAddr, *reusePort)
+	if err != nil {
+		log.Fatal(err)
+	}
 	logger.Infof("Server is ready to handle requests at %v", *listenAddr)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Could not listen on %v: %v\n", *listenAddr, err)
+	if err := serve(server, listener, *shutdownTimeout, logger); err != nil {
+		log.Fatalf("Could not serve on %v: %v\n", *listenAddr, err)
 	}
 }
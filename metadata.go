@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const metadataDocument = "meta"
+
+//Metadata describes who owns a project and what it is about
+type Metadata struct {
+	Owner       string            `json:"owner,omitempty"`
+	Description string            `json:"description,omitempty"`
+	RepoURL     string            `json:"repoUrl,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Config
+}
+
+//ProjectInfo is a project as returned by the project listing
+type ProjectInfo struct {
+	Name     string    `json:"name"`
+	Version  string    `json:"version"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+	Activity *Activity `json:"activity,omitempty"`
+}
+
+//ProjectFilter selects projects of the project listing
+type ProjectFilter struct {
+	Labels   []string
+	StaleFor time.Duration
+}
+
+//HasLabel returns true, if the metadata matches a "key:value" or "key" label filter
+func (meta *Metadata) HasLabel(filter string) bool {
+	parts := strings.SplitN(filter, ":", 2)
+	value, exists := meta.Labels[parts[0]]
+	if len(parts) == 1 {
+		return exists
+	}
+
+	return exists && value == parts[1]
+}
+
+//masked returns a copy of the metadata with its webhook secret hidden
+func (meta *Metadata) masked() *Metadata {
+	if meta == nil {
+		return nil
+	}
+
+	masked := *meta
+	masked.Config = meta.Config.masked()
+	return &masked
+}
+
+//GetMetadata returns the metadata of the given project or nil, if there is none
+func (v *Version) GetMetadata(project string) (*Metadata, error) {
+	document, err := v.fileProvider.ReadDocument(metadataDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get metadata for project %v", project)
+	}
+	if document == nil {
+		return nil, nil
+	}
+
+	meta := &Metadata{}
+	if err := json.Unmarshal(document, meta); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse metadata for project %v", project)
+	}
+
+	return meta, nil
+}
+
+//SetMetadata replaces the metadata of the given project, dependents leading back to the project are rejected,
+//the epoch is kept and may only be raised by StartEpoch
+func (v *Version) SetMetadata(project string, meta *Metadata) error {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project + "/" + metadataDocument)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored, err := v.GetMetadata(project)
+	if err != nil {
+		return err
+	}
+	meta.keepSecret(stored)
+	if err := v.checkHooks(&meta.Config); err != nil {
+		return err
+	}
+	epoch := 0
+	if stored != nil {
+		epoch = stored.Epoch
+	}
+	switch {
+	case meta.Epoch == 0:
+		meta.Epoch = epoch
+	case meta.Epoch != epoch:
+		return errors.Wrapf(ErrEpochChange, "Cannot set epoch %v for project %v in epoch %v", meta.Epoch, project, epoch)
+	}
+
+	return v.storeMetadata(project, meta)
+}
+
+//storeMetadata replaces the metadata of the given project including its epoch
+func (v *Version) storeMetadata(project string, meta *Metadata) error {
+	if err := v.checkDependents(project, meta.Dependents); err != nil {
+		return err
+	}
+
+	document, err := json.Marshal(meta)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode metadata for project %v", project)
+	}
+
+	err = v.fileProvider.StoreDocument(metadataDocument, project, document)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot set metadata for project %v", project)
+	}
+
+	return nil
+}
+
+//ListProjects returns all projects with their version, metadata and activity matching the filter
+func (v *Version) ListProjects(filter ProjectFilter) ([]ProjectInfo, error) {
+	projects, err := v.Projects()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []ProjectInfo{}
+	for _, project := range projects {
+		archived, err := v.IsArchived(project)
+		if err != nil {
+			return nil, err
+		}
+		meta, err := v.GetMetadata(project)
+		if err != nil {
+			return nil, err
+		}
+		activity, err := v.GetActivity(project)
+		if err != nil {
+			return nil, err
+		}
+		if archived || !matchesLabels(meta, filter.Labels) || (filter.StaleFor > 0 && !activity.IsStale(v.now(), filter.StaleFor)) {
+			continue
+		}
+
+		version, err := v.readVersion(project)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot get version for project %v", project)
+		}
+		infos = append(infos, ProjectInfo{Name: project, Version: version, Metadata: meta, Activity: activity})
+	}
+
+	return infos, nil
+}
+
+func matchesLabels(meta *Metadata, labels []string) bool {
+	for _, label := range labels {
+		if meta == nil || !meta.HasLabel(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//OnSetMetadata is a handler for replacing the metadata of a given project
+func (handler *Handler) OnSetMetadata(context *gin.Context) {
+	service, ok := handler.existingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	project := context.Param("project")
+
+	meta := &Metadata{}
+	if err := context.ShouldBindJSON(meta); err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid metadata"))
+		return
+	}
+	if err := meta.Validate(); err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := service.SetMetadata(project, meta); err != nil {
+		_ = context.AbortWithError(dependencyStatus(err), err)
+		return
+	}
+
+	handler.auditLog().Infof("set metadata on project %v", projectKey(context))
+	context.JSON(http.StatusOK, meta.masked())
+}
+
+//OnGetMetadata is a handler for getting the metadata of a given project
+func (handler *Handler) OnGetMetadata(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	meta, err := service.GetMetadata(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if meta == nil {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No metadata for project %v", projectKey(context)))
+		return
+	}
+
+	context.JSON(http.StatusOK, meta.masked())
+}
+
+//OnListProjects is a handler for listing all projects, optionally filtered by labels and staleness
+func (handler *Handler) OnListProjects(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	filter := ProjectFilter{Labels: context.QueryArray("label")}
+	if stale := context.Query("stale"); stale != "" {
+		age, err := parseAge(stale)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		filter.StaleFor = age
+	}
+
+	projects, err := service.ListProjects(filter)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	for i := range projects {
+		projects[i].Metadata = projects[i].Metadata.masked()
+	}
+	context.JSON(http.StatusOK, projects)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Metadata_Has_Label(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	meta := &Metadata{Labels: map[string]string{"team": "payments"}}
+
+	Ω.Expect(meta.HasLabel("team:payments")).To(BeTrue())
+	Ω.Expect(meta.HasLabel("team")).To(BeTrue())
+	Ω.Expect(meta.HasLabel("team:billing")).To(BeFalse())
+	Ω.Expect(meta.HasLabel("tier")).To(BeFalse())
+}
+
+func Test_Set_And_Get_Metadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/project/p1/meta", strings.NewReader(`{"owner":"team-a","labels":{"team":"payments"}}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/project/p1/meta", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"owner":"team-a","labels":{"team":"payments"}}`))
+}
+
+func Test_Set_Metadata_On_Unknown_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("PUT", "/project/p2/meta", strings.NewReader(`{"owner":"team-a"}`))
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(404))
+}
+
+func Test_Set_Invalid_Metadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("PUT", "/project/p1/meta", strings.NewReader(`{"owner":`))
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
+func Test_List_Projects_With_Label_Filter(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_, _ = version.SetVersion("p2", "2.0")
+	_ = version.SetMetadata("p2", &Metadata{Labels: map[string]string{"team": "payments"}})
+
+	all, _ := version.ListProjects(ProjectFilter{})
+	filtered, _ := version.ListProjects(ProjectFilter{Labels: []string{"team:payments"}})
+
+	Ω.Expect(all).To(HaveLen(2))
+	Ω.Expect(all[0].Name).To(Equal("p1"))
+	Ω.Expect(all[0].Metadata).To(BeNil())
+	Ω.Expect(filtered).To(HaveLen(1))
+	Ω.Expect(filtered[0].Name).To(Equal("p2"))
+	Ω.Expect(filtered[0].Version).To(Equal("2.0"))
+	Ω.Expect(filtered[0].Metadata.Labels).To(Equal(map[string]string{"team": "payments"}))
+}
+
+func Test_List_Projects_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/projects?label=team", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`[]`))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+//SetOpenMetrics serves /metrics as OpenMetrics to scrapers asking for it
+func (handler *Handler) SetOpenMetrics(enabled bool) {
+	handler.openMetrics = enabled
+}
+
+//metricsGatherer filters the metric families by name and removes the project label, when it is disabled
+type metricsGatherer struct {
+	next  prometheus.Gatherer
+	names map[string]bool
+}
+
+//Gather returns the metric families of the next gatherer, which are asked for
+func (gatherer *metricsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := gatherer.next.Gather()
+	filtered := []*dto.MetricFamily{}
+	for _, family := range families {
+		if len(gatherer.names) > 0 && !gatherer.names[family.GetName()] {
+			continue
+		}
+		if projectLabels.Disabled() {
+			for _, metric := range family.Metric {
+				metric.Label = withoutProjectLabel(metric.Label)
+			}
+		}
+		filtered = append(filtered, family)
+	}
+
+	return filtered, err
+}
+
+//withoutProjectLabel removes the project label, all projects share the empty label when it is disabled
+func withoutProjectLabel(labels []*dto.LabelPair) []*dto.LabelPair {
+	kept := []*dto.LabelPair{}
+	for _, label := range labels {
+		if label.GetName() != "project" {
+			kept = append(kept, label)
+		}
+	}
+
+	return kept
+}
+
+//metricsHandler serves the metrics for prometheus, the families are filtered by the name[] query parameter
+func (handler *Handler) metricsHandler() gin.HandlerFunc {
+	// exemplars are only part of the OpenMetrics format
+	opts := promhttp.HandlerOpts{EnableOpenMetrics: handler.openMetrics || handler.traceExemplars}
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gatherer := &metricsGatherer{next: prometheus.DefaultGatherer, names: map[string]bool{}}
+		for _, name := range r.URL.Query()["name[]"] {
+			gatherer.names[name] = true
+		}
+		promhttp.HandlerFor(gatherer, opts).ServeHTTP(w, r)
+	})
+
+	return gin.WrapH(promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, metrics))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_OpenMetrics(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetOpenMetrics(true)
+	router := handler.GetRouter()
+
+	metrics := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	router.ServeHTTP(metrics, req)
+
+	Ω.Expect(metrics.Header().Get("Content-Type")).To(HavePrefix("application/openmetrics-text"))
+	Ω.Expect(metrics.Body.String()).To(HaveSuffix("# EOF\n"))
+}
+
+func Test_Filter_Metrics_By_Name(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/patch/p1", nil))
+
+	metrics := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics?name[]=vbump_bumps_total&name[]=vbump_storage_errors_total", nil)
+	router.ServeHTTP(metrics, req)
+
+	Ω.Expect(metrics.Body.String()).To(ContainSubstring("vbump_bumps_total{"))
+	Ω.Expect(metrics.Body.String()).NotTo(ContainSubstring("vbump_request_duration_seconds"))
+	Ω.Expect(metrics.Body.String()).NotTo(ContainSubstring("go_goroutines"))
+}
+
+func Test_Metrics_Without_Project_Label(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	defer func(labels *ProjectLabels) { projectLabels = labels }(projectLabels)
+	projectLabels = NewProjectLabels(0, false)
+	projectLabels.Disable()
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "unlabelled")), nil).GetRouter()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/minor/unlabelled", nil))
+
+	metrics := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics?name[]=vbump_bumps_total", nil)
+	router.ServeHTTP(metrics, req)
+
+	Ω.Expect(metrics.Body.String()).To(ContainSubstring(`vbump_bumps_total{element="minor"}`))
+	Ω.Expect(metrics.Body.String()).NotTo(ContainSubstring("unlabelled"))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//SetPathNormalization serves paths with a trailing slash like the ones without and lowercases the project names of requests
+func (handler *Handler) SetPathNormalization(trailingSlash bool, lowercaseProjects bool) {
+	handler.trailingSlash = trailingSlash
+	handler.lowercaseProjects = lowercaseProjects
+}
+
+//projectName returns the project name as it is stored, lowercased if project names are normalized
+func (handler *Handler) projectName(project string) string {
+	if handler.lowercaseProjects {
+		return strings.ToLower(project)
+	}
+
+	return project
+}
+
+//NormalizeMiddleware lowercases the project of the request, before it is routed to a shard or a handler
+func (handler *Handler) NormalizeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i := range c.Params {
+			if c.Params[i].Key == "project" {
+				c.Params[i].Value = handler.projectName(c.Params[i].Value)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+//trailingSlashHandler removes the trailing slash of request paths, instead of redirecting them
+type trailingSlashHandler struct {
+	next http.Handler
+}
+
+func (h *trailingSlashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(r.URL.Path) > 1 && strings.HasSuffix(r.URL.Path, "/") {
+		r.URL.Path = strings.TrimRight(r.URL.Path, "/")
+		if r.URL.RawPath != "" {
+			r.URL.RawPath = strings.TrimRight(r.URL.RawPath, "/")
+		}
+	}
+
+	h.next.ServeHTTP(w, r)
+}
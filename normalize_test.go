@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Trailing_Slash_Is_Served_Like_Path_Without(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "foo")), nil)
+	handler.SetPathNormalization(true, false)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/foo/", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(Equal("1.0.0"))
+}
+
+func Test_Trailing_Slash_Is_Redirected_By_Default(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "foo")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/foo/", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusMovedPermanently))
+}
+
+func Test_Project_Names_Are_Lowercased(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "foo")), nil)
+	handler.SetPathNormalization(false, true)
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/Foo", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(Equal("1.1.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/foo", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(Equal("1.1.0"))
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+//Event describes a change of a project version
+type Event struct {
+	Namespace string    `json:"namespace,omitempty"`
+	Project   string    `json:"project"`
+	Element   string    `json:"element"`
+	Previous  string    `json:"previous"`
+	Version   string    `json:"version"`
+	Reason    string    `json:"reason,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	Client    string    `json:"client,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+//newEvent constructs the event of a recorded change
+func newEvent(namespace string, project string, entry *HistoryEntry) Event {
+	return Event{
+		Namespace: namespace,
+		Project:   project,
+		Element:   entry.Element,
+		Previous:  entry.Previous,
+		Version:   entry.Version,
+		Reason:    entry.Reason,
+		Actor:     entry.Actor,
+		Client:    entry.Client,
+		Time:      entry.Time,
+	}
+}
+
+//Notifier posts events to webhooks, routed by the owner of the project
+type Notifier struct {
+	defaultURL string
+	routes     map[string]string
+	client     *http.Client
+	logger     *log.Logger
+	pending    sync.WaitGroup
+	secrets    *Secrets
+}
+
+//SetSecrets resolves webhook secrets of projects referring to vault secrets
+func (notifier *Notifier) SetSecrets(secrets *Secrets) {
+	notifier.secrets = secrets
+}
+
+//secretOf returns the webhook secret of the project, vault:<path>#<field> refers to a vault secret
+func (notifier *Notifier) secretOf(meta *Metadata) (string, error) {
+	secret := webhookSecretOf(meta)
+	// projects must not refer to files of the host
+	if notifier.secrets == nil || !strings.HasPrefix(secret, vaultSecretPrefix) {
+		return secret, nil
+	}
+
+	resolved, err := notifier.secrets.Resolve(secret)
+	if err != nil {
+		return "", err
+	}
+	return resolved.Value(), nil
+}
+
+//NewNotifier constructs a notifier sending to the webhook of the project owner or to the default url
+func NewNotifier(defaultURL string, routes map[string]string, logger *log.Logger) *Notifier {
+	if logger == nil {
+		logger = log.New()
+	}
+
+	return &Notifier{
+		defaultURL: defaultURL,
+		routes:     routes,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logger,
+	}
+}
+
+//Target returns the webhook url of the project, of its owner or the default url, "" if there is none
+func (notifier *Notifier) Target(meta *Metadata) string {
+	if meta != nil && meta.Webhook != "" {
+		return meta.Webhook
+	}
+	if meta != nil {
+		if url, exists := notifier.routes[meta.Owner]; exists {
+			return url
+		}
+	}
+
+	return notifier.defaultURL
+}
+
+//Send posts the event to the webhook of the project
+func (notifier *Notifier) Send(event Event, meta *Metadata) error {
+	_, err := notifier.send(event, meta)
+	return err
+}
+
+//send posts the event to the webhook of the project and returns the delivery, nil if there is no webhook
+func (notifier *Notifier) send(event Event, meta *Metadata) (*Delivery, error) {
+	url := notifier.Target(meta)
+	if url == "" {
+		return nil, nil
+	}
+
+	payload, err := notifier.payload(event, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := notifier.secretOf(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &Delivery{ID: newDeliveryID(), URL: url, Event: event, Payload: string(payload), Time: time.Now().UTC()}
+	return delivery, notifier.Deliver(delivery, secret)
+}
+
+//Deliver posts the payload of the delivery signed with the secret and records the attempt in the delivery
+func (notifier *Notifier) Deliver(delivery *Delivery, secret string) error {
+	delivery.Attempts++
+	delivery.Status, delivery.Error = 0, ""
+
+	err := notifier.post(delivery, secret)
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	return err
+}
+
+func (notifier *Notifier) post(delivery *Delivery, secret string) error {
+	request, err := http.NewRequest(http.MethodPost, delivery.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return errors.Wrapf(err, "Cannot notify %v about project %v", delivery.URL, delivery.Event.Project)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(deliveryHeader, delivery.ID)
+	if secret != "" {
+		request.Header.Set(signatureHeader, signPayload(secret, []byte(delivery.Payload)))
+	}
+
+	res, err := notifier.client.Do(request)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot notify %v about project %v", delivery.URL, delivery.Event.Project)
+	}
+	defer res.Body.Close()
+	delivery.Status = res.StatusCode
+	if res.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("Notify %v about project %v failed with status %v", delivery.URL, delivery.Event.Project, res.StatusCode)
+	}
+
+	return nil
+}
+
+//payload renders the payload template of the project or the event as json with the release notes as text
+func (notifier *Notifier) payload(event Event, meta *Metadata) ([]byte, error) {
+	if meta != nil && meta.Templates != nil && meta.Templates.Payload != "" {
+		payload, err := renderTemplate("payload", meta.Templates.Payload, event)
+		return []byte(payload), err
+	}
+
+	text, err := releaseNotes(event, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(struct {
+		Text string `json:"text"`
+		Event
+	}{text, event})
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot encode event")
+	}
+
+	return payload, nil
+}
+
+//Notify sends the event in the background, logs failures and hands the delivery to record
+func (notifier *Notifier) Notify(event Event, meta *Metadata, record func(*Delivery)) {
+	notifier.pending.Add(1)
+	go func() {
+		defer notifier.pending.Done()
+		delivery, err := notifier.send(event, meta)
+		if err != nil {
+			notifier.logger.Error(err)
+		}
+		if delivery != nil {
+			countDelivery(delivery)
+			if record != nil {
+				record(delivery)
+			}
+		}
+	}()
+}
+
+func eventText(event Event) string {
+	project := event.Project
+	if event.Namespace != "" {
+		project = event.Namespace + "/" + project
+	}
+	text := fmt.Sprintf("vbump: %v bump of %v to %v", event.Element, project, event.Version)
+	switch event.Element {
+	case "set":
+		text = fmt.Sprintf("vbump: version of %v set to %v", project, event.Version)
+	case createElement:
+		text = fmt.Sprintf("vbump: project %v created with version %v", project, event.Version)
+	}
+	if event.Actor != "" {
+		text += fmt.Sprintf(" by %v", event.Actor)
+	}
+	if event.Reason != "" {
+		text += fmt.Sprintf(" (%v)", event.Reason)
+	}
+
+	return text
+}
+
+//publish records the change of a project in the metrics, sends it to subscribers and as event, if notifications are enabled
+func (handler *Handler) publish(namespace string, project string, service *Version, entry *HistoryEntry) {
+	recordLastChange(namespace, project, entry)
+	handler.events.Publish(newEvent(namespace, project, entry))
+	if handler.replicator != nil {
+		handler.replicator.Enqueue(namespace, project, entry)
+	}
+	handler.attest(namespace, project, entry)
+	if service.outbox {
+		// the event is already in the outbox of the project
+		handler.deliveries.Add(1)
+		go func() {
+			defer handler.deliveries.Done()
+			handler.dispatchOutbox(service, project)
+		}()
+		return
+	}
+	if handler.notifier == nil {
+		return
+	}
+
+	meta, err := service.GetMetadata(project)
+	if err != nil {
+		handler.logger.Error(err)
+	}
+
+	handler.notifier.Notify(newEvent(namespace, project, entry), meta, func(delivery *Delivery) {
+		if err := service.recordDelivery(project, delivery); err != nil {
+			handler.logger.Error(err)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func newWebhook() (*httptest.Server, chan map[string]interface{}) {
+	received := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+
+	return server, received
+}
+
+func Test_Notifier_Routes_By_Owner(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	notifier := NewNotifier("http://default", map[string]string{"payments": "http://payments"}, nil)
+
+	Ω.Expect(notifier.Target(&Metadata{Owner: "payments"})).To(Equal("http://payments"))
+	Ω.Expect(notifier.Target(&Metadata{Owner: "billing"})).To(Equal("http://default"))
+	Ω.Expect(notifier.Target(nil)).To(Equal("http://default"))
+}
+
+func Test_Notifier_Send(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	webhook, received := newWebhook()
+	defer webhook.Close()
+	notifier := NewNotifier("", map[string]string{"payments": webhook.URL}, nil)
+
+	err := notifier.Send(Event{Project: "p1", Element: "minor", Version: "1.1"}, &Metadata{Owner: "payments"})
+
+	Ω.Expect(err).To(BeNil())
+	payload := <-received
+	Ω.Expect(payload["text"]).To(Equal("vbump: minor bump of p1 to 1.1"))
+	Ω.Expect(payload["project"]).To(Equal("p1"))
+}
+
+func Test_Notifier_Send_Without_Target(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	notifier := NewNotifier("", map[string]string{}, nil)
+
+	err := notifier.Send(Event{Project: "p1"}, nil)
+
+	Ω.Expect(err).To(BeNil())
+}
+
+func Test_Notifier_Send_With_Failing_Webhook(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer webhook.Close()
+	notifier := NewNotifier(webhook.URL, map[string]string{}, nil)
+
+	err := notifier.Send(Event{Project: "p1"}, nil)
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Bump_Sends_Notification(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	webhook, received := newWebhook()
+	defer webhook.Close()
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetNotifier(NewNotifier(webhook.URL, map[string]string{}, nil))
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Eventually(received, time.Second).Should(Receive(And(
+		HaveKeyWithValue("text", "vbump: patch bump of team/p1 to 0.0.1"),
+		HaveKeyWithValue("namespace", "team"),
+	)))
+}
+
+func Test_Notify_Target_Of_Project_Webhook(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	notifier := NewNotifier("http://default", map[string]string{"payments": "http://payments"}, nil)
+
+	Ω.Expect(notifier.Target(&Metadata{Owner: "payments", Config: Config{Webhook: "http://project"}})).To(Equal("http://project"))
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//ErrPolicyAgent is the category of errors of an unavailable policy agent
+var ErrPolicyAgent = errors.New("policy agent unavailable")
+
+//PolicyInput is the context of a mutating request sent to the policy agent
+type PolicyInput struct {
+	Method    string   `json:"method"`
+	Path      string   `json:"path"`
+	Route     string   `json:"route"`
+	Action    string   `json:"action"`
+	Namespace string   `json:"namespace,omitempty"`
+	Project   string   `json:"project,omitempty"`
+	Element   string   `json:"element,omitempty"`
+	Version   string   `json:"version,omitempty"`
+	Actor     string   `json:"actor,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	Client    string   `json:"client,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+}
+
+//PolicyDecision is the decision of the policy agent, the reason tells why a request is denied
+type PolicyDecision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+//PolicyAgent asks an open policy agent for decisions about mutating requests
+type PolicyAgent struct {
+	url    string
+	client *http.Client
+}
+
+//NewPolicyAgent constructs an agent asking the decision at the url, e.g. http://localhost:8181/v1/data/vbump/allow
+func NewPolicyAgent(url string, timeout time.Duration) *PolicyAgent {
+	return &PolicyAgent{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+//SetPolicyAgent evaluates every mutating request and every change of a version with the policy agent before it is executed
+func (handler *Handler) SetPolicyAgent(agent *PolicyAgent) {
+	handler.policyAgent = agent
+	handler.version.UsePolicyAgent(agent)
+}
+
+//UsePolicyAgent evaluates every change of a version with the policy agent, e.g. of the scheduler or of slack commands
+func (v *Version) UsePolicyAgent(agent *PolicyAgent) {
+	v.policyAgent = agent
+}
+
+//policyDecidedKey is the context key of the project, whose change the policy agent decided for the whole request
+type policyDecidedKey struct{}
+
+//checkPolicyAgent asks the policy agent about the change of the project, unless it decided about the request already
+func (v *Version) checkPolicyAgent(project string, element string, currentVersion string) error {
+	if v.policyAgent == nil {
+		return nil
+	}
+	if v.ctx != nil {
+		if decided, ok := v.ctx.Value(policyDecidedKey{}).(string); ok && (decided == "*" || decided == v.namespace+"/"+project) {
+			return nil
+		}
+	}
+
+	// changes outside of a route of the project have no method, path and route, their action is the changed element
+	input := PolicyInput{
+		Action:    element,
+		Namespace: v.namespace,
+		Project:   project,
+		Element:   element,
+		Version:   currentVersion,
+		Actor:     v.annotation.Actor,
+		Client:    v.annotation.Client,
+		Reason:    v.annotation.Reason,
+	}
+	decision, err := v.policyAgent.Decide(input)
+	if err != nil {
+		return errors.WithStack(newError(ErrPolicyAgent, err.Error()))
+	}
+	if !decision.Allow {
+		reason := decision.Reason
+		if reason == "" {
+			reason = "denied by the policy agent"
+		}
+		return errors.WithStack(&PolicyViolation{Rule: "opa", Message: reason})
+	}
+
+	return nil
+}
+
+//decidedPolicy marks the project of the request as decided by the policy agent, "*" skips the agent for all changes of the request
+func decidedPolicy(c *gin.Context, project string) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), policyDecidedKey{}, project))
+}
+
+//Decide returns the decision about the input, the result of the rule is either a boolean or a decision
+func (agent *PolicyAgent) Decide(input PolicyInput) (*PolicyDecision, error) {
+	payload, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot encode policy input")
+	}
+	res, err := agent.client.Post(agent.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot ask policy agent %v", agent.url)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Policy agent %v responded with status %v", agent.url, res.StatusCode)
+	}
+
+	body := struct {
+		Result json.RawMessage `json:"result"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse decision of policy agent %v", agent.url)
+	}
+	// an undefined rule has no result and denies the request
+	decision := &PolicyDecision{}
+	if len(body.Result) == 0 {
+		decision.Reason = "the policy has no decision"
+		return decision, nil
+	}
+	if err := json.Unmarshal(body.Result, &decision.Allow); err == nil {
+		return decision, nil
+	}
+	if err := json.Unmarshal(body.Result, decision); err != nil {
+		return nil, errors.Wrapf(err, "Invalid decision of policy agent %v", agent.url)
+	}
+
+	return decision, nil
+}
+
+//policyInput returns the context of the request, the current version is read, if the route has a project
+func (handler *Handler) policyInput(c *gin.Context) PolicyInput {
+	route := strings.TrimPrefix(c.FullPath(), "/ns/:namespace")
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	input := PolicyInput{
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Route:     c.FullPath(),
+		Action:    segments[0],
+		Namespace: c.Param("namespace"),
+		Project:   c.Param("project"),
+		Element:   c.Param("element"),
+		Actor:     actor(c),
+		Client:    handler.client(c),
+		Reason:    c.Query("reason"),
+	}
+	if token, _ := c.Get(tokenKey); token != nil {
+		input.Scopes = token.(*Token).scopes
+	}
+	switch {
+	case input.Action == "major" || input.Action == "minor" || input.Action == "patch":
+		input.Element = input.Action
+	case input.Action == "decrement" && len(segments) > 1:
+		input.Element = segments[1]
+	case input.Action == "bump":
+		input.Element = strings.Trim(c.Param("elements"), "/")
+	}
+
+	if input.Project != "" {
+		service := handler.version
+		if input.Namespace != "" {
+			if namespaced, err := handler.version.Namespace(input.Namespace); err == nil {
+				service = namespaced
+			}
+		}
+		// the current version is best effort, the request fails on its own, if it cannot be read
+		input.Version, _ = service.readVersion(input.Project)
+	}
+
+	return input
+}
+
+//PolicyAgentMiddleware rejects mutating requests denied by the policy agent with 403 and with 503, if the agent is unavailable
+func (handler *Handler) PolicyAgentMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+		// the break-glass token is for incidents, when the agent may be unavailable itself
+		if token, _ := c.Get(tokenKey); token != nil && token.(*Token).breakGlass {
+			decidedPolicy(c, "*")
+			c.Next()
+			return
+		}
+
+		input := handler.policyInput(c)
+		decision, err := handler.policyAgent.Decide(input)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusServiceUnavailable, err)
+			return
+		}
+		if !decision.Allow {
+			reason := decision.Reason
+			if reason == "" {
+				reason = "denied by the policy agent"
+			}
+			handler.auditLog().Infof("policy agent denied %v %v of %v", input.Method, input.Path, input.Actor)
+			c.AbortWithStatusJSON(http.StatusForbidden, &PolicyViolation{Rule: "opa", Message: reason})
+			return
+		}
+		// routes without a project, e.g. slack commands, and further projects changed by the request are decided by the version service
+		if input.Project != "" {
+			decidedPolicy(c, input.Namespace+"/"+input.Project)
+		}
+
+		c.Next()
+	}
+}
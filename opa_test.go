@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+//newPolicyAgentRouter returns a router asking an agent, which allows patches only and records the inputs
+func newPolicyAgentRouter(inputs chan PolicyInput) (http.Handler, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Input PolicyInput `json:"input"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		inputs <- body.Input
+		switch body.Input.Element {
+		case "patch":
+			_, _ = w.Write([]byte(`{"result": true}`))
+		case "minor":
+			_, _ = w.Write([]byte(`{"result": {"allow": false, "reason": "minor bumps need a release ticket"}}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	tokens := NewTokenStore()
+	tokens.Add("ci", "secret", "*")
+	handler := NewHandler(NewVersion(adapter.NewMock("1.2.3", "p1")), nil)
+	handler.SetTokenStore(tokens)
+	handler.SetPolicyAgent(NewPolicyAgent(server.URL, time.Second))
+
+	return handler.GetRouter(), server.Close
+}
+
+func Test_Policy_Agent_Allows_Change(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	inputs := make(chan PolicyInput, 1)
+	router, stop := newPolicyAgentRouter(inputs)
+	defer stop()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1?reason=hotfix", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("1.2.4"))
+	input := <-inputs
+	Ω.Expect(input.Method).To(Equal("POST"))
+	Ω.Expect(input.Route).To(Equal("/patch/:project"))
+	Ω.Expect(input.Action).To(Equal("patch"))
+	Ω.Expect(input.Project).To(Equal("p1"))
+	Ω.Expect(input.Version).To(Equal("1.2.3"))
+	Ω.Expect(input.Actor).To(Equal("ci"))
+	Ω.Expect(input.Scopes).To(Equal([]string{"*"}))
+	Ω.Expect(input.Reason).To(Equal("hotfix"))
+}
+
+func Test_Policy_Agent_Denies_Change(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	inputs := make(chan PolicyInput, 1)
+	router, stop := newPolicyAgentRouter(inputs)
+	defer stop()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/bump/p1/minor", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(403))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"rule": "opa", "error": "minor bumps need a release ticket"}`))
+	input := <-inputs
+	Ω.Expect(input.Namespace).To(Equal("team"))
+	Ω.Expect(input.Action).To(Equal("bump"))
+	Ω.Expect(input.Version).To(BeEmpty())
+}
+
+func Test_Policy_Agent_Without_Decision_Denies(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	inputs := make(chan PolicyInput, 1)
+	router, stop := newPolicyAgentRouter(inputs)
+	defer stop()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/decrement/major/p1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(403))
+	Ω.Expect((<-inputs).Element).To(Equal("major"))
+}
+
+func Test_Policy_Agent_Is_Not_Asked_For_Reads(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	inputs := make(chan PolicyInput, 1)
+	router, stop := newPolicyAgentRouter(inputs)
+	defer stop()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(inputs).To(BeEmpty())
+}
+
+func Test_Unavailable_Policy_Agent(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.2.3", "p1")), nil)
+	handler.SetPolicyAgent(NewPolicyAgent("http://127.0.0.1:1/v1/data/vbump/allow", time.Second))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(503))
+}
+
+func Test_Policy_Agent_Decides_Changes_Outside_Of_Routes(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	inputs := make(chan PolicyInput, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Input PolicyInput `json:"input"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		inputs <- body.Input
+		_, _ = w.Write([]byte(`{"result": {"allow": false, "reason": "no scheduled releases"}}`))
+	}))
+	defer server.Close()
+	version := NewVersion(adapter.NewMock("1.2.3", "p1"))
+	version.UsePolicyAgent(NewPolicyAgent(server.URL, time.Second))
+
+	_, err := version.WithAnnotation(Annotation{Actor: "scheduler"}).BumpMinor("p1")
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(errors.Cause(err)).To(Equal(&PolicyViolation{Rule: "opa", Message: "no scheduled releases"}))
+	Ω.Expect(current).To(Equal("1.2.3"))
+	input := <-inputs
+	Ω.Expect(input.Project).To(Equal("p1"))
+	Ω.Expect(input.Element).To(Equal("minor"))
+	Ω.Expect(input.Version).To(Equal("1.2.3"))
+	Ω.Expect(input.Actor).To(Equal("scheduler"))
+}
+
+func Test_Policy_Agent_Decides_Propagated_Bumps(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	inputs := make(chan PolicyInput, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Input PolicyInput `json:"input"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		inputs <- body.Input
+		if body.Input.Project == "service-a" {
+			_, _ = w.Write([]byte(`{"result": false}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result": true}`))
+	}))
+	defer server.Close()
+	version := NewVersion(adapter.NewMock("1.0.0", "library-x"))
+	_, _ = version.SetVersion("service-a", "2.0.0")
+	_ = version.SetMetadata("library-x", &Metadata{Config: Config{Dependents: []string{"service-a"}}})
+	handler := NewHandler(version, nil)
+	handler.SetPolicyAgent(NewPolicyAgent(server.URL, time.Second))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/library-x?propagate=true", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	a, _ := version.GetVersion("service-a")
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(a).To(Equal("2.0.0"))
+	Ω.Expect((<-inputs).Project).To(Equal("library-x"))
+	Ω.Expect((<-inputs).Project).To(Equal("service-a"))
+	Ω.Expect(inputs).To(BeEmpty())
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//OnHeadVersion is a handler for probing a given project, it answers with the ETag of the version only
+func (handler *Handler) OnHeadVersion(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	// probes must not count as reads of the project
+	version, err := service.readVersion(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(changeStatus(err, http.StatusInternalServerError), err)
+		return
+	}
+	if version == "" {
+		context.Status(http.StatusNotFound)
+		return
+	}
+
+	if notModified(context, service.Display(context.Param("project"), version)) {
+		return
+	}
+	context.Status(http.StatusOK)
+}
+
+//OnMethodNotAllowed is a handler for requests with a method the path has no route for, it lists the allowed methods in the Allow header and answers OPTIONS with 204
+func (handler *Handler) OnMethodNotAllowed(router *gin.Engine) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Header("Allow", strings.Join(allowedMethods(router.Routes(), context.Request.URL.Path), ", "))
+		if context.Request.Method == http.MethodOptions {
+			context.Status(http.StatusNoContent)
+		}
+	}
+}
+
+//allowedMethods returns the sorted methods of the routes matching the path and OPTIONS
+func allowedMethods(routes gin.RoutesInfo, path string) []string {
+	allowed := map[string]bool{http.MethodOptions: true}
+	for _, route := range routes {
+		if routeMatches(route.Path, path) {
+			allowed[route.Method] = true
+		}
+	}
+
+	methods := []string{}
+	for method := range allowed {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	return methods
+}
+
+//routeMatches returns true, if the path matches the route with its :param and *catchAll segments
+func routeMatches(route string, path string) bool {
+	routeParts := strings.Split(strings.Trim(route, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range routeParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+
+	return len(routeParts) == len(pathParts)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Head_Version_Returns_ETag_Only(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Header().Get("ETag")).To(Equal(versionETag("1.0.0")))
+	Ω.Expect(res.Body.String()).To(BeEmpty())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/version/p1", nil)
+	req.Header.Set("If-None-Match", versionETag("1.0.0"))
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusNotModified))
+}
+
+func Test_Head_Version_Of_Unknown_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/ns/team/version/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusNotFound))
+}
+
+func Test_Options_Lists_Allowed_Methods_Without_Token(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := newAuthRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("OPTIONS", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusNoContent))
+	Ω.Expect(res.Header().Get("Allow")).To(Equal("DELETE, GET, HEAD, OPTIONS, PUT"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("OPTIONS", "/ns/team/version/p1/1.0.0", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusNoContent))
+	Ω.Expect(res.Header().Get("Allow")).To(Equal("GET, OPTIONS, POST"))
+}
+
+func Test_Wrong_Method_Is_Not_Allowed(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/major/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusMethodNotAllowed))
+	Ω.Expect(res.Header().Get("Allow")).To(Equal("OPTIONS, POST"))
+}
+
+func Test_Route_Matches(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(routeMatches("/version/:project", "/version/p1")).To(BeTrue())
+	Ω.Expect(routeMatches("/version/:project", "/version/p1/1.0")).To(BeFalse())
+	Ω.Expect(routeMatches("/bump/:project/*elements", "/bump/p1/minor/patch")).To(BeTrue())
+	Ω.Expect(routeMatches("/", "/")).To(BeTrue())
+	Ω.Expect(routeMatches("/readyz", "/")).To(BeFalse())
+}
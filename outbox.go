@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+const outboxDocument = "outbox"
+
+//OutboxEntry is an event of a change waiting for its delivery
+type OutboxEntry struct {
+	ID    string `json:"id"`
+	Event Event  `json:"event"`
+}
+
+//UseOutbox records the event of every change in the outbox of the project right after storing the version, so it is delivered even after a crash
+func (v *Version) UseOutbox() {
+	v.outbox = true
+}
+
+//Outbox returns the events of the given project waiting for their delivery, the oldest first
+func (v *Version) Outbox(project string) ([]OutboxEntry, error) {
+	entries := []OutboxEntry{}
+	document, err := v.fileProvider.ReadDocument(outboxDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get outbox for project %v", project)
+	}
+	if document == nil {
+		return entries, nil
+	}
+
+	if err := json.Unmarshal(document, &entries); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse outbox for project %v", project)
+	}
+
+	return entries, nil
+}
+
+func (v *Version) storeOutbox(project string, entries []OutboxEntry) error {
+	if len(entries) == 0 {
+		return v.fileProvider.DeleteDocument(outboxDocument, project)
+	}
+
+	document, err := json.Marshal(entries)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode outbox for project %v", project)
+	}
+	if err := v.fileProvider.StoreDocument(outboxDocument, project, document); err != nil {
+		return errors.Wrapf(err, "Cannot store outbox for project %v", project)
+	}
+
+	return nil
+}
+
+//appendOutbox adds the event of a change to the outbox, the caller holds the lock of the project
+func (v *Version) appendOutbox(project string, entry *HistoryEntry) error {
+	entries, err := v.Outbox(project)
+	if err != nil {
+		return err
+	}
+
+	return v.storeOutbox(project, append(entries, OutboxEntry{ID: newDeliveryID(), Event: newEvent(v.namespace, project, entry)}))
+}
+
+//removeOutbox removes a delivered event from the outbox of the given project
+func (v *Version) removeOutbox(project string, id string) error {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := v.Outbox(project)
+	if err != nil {
+		return err
+	}
+
+	pending := []OutboxEntry{}
+	for _, entry := range entries {
+		if entry.ID != id {
+			pending = append(pending, entry)
+		}
+	}
+
+	return v.storeOutbox(project, pending)
+}
+
+//dispatchOutbox delivers all pending events of the project, an event leaves the outbox after its delivery was recorded
+func (handler *Handler) dispatchOutbox(service *Version, project string) {
+	// a single dispatcher per project, so pending events are not delivered twice
+	unlock, err := service.locks.Lock(service.namespace + "/" + project + "/" + outboxDocument)
+	if err != nil {
+		handler.logger.Error(err)
+		return
+	}
+	defer unlock()
+
+	entries, err := service.Outbox(project)
+	if err != nil {
+		handler.logger.Error(err)
+		return
+	}
+
+	for _, entry := range entries {
+		if handler.notifier != nil {
+			handler.deliver(service, project, entry.Event)
+		}
+		if err := service.removeOutbox(project, entry.ID); err != nil {
+			handler.logger.Error(err)
+			return
+		}
+	}
+}
+
+//deliver sends the event to the webhook of the project and records the delivery
+func (handler *Handler) deliver(service *Version, project string, event Event) {
+	meta, err := service.GetMetadata(project)
+	if err != nil {
+		handler.logger.Error(err)
+	}
+
+	delivery, err := handler.notifier.send(event, meta)
+	if err != nil {
+		handler.logger.Error(err)
+	}
+	if delivery == nil {
+		return
+	}
+	countDelivery(delivery)
+	if err := service.recordDelivery(project, delivery); err != nil {
+		handler.logger.Error(err)
+	}
+}
+
+//RecoverOutbox delivers the events left in the outboxes of all projects, e.g. by a crash right after a change
+func (handler *Handler) RecoverOutbox() error {
+	_, err := handler.version.checkNamespaces(func(service *Version, namespace string) ([]FsckProblem, error) {
+		projects, err := service.Projects()
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			entries, err := service.Outbox(project)
+			if err != nil {
+				return nil, err
+			}
+			if len(entries) > 0 {
+				handler.logger.Infof("recover %v undelivered events of project %v", len(entries), project)
+				handler.dispatchOutbox(service, project)
+			}
+		}
+
+		return nil, nil
+	})
+
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Change_Is_Recorded_In_Outbox(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.UseOutbox()
+
+	_, err := version.BumpMinor("p1")
+	entries, _ := version.Outbox("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entries).To(HaveLen(1))
+	Ω.Expect(entries[0].Event.Version).To(Equal("1.1.0"))
+	Ω.Expect(entries[0].Event.Previous).To(Equal("1.0.0"))
+}
+
+func Test_Outbox_Is_Delivered_After_Change(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	webhook, received := newWebhook()
+	defer webhook.Close()
+	datadir, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(datadir)
+	// the outbox is delivered in the background, so the test needs a storage safe for concurrent use
+	version := NewVersion(adapter.New(datadir))
+	_, _ = version.SetVersion("p1", "1.0.0")
+	version.UseOutbox()
+	handler := NewHandler(version, nil)
+	handler.SetNotifier(NewNotifier(webhook.URL, nil, nil))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Eventually(received, time.Second).Should(Receive(HaveKeyWithValue("version", "1.0.1")))
+	Ω.Eventually(func() []OutboxEntry {
+		entries, _ := version.Outbox("p1")
+		return entries
+	}, time.Second).Should(BeEmpty())
+}
+
+func Test_Outbox_Is_Recovered(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	webhook, received := newWebhook()
+	defer webhook.Close()
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.UseOutbox()
+	// changes, whose events were not delivered before a crash
+	_, _ = version.BumpPatch("p1")
+	namespaced, _ := version.Namespace("team")
+	_, _ = namespaced.SetVersion("p2", "2.0.0")
+	handler := NewHandler(version, nil)
+	handler.SetNotifier(NewNotifier(webhook.URL, nil, nil))
+
+	err := handler.RecoverOutbox()
+	first, second := <-received, <-received
+	pending, _ := version.Outbox("p1")
+	pendingInNamespace, _ := namespaced.Outbox("p2")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(first["version"]).To(Equal("1.0.1"))
+	Ω.Expect(second["namespace"]).To(Equal("team"))
+	Ω.Expect(pending).To(BeEmpty())
+	Ω.Expect(pendingInNamespace).To(BeEmpty())
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//maxPadding limits the width of a padded part
+const maxPadding = 10
+
+//Padding renders the parts of the versions of a project zero-padded to the given widths, e.g. minor 2 renders 1.4.2 as 1.04.2
+type Padding struct {
+	Major int `json:"major,omitempty"`
+	Minor int `json:"minor,omitempty"`
+	Patch int `json:"patch,omitempty"`
+}
+
+//Validate returns an error, if a width is negative or too wide
+func (padding *Padding) Validate() error {
+	if padding == nil {
+		return nil
+	}
+
+	for _, width := range []int{padding.Major, padding.Minor, padding.Patch} {
+		if width < 0 || width > maxPadding {
+			return errors.Errorf("%v is not a valid padding, expected 0 to %v", width, maxPadding)
+		}
+	}
+	return nil
+}
+
+//pad returns the version with zero-padded parts, versions with other than numeric parts are returned as is
+func (padding *Padding) pad(version string) string {
+	if padding == nil {
+		return version
+	}
+
+	parts := strings.Split(releaseOf(version), ".")
+	widths := []int{padding.Major, padding.Minor, padding.Patch}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || i >= len(widths) {
+			return version
+		}
+		parts[i] = fmt.Sprintf("%0*d", widths[i], number)
+	}
+
+	return strings.Join(parts, ".") + prereleaseOf(version)
+}
+
+//unpad returns the version with the leading zeros of its parts removed, so it is stored canonical
+func (padding *Padding) unpad(version string) string {
+	if padding == nil {
+		return version
+	}
+
+	parts := strings.Split(releaseOf(version), ".")
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return version
+		}
+		parts[i] = strconv.Itoa(number)
+	}
+
+	return strings.Join(parts, ".") + prereleaseOf(version)
+}
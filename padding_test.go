@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Pad_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	padding := &Padding{Minor: 2, Patch: 2}
+
+	Ω.Expect(padding.pad("1.4.2")).To(Equal("1.04.02"))
+	Ω.Expect(padding.pad("1.4.123")).To(Equal("1.04.123"))
+	Ω.Expect(padding.pad("1.4.2-rc.1+build.5")).To(Equal("1.04.02-rc.1+build.5"))
+	Ω.Expect(padding.pad("1.4")).To(Equal("1.04"))
+	Ω.Expect(padding.pad("2:1.4-1")).To(Equal("2:1.4-1"))
+	Ω.Expect((*Padding)(nil).pad("1.4.2")).To(Equal("1.4.2"))
+	Ω.Expect(padding.unpad("1.04.02-rc.1")).To(Equal("1.4.2-rc.1"))
+	Ω.Expect((&Padding{Patch: 11}).Validate()).NotTo(BeNil())
+}
+
+func Test_Padded_Versions_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.9", "p1"))
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/config/p1", bytes.NewBufferString(`{"schemaVersion": 1, "prefix": "v", "padding": {"minor": 2, "patch": 2}}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("v1.04.10"))
+	Ω.Expect(res.Header().Get("X-Vbump-Previous-Version")).To(Equal("v1.04.09"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/version/p1/v1.05.01", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("v1.05.01"))
+	stored, _ := version.fileProvider.ReadVersion("p1")
+	Ω.Expect(stored).To(Equal("1.5.1"))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	strictParsing  = "strict"
+	lenientParsing = "lenient"
+)
+
+var (
+	strictVersion  = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-(0|[1-9]\d*|\d*[A-Za-z-][0-9A-Za-z-]*)(\.(0|[1-9]\d*|\d*[A-Za-z-][0-9A-Za-z-]*))*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+	lenientVersion = regexp.MustCompile(`^[vV]?(\d+)(?:\.(\d+))?(?:\.(\d+))?(-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+)
+
+//validParseMode returns true for the parse modes of a project, "" keeps one to three numeric parts
+func validParseMode(mode string) bool {
+	return mode == "" || mode == strictParsing || mode == lenientParsing
+}
+
+//parseVersion validates a version in the parse mode of the given project and returns it normalized
+func (v *Version) parseVersion(project string, version string) (string, error) {
+	meta, err := v.GetMetadata(project)
+	if err != nil {
+		return "", err
+	}
+
+	return parseVersionOf(meta, version)
+}
+
+//parseVersionOf validates a version in the parse mode of a project with the given metadata and returns it normalized
+func parseVersionOf(meta *Metadata, version string) (string, error) {
+	mode := ""
+	if meta != nil {
+		mode = meta.ParseMode
+		if meta.Prefix != "" {
+			version = strings.TrimPrefix(version, meta.Prefix)
+		}
+		var err error
+		version, err = stripEpoch(meta, version)
+		if err != nil {
+			return "", err
+		}
+		version = meta.Padding.unpad(version)
+		if meta.Scheme != nil && meta.Scheme.HookURL != "" {
+			// custom schemes define their own versions
+			if !schemeVersion.MatchString(version) {
+				return "", invalidVersionf("%v is not a valid version", version)
+			}
+			return version, nil
+		}
+	}
+
+	return parseVersionIn(mode, version)
+}
+
+func parseVersionIn(mode string, version string) (string, error) {
+	switch mode {
+	case strictParsing:
+		if !strictVersion.MatchString(version) {
+			return "", invalidVersionf("%v is not a valid semantic version", version)
+		}
+		return version, nil
+	case lenientParsing:
+		parts := lenientVersion.FindStringSubmatch(version)
+		if parts == nil {
+			return "", invalidVersionf("%v is not a valid version", version)
+		}
+		normalized := []string{}
+		for _, part := range parts[1:4] {
+			number, _ := strconv.Atoi(part)
+			normalized = append(normalized, strconv.Itoa(number))
+		}
+		return strings.Join(normalized, ".") + parts[4] + parts[5], nil
+	default:
+		if !validateVersion(version) {
+			return "", invalidVersionf("%v is not a valid version", version)
+		}
+		return version, nil
+	}
+}
+
+//releaseOf returns the version without prerelease and build metadata
+func releaseOf(version string) string {
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		return version[:i]
+	}
+
+	return version
+}
+
+//prereleaseOf returns the prerelease and build metadata of the version, "" for a release
+func prereleaseOf(version string) string {
+	return version[len(releaseOf(version)):]
+}
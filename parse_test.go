@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Parse_Strict_Versions(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for _, valid := range []string{"1.2.3", "0.0.0", "1.2.3-rc.1", "1.2.3-alpha-1+build.5", "10.20.30+001"} {
+		version, err := parseVersionIn(strictParsing, valid)
+		Ω.Expect(err).To(BeNil(), valid)
+		Ω.Expect(version).To(Equal(valid))
+	}
+	for _, invalid := range []string{"1.2", "01.2.3", "1.02.3", "v1.2.3", "1.2.3-01", "1.2.3-"} {
+		_, err := parseVersionIn(strictParsing, invalid)
+		Ω.Expect(err).NotTo(BeNil(), invalid)
+	}
+}
+
+func Test_Parse_Lenient_Versions(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for input, expected := range map[string]string{
+		"1.2":          "1.2.0",
+		"v1.2.3":       "1.2.3",
+		"V7":           "7.0.0",
+		"01.02.03":     "1.2.3",
+		"v1.2-rc.1+b5": "1.2.0-rc.1+b5",
+	} {
+		version, err := parseVersionIn(lenientParsing, input)
+		Ω.Expect(err).To(BeNil(), input)
+		Ω.Expect(version).To(Equal(expected))
+	}
+	_, err := parseVersionIn(lenientParsing, "1.2.3.4")
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Set_Version_In_Parse_Mode_Of_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+
+	_, errDefault := version.Set("p1", "v1.2")
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{ParseMode: lenientParsing}})
+	lenient, errLenient := version.Set("p1", "v1.2")
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{ParseMode: strictParsing}})
+	_, errStrict := version.Set("p1", "1.3")
+
+	Ω.Expect(errDefault).NotTo(BeNil())
+	Ω.Expect(errLenient).To(BeNil())
+	Ω.Expect(lenient.Version).To(Equal("1.2.0"))
+	Ω.Expect(errStrict).NotTo(BeNil())
+}
+
+func Test_Bump_Prerelease(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	for _, example := range []struct{ version, element, expected string }{
+		{"1.2.3-rc.1", "patch", "1.2.3"},
+		{"1.2.0-rc.1", "minor", "1.2.0"},
+		{"1.2.3-rc.1", "minor", "1.3.0"},
+		{"2.0.0-beta", "major", "2.0.0"},
+		{"1.2.0-beta", "major", "2.0.0"},
+		{"1.2.3+build.7", "patch", "1.2.4"},
+	} {
+		version, err := nextVersion(example.version, example.element, 1)
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(version).To(Equal(example.expected), example.version)
+	}
+}
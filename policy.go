@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//Policy restricts how the version of a project may change, it is evaluated before any change is stored
+type Policy struct {
+	MaxStep           int  `json:"maxStep,omitempty"`
+	MaxMajor          *int `json:"maxMajor,omitempty"`
+	ForbidMajor       bool `json:"forbidMajor,omitempty"`
+	RequirePrerelease bool `json:"requirePrerelease,omitempty"`
+	Monotonic         bool `json:"monotonic,omitempty"`
+	GapFree           bool `json:"gapFree,omitempty"`
+}
+
+//PolicyViolation is returned when a change violates a rule of the project policy
+type PolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"error"`
+}
+
+func (violation *PolicyViolation) Error() string {
+	return violation.Message
+}
+
+func violate(rule string, format string, args ...interface{}) *PolicyViolation {
+	return &PolicyViolation{Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
+
+//policyOf returns the policy of the given project or nil, if there is none
+func (v *Version) policyOf(project string) (*Policy, error) {
+	meta, err := v.GetMetadata(project)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+
+	return meta.Policy, nil
+}
+
+//checkStep returns an error, if the step is not positive or exceeds the policy of the given project
+func (v *Version) checkStep(project string, step int) error {
+	if step < 1 {
+		return errors.Errorf("%v is not a valid step", step)
+	}
+	if step == 1 {
+		return nil
+	}
+
+	policy, err := v.policyOf(project)
+	if err != nil {
+		return err
+	}
+	if policy != nil && policy.MaxStep > 0 && step > policy.MaxStep {
+		return violate("maxStep", "Cannot bump project %v by %v, maximum is %v", project, step, policy.MaxStep)
+	}
+	if policy != nil && policy.GapFree {
+		return violate("gapFree", "Cannot bump project %v by %v without a gap", project, step)
+	}
+
+	return nil
+}
+
+//checkPolicy returns a violation, if changing the version of the project from current to next breaks its policy
+func (v *Version) checkPolicy(project string, element string, current string, next string) error {
+	policy, err := v.policyOf(project)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	if policy.ForbidMajor && hasElement(element, "major") {
+		return violate("forbidMajor", "Major bumps are forbidden for project %v", project)
+	}
+
+	// numeric rules don't apply to versions of custom schemes
+	nextVersion, err := parseSemver(releaseOf(next))
+	if err == nil && policy.MaxMajor != nil && nextVersion[0] > *policy.MaxMajor {
+		return violate("maxMajor", "Version %v of project %v exceeds major version %v", next, project, *policy.MaxMajor)
+	}
+
+	if err == nil && policy.Monotonic && element == "set" && current != "" {
+		currentVersion, err := parseSemver(releaseOf(current))
+		if err == nil && nextVersion.compare(currentVersion) < 0 {
+			return violate("monotonic", "Version %v of project %v is lower than %v", next, project, current)
+		}
+	}
+
+	if policy.GapFree && !strings.HasPrefix(element, "decrement-") && !isContiguous(current, next) {
+		return violate("gapFree", "Version %v of project %v does not follow %v without a gap", next, project, current)
+	}
+
+	if policy.RequirePrerelease && !strings.Contains(next, "-") {
+		released, err := v.hasPrerelease(project, current, next)
+		if err != nil {
+			return err
+		}
+		if !released {
+			return violate("requirePrerelease", "Version %v of project %v requires a prerelease first", next, project)
+		}
+	}
+
+	return nil
+}
+
+//hasElement returns true, if the recorded change like "minor+patch" bumped the given element
+func hasElement(change string, element string) bool {
+	for _, bumped := range strings.Split(change, "+") {
+		if bumped == element {
+			return true
+		}
+	}
+
+	return false
+}
+
+//isGapFree returns true, if bumps of the given project are only finalized by confirming a reservation
+func (v *Version) isGapFree(project string) (bool, error) {
+	policy, err := v.policyOf(project)
+	if err != nil || policy == nil {
+		return false, err
+	}
+
+	return policy.GapFree, nil
+}
+
+//checkUnreserved returns a violation for bumps of gap-free projects, which are only finalized by confirming a reservation
+func (v *Version) checkUnreserved(project string) error {
+	gapFree, err := v.isGapFree(project)
+	if err != nil {
+		return err
+	}
+	if gapFree {
+		return violate("gapFree", "Bumps of project %v are finalized by confirming a reservation", project)
+	}
+
+	return nil
+}
+
+//isContiguous returns true, if next is the current version or follows it by a single bump
+func isContiguous(current string, next string) bool {
+	if current == "" || current == next {
+		return true
+	}
+
+	for _, element := range []string{"major", "minor", "patch"} {
+		if following, _ := nextVersion(current, element, 1); following == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+//hasPrerelease returns true, if the project had a prerelease of the given final version
+func (v *Version) hasPrerelease(project string, current string, final string) (bool, error) {
+	if strings.HasPrefix(current, final+"-") {
+		return true, nil
+	}
+
+	entries, err := v.History(project)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Version, final+"-") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+//stepOf returns the step of the "by" query parameter, 1 if there is none
+func stepOf(context *gin.Context) (int, error) {
+	by := context.Query("by")
+	if by == "" {
+		return 1, nil
+	}
+
+	step, err := strconv.Atoi(by)
+	if err != nil || step < 1 {
+		return 0, errors.Errorf("%v is not a valid step", by)
+	}
+
+	return step, nil
+}
+
+//abortChange aborts a failed change, policy violations and unbumpable stored versions are returned with their details
+func abortChange(context *gin.Context, err error, fallback int) {
+	if violation, ok := errors.Cause(err).(*PolicyViolation); ok {
+		_ = context.Error(err)
+		context.AbortWithStatusJSON(http.StatusForbidden, violation)
+		return
+	}
+	if stored, ok := errors.Cause(err).(*StoredVersionError); ok {
+		_ = context.Error(err)
+		context.AbortWithStatusJSON(http.StatusConflict, stored)
+		return
+	}
+
+	status := changeStatus(err, fallback)
+	if errors.Is(err, ErrStorage) && isTransient(err) {
+		// the storage is unavailable for now, so the change is worth a retry
+		status = http.StatusServiceUnavailable
+	}
+	_ = context.AbortWithError(status, err)
+}
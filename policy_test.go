@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func Test_Bump_By_Step(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+
+	entry, err := version.BumpBy("p1", "minor", 5)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Version).To(Equal("1.9.0"))
+	Ω.Expect(entry.Element).To(Equal("minor"))
+}
+
+func Test_Bump_By_Step_Exceeding_Policy(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Policy: &Policy{MaxStep: 3}}})
+
+	_, err := version.BumpBy("p1", "patch", 4)
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(err).NotTo(BeNil())
+	Ω.Expect(current).To(Equal("1.4.2"))
+}
+
+func Test_Bump_By_Step_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Policy: &Policy{MaxStep: 10}}})
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/p1?by=10", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.14.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/minor/p1?by=11", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/minor/p1?by=-1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
+func Test_Policy_Rules(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	maxMajor := 2
+
+	for _, example := range []struct {
+		policy Policy
+		change func(*Version) error
+		rule   string
+	}{
+		{Policy{ForbidMajor: true}, func(v *Version) error { _, err := v.Bump("p1", "major"); return err }, "forbidMajor"},
+		{Policy{MaxMajor: &maxMajor}, func(v *Version) error { _, err := v.Set("p1", "3.0.0"); return err }, "maxMajor"},
+		{Policy{Monotonic: true}, func(v *Version) error { _, err := v.Set("p1", "1.3.9"); return err }, "monotonic"},
+		{Policy{RequirePrerelease: true}, func(v *Version) error { _, err := v.Bump("p1", "patch"); return err }, "requirePrerelease"},
+	} {
+		version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+		_ = version.SetMetadata("p1", &Metadata{Config: Config{Policy: &example.policy}})
+
+		err := example.change(version)
+		current, _ := version.GetVersion("p1")
+
+		violation, ok := errors.Cause(err).(*PolicyViolation)
+		Ω.Expect(ok).To(BeTrue())
+		Ω.Expect(violation.Rule).To(Equal(example.rule))
+		Ω.Expect(current).To(Equal("1.4.2"))
+	}
+}
+
+func Test_Policy_Allows_Compliant_Changes(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	maxMajor := 2
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Policy: &Policy{ForbidMajor: true, MaxMajor: &maxMajor, Monotonic: true}}})
+
+	_, errMinor := version.Bump("p1", "minor")
+	_, errSet := version.Set("p1", "2.0.0")
+	_, errSame := version.Set("p1", "2.0.0")
+
+	Ω.Expect(errMinor).To(BeNil())
+	Ω.Expect(errSet).To(BeNil())
+	Ω.Expect(errSame).To(BeNil())
+}
+
+func Test_Policy_Violation_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.2", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Policy: &Policy{Monotonic: true}}})
+	router := NewHandler(version, nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/version/p1/1.0.0", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(403))
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`"rule":"monotonic"`))
+}
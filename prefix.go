@@ -0,0 +1,88 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	//finalizePrerelease bumps a prerelease like a project, a prerelease of the next version is released
+	finalizePrerelease = "finalize"
+	//nextPrerelease counts up the prerelease and keeps the release
+	nextPrerelease = "next"
+)
+
+var transientPrerelease = regexp.MustCompile(`^(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+//validTransientPrerelease returns true for the prerelease modes of the transient bumps, "" finalizes
+func validTransientPrerelease(prerelease string) bool {
+	return prerelease == "" || prerelease == finalizePrerelease || prerelease == nextPrerelease
+}
+
+//countPrerelease increments the last numeric identifier of the prerelease or appends ".0" like npm, build metadata is dropped
+func countPrerelease(version string) (string, error) {
+	prerelease := strings.TrimPrefix(prereleaseOf(version), "-")
+	if i := strings.Index(prerelease, "+"); i >= 0 {
+		prerelease = prerelease[:i]
+	}
+	if prerelease == "" {
+		return "", invalidVersionf("%v has no prerelease to count up", version)
+	}
+
+	identifiers := strings.Split(prerelease, ".")
+	for i := len(identifiers) - 1; i >= 0; i-- {
+		if number, err := strconv.Atoi(identifiers[i]); err == nil {
+			identifiers[i] = strconv.Itoa(number + 1)
+			return releaseOf(version) + "-" + strings.Join(identifiers, "."), nil
+		}
+	}
+
+	return releaseOf(version) + "-" + prerelease + ".0", nil
+}
+
+//validPrefix returns true for the version prefixes of a project
+func validPrefix(prefix string) bool {
+	return prefix == "" || prefix == "v"
+}
+
+//splitPrefix separates a leading "v" or "V" from the version
+func splitPrefix(version string) (string, string) {
+	if len(version) > 1 && strings.ContainsAny(version[:1], "vV") && version[1] >= '0' && version[1] <= '9' {
+		return version[:1], version[1:]
+	}
+
+	return "", version
+}
+
+//Display returns the version of the given project with the prefix, the epoch and the padding the project is configured for
+func (v *Version) Display(project string, version string) string {
+	meta, err := v.GetMetadata(project)
+	if err != nil || meta == nil || version == "" {
+		return version
+	}
+
+	return meta.Prefix + epochPrefix(meta) + meta.Padding.pad(version)
+}
+
+//bumpTransient bumps the element of a version without changing any project and keeps its prefix, prereleases are finalized or counted up
+func bumpTransient(version string, element string, prerelease string) (string, error) {
+	prefix, version := splitPrefix(version)
+	if !validateVersion(releaseOf(version)) || !transientPrerelease.MatchString(prereleaseOf(version)) {
+		return "", invalidVersionf("%v is not a valid version", prefix+version)
+	}
+
+	if prerelease == nextPrerelease {
+		next, err := countPrerelease(version)
+		if err != nil {
+			return "", err
+		}
+		return prefix + next, nil
+	}
+	next, err := nextVersion(version, element, 1)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + next, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Transient_Bump_Keeps_Prefix(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+
+	patch, errPatch := version.BumpTransientPatch("v1.2.3")
+	minor, errMinor := version.BumpTransientMinor("V1.2")
+	_, errInvalid := version.BumpTransientPatch("v")
+
+	Ω.Expect(errPatch).To(BeNil())
+	Ω.Expect(patch).To(Equal("v1.2.4"))
+	Ω.Expect(errMinor).To(BeNil())
+	Ω.Expect(minor).To(Equal("V1.3"))
+	Ω.Expect(errInvalid).NotTo(BeNil())
+}
+
+func Test_Set_Version_With_Prefix(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Prefix: "v"}})
+
+	entry, err := version.Set("p1", "v1.2.3")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Version).To(Equal("1.2.3"))
+	Ω.Expect(version.Display("p1", entry.Version)).To(Equal("v1.2.3"))
+}
+
+func Test_Prefix_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Prefix: "v"}})
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("v1.1.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/version/p1/v2.0.0", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("v2.0.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("v2.0.0"))
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+)
+
+const (
+	createElement  = "create"
+	initialVersion = "0.0.0"
+
+	//DefaultDeniedProjects are the project names colliding with routes and internal directories of the datadir
+	DefaultDeniedProjects = "^(_.*|admin|metrics|projects)$"
+)
+
+var (
+	//ErrUnknownProject is returned when changing a project, which doesn't exist and may not be created implicitly
+	ErrUnknownProject = newError(ErrNotFound, "project does not exist")
+	//ErrProjectExists is returned when creating a project, which already exists
+	ErrProjectExists = newError(ErrConflict, "project already exists")
+	//ErrDeniedProject is returned when creating a project with a reserved name
+	ErrDeniedProject = newError(ErrConflict, "project name is reserved")
+)
+
+//CreateProjectRequest creates a project with an optional initial version and metadata
+type CreateProjectRequest struct {
+	Version  string    `json:"version,omitempty"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+//RequireExplicitCreation disables creating projects implicitly by their first change
+func (v *Version) RequireExplicitCreation() {
+	v.explicitCreation = true
+}
+
+//StrictProjects rejects bumps of unknown projects, they are still created by set version or explicitly
+func (v *Version) StrictProjects() {
+	v.strictProjects = true
+}
+
+//DenyProjectNames rejects creating projects with names matching the pattern, "" allows all names
+func (v *Version) DenyProjectNames(pattern string) error {
+	if pattern == "" {
+		v.deniedProjects = nil
+		return nil
+	}
+
+	denied, err := regexp.Compile(pattern)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid pattern of denied project names %v", pattern)
+	}
+	v.deniedProjects = denied
+	return nil
+}
+
+//checkProjectName returns ErrDeniedProject, if a project with the name must not be created
+func (v *Version) checkProjectName(project string) error {
+	if v.deniedProjects != nil && v.deniedProjects.MatchString(project) {
+		return errors.Wrapf(ErrDeniedProject, "Cannot create project %v", project)
+	}
+
+	return nil
+}
+
+//mayCreate returns true, if a change of the element may create an unknown project
+func (v *Version) mayCreate(element string) bool {
+	switch {
+	case element == createElement:
+		return true
+	case v.explicitCreation:
+		return false
+	case v.strictProjects:
+		return element == "set"
+	}
+
+	return true
+}
+
+//Create creates the given project with the initial version and metadata
+func (v *Version) Create(project string, version string, meta *Metadata) (*HistoryEntry, error) {
+	if err := validateProjectName(project); err != nil {
+		return nil, err
+	}
+	if err := v.checkProjectName(project); err != nil {
+		return nil, err
+	}
+	if err := v.checkDeleted(project); err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		if err := meta.Validate(); err != nil {
+			return nil, err
+		}
+		if err := v.checkDependents(project, meta.Dependents); err != nil {
+			return nil, err
+		}
+		if err := v.checkHooks(&meta.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	// the version is parsed with the requested metadata, which is only stored for a created project
+	version, err := parseVersionOf(meta, v.createVersionOf(project, version))
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := v.change(project, createElement, func(current string) (string, error) {
+		if current != "" {
+			return "", ErrProjectExists
+		}
+		return version, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot create project %v", project)
+	}
+	if meta != nil {
+		if err := v.SetMetadata(project, meta); err != nil {
+			return entry, err
+		}
+	}
+
+	return entry, nil
+}
+
+//createVersionOf returns the given version or the initial version for a new project
+func (v *Version) createVersionOf(project string, version string) string {
+	if version == "" {
+		version = v.initialVersionOf(project)
+	}
+	if version == "" {
+		version = initialVersion
+	}
+
+	return version
+}
+
+//OnCreateProject is a handler for creating a project explicitly
+func (handler *Handler) OnCreateProject(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	request := CreateProjectRequest{}
+	if name := context.Query("template"); name != "" {
+		template, err := service.projectTemplate(name)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		// the body overrides the settings of the template field by field
+		request.Version, request.Metadata = template.Version, &Metadata{Config: template.Config}
+	}
+	if context.Request.ContentLength != 0 {
+		if err := context.ShouldBindBodyWith(&request, binding.JSON); err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid project"))
+			return
+		}
+	}
+
+	if request.Metadata != nil {
+		if err := request.Metadata.Validate(); err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entry, err := service.Create(context.Param("project"), request.Version, request.Metadata)
+	if err != nil {
+		abortChange(context, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	handler.changed(context, service, entry)
+	handler.changeLog(context).Infof("create project %v with version %v", projectKey(context), entry.Version)
+	context.String(http.StatusCreated, "%s", service.Display(context.Param("project"), entry.Version))
+}
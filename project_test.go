@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func Test_Create_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+
+	entry, err := version.Create("p2", "", nil)
+	_, errExisting := version.Create("p1", "2.0.0", nil)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Element).To(Equal("create"))
+	Ω.Expect(entry.Version).To(Equal("0.0.0"))
+	Ω.Expect(errors.Cause(errExisting)).To(Equal(ErrProjectExists))
+}
+
+func Test_Create_Project_With_Metadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+
+	entry, err := version.Create("p1", "v1.2", &Metadata{Owner: "payments", Config: Config{ParseMode: lenientParsing}})
+	meta, _ := version.GetMetadata("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Version).To(Equal("1.2.0"))
+	Ω.Expect(meta.Owner).To(Equal("payments"))
+}
+
+func Test_Create_Existing_Project_Keeps_Metadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	_, _ = version.Create("p1", "1.0.0", &Metadata{Owner: "payments"})
+
+	_, err := version.Create("p1", "2.0.0", &Metadata{Owner: "billing"})
+	meta, _ := version.GetMetadata("p1")
+
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrProjectExists))
+	Ω.Expect(meta.Owner).To(Equal("payments"))
+}
+
+func Test_Change_Without_Implicit_Creation(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.RequireExplicitCreation()
+
+	_, errBump := version.Bump("typo", "patch")
+	_, errSet := version.Set("typo", "1.0.0")
+	_, errKnown := version.Bump("p1", "patch")
+	_, errCreate := version.Create("p2", "1.0.0", nil)
+
+	Ω.Expect(errors.Cause(errBump)).To(Equal(ErrUnknownProject))
+	Ω.Expect(errors.Cause(errSet)).To(Equal(ErrUnknownProject))
+	Ω.Expect(errKnown).To(BeNil())
+	Ω.Expect(errCreate).To(BeNil())
+}
+
+func Test_Create_Project_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.RequireExplicitCreation()
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p2", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/project/p2", bytes.NewBufferString(`{"version": "0.1.0", "metadata": {"policy": {"forbidMajor": true}}}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(201))
+	Ω.Expect(res.Body.String()).To(Equal("0.1.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/project/p2", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(409))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/major/p2", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+}
+
+func Test_Bump_With_Strict_Projects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.StrictProjects()
+	router := NewHandler(version, nil).GetRouter()
+
+	for _, path := range []string{"/patch/typo", "/minor/typo", "/major/typo", "/bump/typo/minor", "/reserve/patch/typo"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(404), path)
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/version/p2/1.0.0", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+}
+
+func Test_Create_Denied_Project_Names(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "metrics"))
+	Ω.Expect(version.DenyProjectNames(DefaultDeniedProjects)).To(Succeed())
+	router := NewHandler(version, nil).GetRouter()
+
+	for _, path := range []string{"/patch/admin", "/version/_quarantine/1.0.0", "/project/projects", "/ns/team/minor/metrics"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(409), path)
+	}
+
+	// existing projects keep working
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/metrics", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/admin-service", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+}
+
+func Test_Deny_Project_Names_With_Invalid_Pattern(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+
+	Ω.Expect(version.DenyProjectNames("(")).NotTo(Succeed())
+	Ω.Expect(version.DenyProjectNames("")).To(Succeed())
+	Ω.Expect(version.checkProjectName("_x")).To(Succeed())
+}
+
+func Test_Project_Names_Must_Stay_In_Namespace(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+
+	_, errCreate := version.Create("../escaped", "", nil)
+	_, errSet := version.SetVersion(".hidden", "1.0.0")
+	_, errExists := version.Exists(`dir\p1`)
+
+	Ω.Expect(errors.Is(errCreate, ErrInvalidProjectName)).To(BeTrue())
+	Ω.Expect(errors.Is(errSet, ErrInvalidProjectName)).To(BeTrue())
+	Ω.Expect(errors.Is(errExists, ErrInvalidProjectName)).To(BeTrue())
+}
+
+func Test_Concurrent_Creates_Of_A_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	version := NewVersion(&slowDocuments{adapter.New(basePath)})
+
+	results := make(chan error, 20)
+	creators := sync.WaitGroup{}
+	for c := 0; c < 20; c++ {
+		creators.Add(1)
+		go func() {
+			defer creators.Done()
+			_, err := version.Create("p1", "1.0.0", nil)
+			results <- err
+		}()
+	}
+	creators.Wait()
+	close(results)
+
+	created := 0
+	for err := range results {
+		if err == nil {
+			created++
+			continue
+		}
+		Ω.Expect(errors.Cause(err)).To(Equal(ErrProjectExists))
+	}
+	Ω.Expect(created).To(Equal(1))
+}
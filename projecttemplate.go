@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+//ErrUnknownTemplate is returned when creating a project from a template, which isn't configured
+var ErrUnknownTemplate = errors.New("project template does not exist")
+
+//ProjectTemplate holds the initial version and settings applied to new projects created from it
+type ProjectTemplate struct {
+	Version string `json:"version,omitempty"`
+	Config  Config `json:"config"`
+}
+
+//LoadProjectTemplates reads named project templates from a JSON file, e.g. {"service-default": {"version": "0.1.0", "config": {...}}}
+func LoadProjectTemplates(filename string) (map[string]ProjectTemplate, error) {
+	document, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot read project template file %v", filename)
+	}
+
+	templates := map[string]ProjectTemplate{}
+	if err := json.Unmarshal(document, &templates); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse project template file %v", filename)
+	}
+
+	return templates, nil
+}
+
+//AddProjectTemplate registers a named template for creating projects
+func (v *Version) AddProjectTemplate(name string, template ProjectTemplate) error {
+	if template.Version != "" && !validateVersion(template.Version) {
+		return errors.Errorf("%v is not a valid version of project template %v", template.Version, name)
+	}
+	if err := template.Config.Validate(); err != nil {
+		return errors.Wrapf(err, "Invalid project template %v", name)
+	}
+
+	if v.projectTemplates == nil {
+		v.projectTemplates = map[string]ProjectTemplate{}
+	}
+	v.projectTemplates[name] = template
+	return nil
+}
+
+//projectTemplate returns a copy of the named template, the metadata of new projects starts with its settings
+func (v *Version) projectTemplate(name string) (*ProjectTemplate, error) {
+	template, exists := v.projectTemplates[name]
+	if !exists {
+		return nil, errors.Wrapf(ErrUnknownTemplate, "Cannot find project template %v", name)
+	}
+
+	// the settings are decoded into by the request, so they must not share policies or schedules with the template
+	document, err := json.Marshal(template)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot copy project template %v", name)
+	}
+	copied := &ProjectTemplate{}
+	if err := json.Unmarshal(document, copied); err != nil {
+		return nil, errors.Wrapf(err, "Cannot copy project template %v", name)
+	}
+
+	return copied, nil
+}
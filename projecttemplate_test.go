@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Load_Project_Templates(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "templates")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "templates.json")
+	_ = ioutil.WriteFile(filename, []byte(`{"service-default": {"version": "0.1.0", "config": {"prefix": "v", "policy": {"maxStep": 1}}}}`), 0644)
+	version := NewVersion(adapter.NewMock("", ""))
+
+	templates, err := LoadProjectTemplates(filename)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(templates["service-default"].Version).To(Equal("0.1.0"))
+	Ω.Expect(templates["service-default"].Config.Policy.MaxStep).To(Equal(1))
+	Ω.Expect(version.AddProjectTemplate("service-default", templates["service-default"])).To(BeNil())
+	Ω.Expect(version.AddProjectTemplate("broken", ProjectTemplate{Version: "one"})).NotTo(BeNil())
+	Ω.Expect(version.AddProjectTemplate("broken", ProjectTemplate{Config: Config{ParseMode: "loose"}})).NotTo(BeNil())
+}
+
+func Test_Create_Project_From_Template(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	_ = version.AddProjectTemplate("service-default", ProjectTemplate{Version: "0.1.0", Config: Config{Webhook: "http://hooks", Policy: &Policy{MaxStep: 1}}})
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/project/p1?template=service-default", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(201))
+	Ω.Expect(res.Body.String()).To(Equal("0.1.0"))
+	meta, _ := version.GetMetadata("p1")
+	Ω.Expect(meta.Webhook).To(Equal("http://hooks"))
+	Ω.Expect(meta.Policy.MaxStep).To(Equal(1))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/project/p2?template=service-default", bytes.NewBufferString(`{"version": "2.0.0", "metadata": {"owner": "payments", "policy": {"monotonic": true}}}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(201))
+	Ω.Expect(res.Body.String()).To(Equal("2.0.0"))
+	meta, _ = version.GetMetadata("p2")
+	Ω.Expect(meta.Owner).To(Equal("payments"))
+	Ω.Expect(meta.Webhook).To(Equal("http://hooks"))
+	Ω.Expect(*meta.Policy).To(Equal(Policy{MaxStep: 1, Monotonic: true}))
+	Ω.Expect(version.projectTemplates["service-default"].Config.Policy.Monotonic).To(BeFalse())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/project/p3?template=unknown", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+}
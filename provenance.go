@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	statementType  = "https://in-toto.io/Statement/v0.1"
+	provenanceType = "https://slsa.dev/provenance/v0.2"
+	bumpBuildType  = "https://github.com/maibornwolff/vbump/change@v1"
+)
+
+//Statement is an in-toto statement about the version of a project, the subject digest is the sha256 of the version
+type Statement struct {
+	Type          string     `json:"_type"`
+	Subject       []Subject  `json:"subject"`
+	PredicateType string     `json:"predicateType"`
+	Predicate     Provenance `json:"predicate"`
+}
+
+//Subject is the project with the digest of its version
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+//Provenance is the SLSA provenance predicate of a change, the change is the build of the version
+type Provenance struct {
+	Builder    ProvenanceBuilder    `json:"builder"`
+	BuildType  string               `json:"buildType"`
+	Invocation ProvenanceInvocation `json:"invocation"`
+	Metadata   ProvenanceMetadata   `json:"metadata"`
+}
+
+//ProvenanceBuilder identifies the vbump instance
+type ProvenanceBuilder struct {
+	ID string `json:"id"`
+}
+
+//ProvenanceInvocation tells the trigger and the actor of the change
+type ProvenanceInvocation struct {
+	Parameters  ProvenanceParameters `json:"parameters"`
+	Environment map[string]string    `json:"environment,omitempty"`
+}
+
+//ProvenanceParameters are the element, the previous and the new version of the change
+type ProvenanceParameters struct {
+	Namespace string `json:"namespace,omitempty"`
+	Project   string `json:"project"`
+	Element   string `json:"element"`
+	Previous  string `json:"previous,omitempty"`
+	Version   string `json:"version"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+//ProvenanceMetadata tells the time of the change
+type ProvenanceMetadata struct {
+	BuildFinishedOn time.Time `json:"buildFinishedOn"`
+}
+
+//attestationRecord is a line of the attestation log, previous is the sha256 of the line before, so changed lines are detected
+type attestationRecord struct {
+	Index     int       `json:"index"`
+	Previous  string    `json:"previous,omitempty"`
+	Statement Statement `json:"statement"`
+}
+
+//Attestor emits a provenance statement for every change to an append-only log and to a sink
+type Attestor struct {
+	mutex    sync.Mutex
+	builder  string
+	file     *os.File
+	index    int
+	previous string
+	sink     string
+	client   *http.Client
+}
+
+//NewAttestor constructs an attestor appending to the log file and posting to the sink url, both are optional
+func NewAttestor(builder string, filename string, sink string) (*Attestor, error) {
+	attestor := &Attestor{builder: builder, sink: sink, client: &http.Client{Timeout: 10 * time.Second}}
+	if filename == "" {
+		return attestor, nil
+	}
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot open attestation log %v", filename)
+	}
+	// the chain continues after the last line of the log
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(bytes.TrimSpace(line)) > 0 {
+			attestor.index++
+			attestor.previous = lineHash(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, errors.Wrapf(err, "Cannot read attestation log %v", filename)
+	}
+	attestor.file = file
+
+	return attestor, nil
+}
+
+//SetAttestor emits a provenance statement for every change
+func (handler *Handler) SetAttestor(attestor *Attestor) {
+	handler.attestor = attestor
+}
+
+func lineHash(line []byte) string {
+	hash := sha256.Sum256(line)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+//statement returns the provenance statement of the change
+func (attestor *Attestor) statement(namespace string, project string, entry *HistoryEntry) Statement {
+	name := project
+	if namespace != "" {
+		name = namespace + "/" + project
+	}
+	digest := sha256.Sum256([]byte(entry.Version))
+
+	environment := map[string]string{}
+	if entry.Actor != "" {
+		environment["actor"] = entry.Actor
+	}
+	if entry.Client != "" {
+		environment["client"] = entry.Client
+	}
+
+	return Statement{
+		Type:          statementType,
+		Subject:       []Subject{{Name: name, Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])}}},
+		PredicateType: provenanceType,
+		Predicate: Provenance{
+			Builder:   ProvenanceBuilder{ID: attestor.builder},
+			BuildType: bumpBuildType,
+			Invocation: ProvenanceInvocation{
+				Parameters: ProvenanceParameters{
+					Namespace: namespace,
+					Project:   project,
+					Element:   entry.Element,
+					Previous:  entry.Previous,
+					Version:   entry.Version,
+					Reason:    entry.Reason,
+				},
+				Environment: environment,
+			},
+			Metadata: ProvenanceMetadata{BuildFinishedOn: entry.Time},
+		},
+	}
+}
+
+//Append appends the statement to the log, it is chained to the previous line with its hash
+func (attestor *Attestor) Append(statement Statement) error {
+	if attestor.file == nil {
+		return nil
+	}
+
+	attestor.mutex.Lock()
+	defer attestor.mutex.Unlock()
+	line, err := json.Marshal(attestationRecord{Index: attestor.index + 1, Previous: attestor.previous, Statement: statement})
+	if err != nil {
+		return errors.Wrap(err, "Cannot encode attestation")
+	}
+	if _, err := attestor.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrapf(err, "Cannot write attestation log %v", attestor.file.Name())
+	}
+	attestor.index++
+	attestor.previous = lineHash(line)
+
+	return nil
+}
+
+//Send posts the statement to the sink
+func (attestor *Attestor) Send(statement Statement) error {
+	if attestor.sink == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return errors.Wrap(err, "Cannot encode attestation")
+	}
+	res, err := attestor.client.Post(attestor.sink, "application/vnd.in-toto+json", bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrapf(err, "Cannot send attestation to %v", attestor.sink)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("Send attestation to %v failed with status %v", attestor.sink, res.StatusCode)
+	}
+
+	return nil
+}
+
+//attest emits the provenance statement of the change, the log is written before the response, the sink asynchronously
+func (handler *Handler) attest(namespace string, project string, entry *HistoryEntry) {
+	if handler.attestor == nil {
+		return
+	}
+
+	statement := handler.attestor.statement(namespace, project, entry)
+	if err := handler.attestor.Append(statement); err != nil {
+		handler.logger.Error(err)
+	}
+	if handler.attestor.sink == "" {
+		return
+	}
+	handler.deliveries.Add(1)
+	go func() {
+		defer handler.deliveries.Done()
+		if err := handler.attestor.Send(statement); err != nil {
+			handler.logger.Warn(err)
+		}
+	}()
+}
+
+//VerifyAttestations checks the chain of the attestation log and returns the number of statements
+func VerifyAttestations(filename string) (int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Cannot open attestation log %v", filename)
+	}
+	defer file.Close()
+
+	count, previous := 0, ""
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record := attestationRecord{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return count, errors.Wrapf(err, "Invalid attestation %v", count+1)
+		}
+		if record.Index != count+1 || record.Previous != previous {
+			return count, errors.Errorf("Attestation %v does not follow attestation %v", count+1, count)
+		}
+		count++
+		previous = lineHash(line)
+	}
+
+	return count, errors.Wrapf(scanner.Err(), "Cannot read attestation log %v", filename)
+}
+
+func runVerifyAttestations(filename string, out io.Writer) int {
+	count, err := VerifyAttestations(filename)
+	if err != nil {
+		fmt.Fprintf(out, "%v after %v valid statements\n", err, count)
+		return 1
+	}
+
+	fmt.Fprintf(out, "%v statements of %v are valid\n", count, filename)
+	return 0
+}
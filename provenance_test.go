@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Attest_Changes_To_Log(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "attestations")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "attestations.jsonl")
+	attestor, err := NewAttestor("vbump/east", filename, "")
+	Ω.Expect(err).To(BeNil())
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetAttestor(attestor)
+	router := handler.GetRouter()
+
+	for _, path := range []string{"/patch/p1", "/minor/p1"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(200))
+	}
+
+	document, _ := ioutil.ReadFile(filename)
+	lines := strings.Split(strings.TrimSpace(string(document)), "\n")
+	Ω.Expect(lines).To(HaveLen(2))
+	record := attestationRecord{}
+	Ω.Expect(json.Unmarshal([]byte(lines[1]), &record)).To(BeNil())
+	digest := sha256.Sum256([]byte("1.1.0"))
+	Ω.Expect(record.Index).To(Equal(2))
+	Ω.Expect(record.Previous).To(Equal(lineHash([]byte(lines[0]))))
+	Ω.Expect(record.Statement.Type).To(Equal(statementType))
+	Ω.Expect(record.Statement.Subject).To(Equal([]Subject{{Name: "p1", Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])}}}))
+	Ω.Expect(record.Statement.Predicate.Builder.ID).To(Equal("vbump/east"))
+	Ω.Expect(record.Statement.Predicate.Invocation.Parameters.Element).To(Equal("minor"))
+	Ω.Expect(record.Statement.Predicate.Invocation.Parameters.Previous).To(Equal("1.0.1"))
+
+	count, err := VerifyAttestations(filename)
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(count).To(Equal(2))
+}
+
+func Test_Attestation_Log_Continues_Chain(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "attestations")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "attestations.jsonl")
+	entry := &HistoryEntry{Time: time.Now(), Element: "patch", Previous: "1.0.0", Version: "1.0.1"}
+
+	for i := 0; i < 2; i++ {
+		attestor, err := NewAttestor("vbump", filename, "")
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(attestor.Append(attestor.statement("team", "p1", entry))).To(BeNil())
+		attestor.file.Close()
+	}
+
+	count, err := VerifyAttestations(filename)
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(count).To(Equal(2))
+}
+
+func Test_Verify_Changed_Attestation_Log(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "attestations")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "attestations.jsonl")
+	attestor, _ := NewAttestor("vbump", filename, "")
+	for _, version := range []string{"1.0.1", "1.0.2", "1.0.3"} {
+		_ = attestor.Append(attestor.statement("", "p1", &HistoryEntry{Element: "patch", Version: version}))
+	}
+	attestor.file.Close()
+	document, _ := ioutil.ReadFile(filename)
+	_ = ioutil.WriteFile(filename, bytes.Replace(document, []byte(`"version":"1.0.2"`), []byte(`"version":"1.0.9"`), 1), 0644)
+
+	count, err := VerifyAttestations(filename)
+
+	Ω.Expect(err).NotTo(BeNil())
+	Ω.Expect(count).To(Equal(2))
+	var out bytes.Buffer
+	Ω.Expect(runVerifyAttestations(filename, &out)).To(Equal(1))
+}
+
+func Test_Send_Attestation_To_Sink(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	received := make(chan Statement, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statement := Statement{}
+		_ = json.NewDecoder(r.Body).Decode(&statement)
+		received <- statement
+	}))
+	defer server.Close()
+	attestor, _ := NewAttestor("vbump", "", server.URL)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetAttestor(attestor)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/major/p1", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	statement := <-received
+	Ω.Expect(statement.Subject[0].Name).To(Equal("team/p1"))
+	Ω.Expect(statement.Predicate.Invocation.Parameters.Element).To(Equal("major"))
+}
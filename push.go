@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//pushClient forwards pushes to the instances owning some of their projects
+var pushClient = &http.Client{Timeout: 10 * time.Second}
+
+//PushRule bumps the project, if a push changes a file matching the pattern, "**" matches any number of directories
+type PushRule struct {
+	Pattern string
+	Project string
+}
+
+//PushEvent is the part of a github or gitlab push event listing the changed files
+type PushEvent struct {
+	After   string `json:"after"`
+	Commits []struct {
+		Added    []string `json:"added"`
+		Modified []string `json:"modified"`
+		Removed  []string `json:"removed"`
+	} `json:"commits"`
+}
+
+//SetPushRules enables the push receiver bumping the projects of the changed files, pushes are verified with the secret
+func (handler *Handler) SetPushRules(rules map[string]string, secret string) error {
+	handler.pushRules = nil
+	for pattern, project := range rules {
+		if err := validGlob(pattern); err != nil {
+			return err
+		}
+		handler.pushRules = append(handler.pushRules, PushRule{Pattern: pattern, Project: project})
+	}
+	handler.pushSecret = NewSecret(secret)
+
+	return nil
+}
+
+//UsePushSecret verifies pushes with a secret, which is refreshed from its file or vault
+func (handler *Handler) UsePushSecret(secret *Secret) {
+	handler.pushSecret = secret
+}
+
+//validGlob returns an error, if the pattern is malformed
+func validGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return errors.Wrapf(err, "%v is not a valid path pattern", pattern)
+		}
+	}
+
+	return nil
+}
+
+//matchGlob returns true, if the path matches the pattern segment by segment, "**" matches zero or more segments
+func matchGlob(pattern []string, file []string) bool {
+	if len(pattern) == 0 {
+		return len(file) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(file); i++ {
+			if matchGlob(pattern[1:], file[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(file) == 0 {
+		return false
+	}
+	if matches, _ := path.Match(pattern[0], file[0]); !matches {
+		return false
+	}
+
+	return matchGlob(pattern[1:], file[1:])
+}
+
+//Projects returns the projects of all files changed by the push in alphabetical order
+func (event *PushEvent) Projects(rules []PushRule) []string {
+	projects := map[string]bool{}
+	for _, commit := range event.Commits {
+		for _, files := range [][]string{commit.Added, commit.Modified, commit.Removed} {
+			for _, file := range files {
+				for _, rule := range rules {
+					if matchGlob(strings.Split(rule.Pattern, "/"), strings.Split(file, "/")) {
+						projects[rule.Project] = true
+					}
+				}
+			}
+		}
+	}
+
+	names := []string{}
+	for project := range projects {
+		names = append(names, project)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//verifyPush returns an error, if the push is not signed by github or doesn't carry the gitlab token
+func verifyPush(secret string, header http.Header, body []byte) error {
+	if token := header.Get("X-Gitlab-Token"); token != "" {
+		if !hmac.Equal([]byte(token), []byte(secret)) {
+			return errors.New("Invalid gitlab token")
+		}
+		return nil
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Hub-Signature-256"))) {
+		return errors.New("Missing or invalid push signature")
+	}
+
+	return nil
+}
+
+//OnPush is a handler for github and gitlab push events bumping every project with changed files once, the element defaults to patch
+func (handler *Handler) OnPush(context *gin.Context) {
+	body, err := ioutil.ReadAll(context.Request.Body)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Cannot read push event"))
+		return
+	}
+	if secret := handler.pushSecret.Value(); secret != "" {
+		if err := verifyPush(secret, context.Request.Header, body); err != nil {
+			_ = context.AbortWithError(http.StatusUnauthorized, err)
+			return
+		}
+	}
+	event := PushEvent{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid push event"))
+		return
+	}
+
+	element := context.DefaultQuery("element", "patch")
+	if element != "major" && element != "minor" && element != "patch" {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Errorf("%v is not a valid version element", element))
+		return
+	}
+
+	reason := "push"
+	if len(event.After) >= 7 {
+		reason += " " + event.After[:7]
+	}
+	service := handler.version.WithAnnotation(Annotation{Reason: reason, Actor: "push", Client: handler.client(context)})
+
+	// a push forwarded by a peer only bumps the projects of this instance
+	forwarded := handler.forwardedByPeer(context)
+	owners := map[string]bool{}
+	propagated := []string{}
+	bumped := map[string]string{}
+	for _, project := range event.Projects(handler.pushRules) {
+		if owner := handler.foreignOwner("", project); owner != "" {
+			if !forwarded {
+				owners[owner] = true
+			}
+			continue
+		}
+
+		entry, err := service.Bump(project, element)
+		if err != nil {
+			countFailure("", project, "bump", err)
+			_ = context.Error(err)
+			handler.logger.Errorf("cannot bump %v of project %v on push: %v", element, project, err)
+			continue
+		}
+
+		countBump("", project, element)
+		countClientChange(entry)
+		handler.publish("", project, service, entry)
+		handler.auditLog().Infof("bump %v version to %v on project %v on push", element, entry.Version, project)
+		bumped[project] = entry.Version
+		if context.Query("propagate") == "true" {
+			propagated = append(propagated, handler.propagateBump("", project, service, entry, handler.auditLog())...)
+		}
+	}
+
+	for owner := range owners {
+		versions, err := handler.forwardPush(owner, context.Request, body)
+		if err != nil {
+			_ = context.Error(err)
+			handler.logger.Error(err)
+			continue
+		}
+		for project, version := range versions {
+			bumped[project] = version
+		}
+	}
+
+	if len(propagated) > 0 {
+		context.Header("X-Vbump-Propagated", strings.Join(propagated, ","))
+	}
+	context.JSON(http.StatusOK, bumped)
+}
+
+//forwardPush passes the push on to an instance owning some of its projects and returns the versions bumped there
+func (handler *Handler) forwardPush(owner string, request *http.Request, body []byte) (map[string]string, error) {
+	forward, err := http.NewRequest(http.MethodPost, owner+request.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot forward push to %v", owner)
+	}
+	// the owner verifies the signature of the push again
+	forward.Header = request.Header.Clone()
+	forward.Header.Set(shardForwardedHeader, handler.shards.self)
+
+	res, err := pushClient.Do(forward)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot forward push to %v", owner)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, errors.Errorf("Forward push to %v failed with status %v", owner, res.StatusCode)
+	}
+
+	bumped := map[string]string{}
+	if err := json.NewDecoder(res.Body).Decode(&bumped); err != nil {
+		return nil, errors.Wrapf(err, "Invalid response of %v to a forwarded push", owner)
+	}
+
+	return bumped, nil
+}
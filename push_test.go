@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+const pushEvent = `{"after": "abc1234def", "commits": [
+	{"added": ["services/payments/api/handler.go"], "modified": ["README.md"], "removed": []},
+	{"added": [], "modified": ["services/billing/main.go", "services/payments/go.mod"], "removed": ["docs/old.md"]}
+]}`
+
+func pushRequest(secret string, event string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(event))
+	req, _ := http.NewRequest("POST", "/hooks/push", strings.NewReader(event))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func Test_Glob_Matching(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	matches := func(pattern string, file string) bool {
+		return matchGlob(strings.Split(pattern, "/"), strings.Split(file, "/"))
+	}
+
+	Ω.Expect(matches("services/payments/**", "services/payments/api/handler.go")).To(BeTrue())
+	Ω.Expect(matches("services/payments/**", "services/payments")).To(BeTrue())
+	Ω.Expect(matches("**/*.md", "docs/old.md")).To(BeTrue())
+	Ω.Expect(matches("services/*/main.go", "services/billing/main.go")).To(BeTrue())
+	Ω.Expect(matches("services/payments/**", "services/paymentsv2/main.go")).To(BeFalse())
+}
+
+func Test_Push_Bumps_Changed_Projects_Once(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "payments"))
+	_, _ = version.SetVersion("billing", "2.0.0")
+	handler := NewHandler(version, nil)
+	tokens := NewTokenStore()
+	tokens.Add("ci", "t0k3n", "*")
+	handler.SetTokenStore(tokens)
+	_ = handler.SetPushRules(map[string]string{
+		"services/payments/**": "payments",
+		"services/billing/**":  "billing",
+		"frontend/**":          "frontend",
+	}, "s3cr3t")
+
+	res := httptest.NewRecorder()
+	handler.GetRouter().ServeHTTP(res, pushRequest("s3cr3t", pushEvent))
+	history, _ := version.History("payments")
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"payments": "1.0.1", "billing": "2.0.1"}`))
+	Ω.Expect(history[len(history)-1].Reason).To(Equal("push abc1234"))
+	Ω.Expect(history[len(history)-1].Actor).To(Equal("push"))
+}
+
+func Test_Push_Verifies_Signature(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "payments"))
+	handler := NewHandler(version, nil)
+	_ = handler.SetPushRules(map[string]string{"services/payments/**": "payments"}, "s3cr3t")
+
+	forged := httptest.NewRecorder()
+	handler.GetRouter().ServeHTTP(forged, pushRequest("guessed", pushEvent))
+	gitlab := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/hooks/push?element=minor", strings.NewReader(pushEvent))
+	req.Header.Set("X-Gitlab-Token", "s3cr3t")
+	handler.GetRouter().ServeHTTP(gitlab, req)
+	current, _ := version.GetVersion("payments")
+
+	Ω.Expect(forged.Code).To(Equal(http.StatusUnauthorized))
+	Ω.Expect(gitlab.Code).To(Equal(http.StatusOK))
+	Ω.Expect(current).To(Equal("1.1.0"))
+}
+
+func Test_Push_Rules_Are_Validated(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "payments")), nil)
+
+	Ω.Expect(handler.SetPushRules(map[string]string{"services/[payments/**": "payments"}, "")).NotTo(BeNil())
+}
+
+func Test_Push_Bumps_Projects_Of_Other_Shards_On_Their_Owner(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	routers := make([]http.Handler, 2)
+	servers := make([]*httptest.Server, 2)
+	for i := range servers {
+		router := &routers[i]
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { (*router).ServeHTTP(w, r) }))
+		defer servers[i].Close()
+	}
+	shards, _ := NewShardMap(servers[0].URL, []string{servers[1].URL})
+	local, foreign := "", ""
+	for i := 0; local == "" || foreign == ""; i++ {
+		if project := "p" + strconv.Itoa(i); shards.IsLocal(project) {
+			local = project
+		} else {
+			foreign = project
+		}
+	}
+	versions := make([]*Version, 2)
+	for i := range servers {
+		shards, _ := NewShardMap(servers[i].URL, []string{servers[1-i].URL})
+		versions[i] = NewVersion(adapter.NewMock("", ""))
+		handler := NewHandler(versions[i], nil)
+		handler.SetShardMap(shards)
+		_ = handler.SetPushRules(map[string]string{"services/*/**": local, "frontend/**": foreign}, "s3cr3t")
+		routers[i] = handler.GetRouter()
+	}
+	_, _ = versions[0].SetVersion(local, "1.0.0")
+	_, _ = versions[1].SetVersion(foreign, "2.0.0")
+
+	req := pushRequest("s3cr3t", `{"commits": [{"modified": ["services/payments/main.go", "frontend/index.html"]}]}`)
+	req.URL, _ = url.Parse(servers[0].URL + "/hooks/push?element=minor")
+	res, err := http.DefaultClient.Do(req)
+	Ω.Expect(err).To(BeNil())
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	current, _ := versions[1].GetVersion(foreign)
+	exists, _ := versions[0].Exists(foreign)
+
+	Ω.Expect(res.StatusCode).To(Equal(http.StatusOK))
+	Ω.Expect(string(body)).To(MatchJSON(`{"` + local + `": "1.1.0", "` + foreign + `": "2.1.0"}`))
+	Ω.Expect(current).To(Equal("2.1.0"))
+	Ω.Expect(exists).To(BeFalse())
+}
+
+func Test_Push_Propagates_Bumps(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "payments"))
+	_, _ = version.SetVersion("checkout", "2.0.0")
+	_ = version.SetMetadata("payments", &Metadata{Config: Config{Dependents: []string{"checkout"}}})
+	handler := NewHandler(version, nil)
+	_ = handler.SetPushRules(map[string]string{"services/payments/**": "payments"}, "s3cr3t")
+
+	res := httptest.NewRecorder()
+	req := pushRequest("s3cr3t", pushEvent)
+	req.URL.RawQuery = "propagate=true"
+	handler.GetRouter().ServeHTTP(res, req)
+	current, _ := version.GetVersion("checkout")
+
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"payments": "1.0.1"}`))
+	Ω.Expect(res.Header().Get("X-Vbump-Propagated")).To(Equal("checkout=2.0.1"))
+	Ω.Expect(current).To(Equal("2.0.1"))
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+)
+
+//Quota limits what a namespace may allocate, zero means unlimited,
+//a history retention replaces the global retention for the projects of the namespace
+type Quota struct {
+	MaxProjects     int
+	MaxBumpsPerHour int
+	History         HistoryRetention
+}
+
+//Quotas enforces quotas for namespaces
+type Quotas struct {
+	defaults Quota
+	quotas   map[string]Quota
+	mutex    sync.Mutex
+	bumps    map[string][]time.Time
+	creating map[string]map[string]bool
+	now      func() time.Time
+}
+
+//NewQuotas constructs quotas applying the given defaults to every namespace
+func NewQuotas(defaults Quota) *Quotas {
+	return &Quotas{
+		defaults: defaults,
+		quotas:   map[string]Quota{},
+		bumps:    map[string][]time.Time{},
+		creating: map[string]map[string]bool{},
+		now:      time.Now,
+	}
+}
+
+//ParseQuota parses a quota in the form "namespace:maxprojects:maxbumpsperhour[:maxhistoryentries[:maxhistoryage]]"
+func ParseQuota(text string) (string, Quota, error) {
+	parts := strings.Split(text, ":")
+	if len(parts) < 3 || len(parts) > 5 || parts[0] == "" {
+		return "", Quota{}, errors.Errorf("%v is not a valid quota, expected namespace:maxprojects:maxbumpsperhour[:maxhistoryentries[:maxhistoryage]]", text)
+	}
+
+	maxProjects, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", Quota{}, errors.Wrapf(err, "%v is not a valid quota", text)
+	}
+	maxBumps, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", Quota{}, errors.Wrapf(err, "%v is not a valid quota", text)
+	}
+	quota := Quota{MaxProjects: maxProjects, MaxBumpsPerHour: maxBumps}
+	if len(parts) > 3 {
+		if quota.History.MaxEntries, err = strconv.Atoi(parts[3]); err != nil {
+			return "", Quota{}, errors.Wrapf(err, "%v is not a valid quota", text)
+		}
+	}
+	if len(parts) > 4 {
+		if quota.History.MaxAge, err = parseAge(parts[4]); err != nil {
+			return "", Quota{}, errors.Wrapf(err, "%v is not a valid quota", text)
+		}
+	}
+
+	return parts[0], quota, nil
+}
+
+//Set overrides the default quota for a namespace
+func (quotas *Quotas) Set(namespace string, quota Quota) {
+	quotas.quotas[namespace] = quota
+}
+
+//For returns the quota of the given namespace
+func (quotas *Quotas) For(namespace string) Quota {
+	if quota, exists := quotas.quotas[namespace]; exists {
+		return quota
+	}
+
+	return quotas.defaults
+}
+
+//retention returns the history retention of the namespace, limits of its quota replace the global ones
+func (quotas *Quotas) retention(namespace string, global HistoryRetention) HistoryRetention {
+	if quotas == nil || namespace == "" {
+		return global
+	}
+
+	retention := global
+	if history := quotas.For(namespace).History; history.MaxEntries > 0 {
+		retention.MaxEntries = history.MaxEntries
+	}
+	if history := quotas.For(namespace).History; history.MaxAge > 0 {
+		retention.MaxAge = history.MaxAge
+	}
+	return retention
+}
+
+//limitsHistory returns true, if a quota limits the history of a namespace
+func (quotas *Quotas) limitsHistory() bool {
+	if quotas == nil {
+		return false
+	}
+	if quotas.defaults.History.Enabled() {
+		return true
+	}
+	for _, quota := range quotas.quotas {
+		if quota.History.Enabled() {
+			return true
+		}
+	}
+
+	return false
+}
+
+//reserve takes a bump of the namespace and a slot for every given project, which doesn't exist yet, so concurrent changes
+//cannot exceed the quota together, the returned release gives the bump back for a failed change and frees the slots
+func (quotas *Quotas) reserve(namespace string, service *Version, projects ...string) (func(succeeded bool), int, error) {
+	quota := quotas.For(namespace)
+
+	quotas.mutex.Lock()
+	defer quotas.mutex.Unlock()
+
+	now := quotas.now()
+	if quota.MaxBumpsPerHour > 0 {
+		since := now.Add(-time.Hour)
+		recent := quotas.bumps[namespace][:0]
+		for _, bump := range quotas.bumps[namespace] {
+			if bump.After(since) {
+				recent = append(recent, bump)
+			}
+		}
+		quotas.bumps[namespace] = recent
+		if len(recent) >= quota.MaxBumpsPerHour {
+			return nil, http.StatusTooManyRequests, errors.Errorf("Namespace %v exceeded its bumps per hour", namespace)
+		}
+	}
+
+	created := []string{}
+	if quota.MaxProjects > 0 {
+		existing, err := service.Projects()
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		// projects being created by other changes count as existing
+		counted := map[string]bool{}
+		for _, project := range existing {
+			counted[project] = true
+		}
+		for project := range quotas.creating[namespace] {
+			counted[project] = true
+		}
+		for _, project := range projects {
+			exists, err := service.Exists(project)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			if !exists && !counted[project] {
+				created = append(created, project)
+			}
+		}
+		if len(created) > 0 && len(counted)+len(created) > quota.MaxProjects {
+			return nil, http.StatusForbidden, errors.Errorf("Namespace %v exceeded its maximum of %v projects", namespace, quota.MaxProjects)
+		}
+	}
+
+	if quota.MaxBumpsPerHour > 0 {
+		quotas.bumps[namespace] = append(quotas.bumps[namespace], now)
+	}
+	if len(created) > 0 && quotas.creating[namespace] == nil {
+		quotas.creating[namespace] = map[string]bool{}
+	}
+	for _, project := range created {
+		quotas.creating[namespace][project] = true
+	}
+	return func(succeeded bool) {
+		quotas.mutex.Lock()
+		defer quotas.mutex.Unlock()
+
+		// created projects are counted as existing from now on
+		for _, project := range created {
+			delete(quotas.creating[namespace], project)
+		}
+		if !succeeded && quota.MaxBumpsPerHour > 0 {
+			quotas.bumps[namespace] = withoutBump(quotas.bumps[namespace], now)
+		}
+	}, 0, nil
+}
+
+//withoutBump removes one bump at the given time
+func withoutBump(bumps []time.Time, bump time.Time) []time.Time {
+	for i := range bumps {
+		if bumps[i].Equal(bump) {
+			return append(bumps[:i], bumps[i+1:]...)
+		}
+	}
+
+	return bumps
+}
+
+//QuotaMiddleware rejects changes in namespaces exceeding their quota
+func (handler *Handler) QuotaMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, ok := handler.versionFor(c)
+		if !ok {
+			return
+		}
+
+		namespace := c.Param("namespace")
+		projects := []string{c.Param("project")}
+		if c.Param("project") == "" {
+			// a manifest creates all of its projects, an invalid manifest is rejected by the handler
+			manifest := BulkManifest{}
+			_ = c.ShouldBindBodyWith(&manifest, binding.JSON)
+			projects = manifest.Names()
+		}
+		release, status, err := handler.quotas.reserve(namespace, service, projects...)
+		if err != nil {
+			if status == http.StatusTooManyRequests {
+				c.Header("Retry-After", "3600")
+			}
+			_ = c.AbortWithError(status, err)
+			return
+		}
+
+		c.Next()
+		release(c.Writer.Status() < http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+//slowVersions widens the window between reading and storing a version, also in namespaces
+type slowVersions struct {
+	adapter.IFileProvider
+}
+
+func (provider *slowVersions) Namespace(namespace string) (adapter.IFileProvider, error) {
+	namespaced, err := provider.IFileProvider.Namespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &slowVersions{namespaced}, nil
+}
+
+func (provider *slowVersions) ReadVersion(project string) (string, error) {
+	version, err := provider.IFileProvider.ReadVersion(project)
+	time.Sleep(time.Millisecond)
+	return version, err
+}
+
+func newQuotaRouter(quota Quota) (http.Handler, *Quotas) {
+	quotas := NewQuotas(Quota{})
+	quotas.Set("team", quota)
+	handler := NewHandler(NewVersion(adapter.NewMock("", "")), nil)
+	handler.SetQuotas(quotas)
+
+	return handler.GetRouter(), quotas
+}
+
+func Test_Parse_Quota(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	namespace, quota, err := ParseQuota("team:10:100")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(namespace).To(Equal("team"))
+	Ω.Expect(quota).To(Equal(Quota{MaxProjects: 10, MaxBumpsPerHour: 100}))
+}
+
+func Test_Parse_Quota_With_History_Retention(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	_, quota, err := ParseQuota("team:10:100:50:30d")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(quota.History).To(Equal(HistoryRetention{MaxEntries: 50, MaxAge: 30 * 24 * time.Hour}))
+}
+
+func Test_Parse_Invalid_Quota(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	_, _, err := ParseQuota("team:10")
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Quota_Max_Projects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router, _ := newQuotaRouter(Quota{MaxProjects: 1})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ns/team/patch/p2", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ns/other/patch/p2", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+}
+
+func Test_Quota_Max_Bumps_Per_Hour(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router, quotas := newQuotaRouter(Quota{MaxBumpsPerHour: 2})
+	now := time.Now()
+	quotas.now = func() time.Time { return now }
+
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/ns/team/patch/p1", nil)
+		router.ServeHTTP(res, req)
+		codes = append(codes, res.Code)
+	}
+	Ω.Expect(codes).To(Equal([]int{200, 200, 429}))
+
+	now = now.Add(time.Hour + time.Second)
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+}
+
+func Test_Quota_Ignores_Requests_Not_Changing_Versions(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router, _ := newQuotaRouter(Quota{MaxBumpsPerHour: 1})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ns/team/archive/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(204))
+}
+
+func Test_Quota_Max_Projects_Counts_Bulk_Manifest(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router, _ := newQuotaRouter(Quota{MaxProjects: 2})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ns/team/projects/bulk", bytes.NewBufferString(`{"projects": [{"name": "p1"}, {"name": "p2"}, {"name": "p3"}]}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ns/team/projects/bulk", bytes.NewBufferString(`{"projects": [{"name": "p1"}, {"name": "p2"}]}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`"created":1`))
+}
+
+func Test_Quota_Holds_For_Concurrent_Requests(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	quotas := NewQuotas(Quota{})
+	quotas.Set("team", Quota{MaxProjects: 3, MaxBumpsPerHour: 5})
+	handler := NewHandler(NewVersion(&slowVersions{adapter.New(basePath)}), nil)
+	handler.SetQuotas(quotas)
+	router := handler.GetRouter()
+
+	codes := make(chan int, 20)
+	requests := sync.WaitGroup{}
+	for r := 0; r < 20; r++ {
+		requests.Add(1)
+		go func(r int) {
+			defer requests.Done()
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/ns/team/patch/p%v", r), nil)
+			router.ServeHTTP(res, req)
+			codes <- res.Code
+		}(r)
+	}
+	requests.Wait()
+	close(codes)
+
+	succeeded := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			succeeded++
+		}
+	}
+	Ω.Expect(succeeded).To(Equal(3))
+}
+
+func Test_Quota_Gives_Back_Failed_Bumps(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router, _ := newQuotaRouter(Quota{MaxBumpsPerHour: 1})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ns/team/version/p1/invalid", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(BeNumerically(">=", 400))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/ns/team/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+}
+
+func Test_Quota_Counts_Builds_And_Hotfixes(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router, _ := newQuotaRouter(Quota{MaxBumpsPerHour: 2})
+
+	for _, path := range []string{"/ns/team/patch/p1", "/ns/team/channel/p1/beta", "/ns/team/hotfix/p1/0.0.1", "/ns/team/reserve/patch/p1"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+		if path == "/ns/team/patch/p1" || path == "/ns/team/channel/p1/beta" {
+			Ω.Expect(res.Code).To(Equal(200), path)
+			continue
+		}
+		Ω.Expect(res.Code).To(Equal(429), path)
+	}
+}
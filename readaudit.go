@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+//readKey identifies the reads of a project by an actor from a client
+type readKey struct {
+	namespace string
+	project   string
+	actor     string
+	client    string
+}
+
+//ReadAudit counts the reads of projects by actor and writes them aggregated to the audit log every interval
+type ReadAudit struct {
+	mutex  sync.Mutex
+	counts map[readKey]int
+	since  time.Time
+	now    func() time.Time
+}
+
+//NewReadAudit constructs an empty read audit
+func NewReadAudit() *ReadAudit {
+	return &ReadAudit{counts: map[readKey]int{}, since: time.Now(), now: time.Now}
+}
+
+//SetReadAudit audits the reads of projects in addition to the changes
+func (handler *Handler) SetReadAudit(reads *ReadAudit) {
+	handler.reads = reads
+}
+
+//Count records a read
+func (reads *ReadAudit) Count(key readKey) {
+	reads.mutex.Lock()
+	defer reads.mutex.Unlock()
+	reads.counts[key]++
+}
+
+//Flush writes one audit entry per project, actor and client with the number of reads since the last flush
+func (reads *ReadAudit) Flush(audit *log.Logger) int {
+	reads.mutex.Lock()
+	counts, since, until := reads.counts, reads.since, reads.now()
+	reads.counts, reads.since = map[readKey]int{}, until
+	reads.mutex.Unlock()
+
+	keys := make([]readKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].namespace+"/"+keys[i].project != keys[j].namespace+"/"+keys[j].project {
+			return keys[i].namespace+"/"+keys[i].project < keys[j].namespace+"/"+keys[j].project
+		}
+		return keys[i].actor+"/"+keys[i].client < keys[j].actor+"/"+keys[j].client
+	})
+
+	for _, key := range keys {
+		fields := log.Fields{"reads": counts[key], "since": since.UTC(), "until": until.UTC()}
+		if key.namespace != "" {
+			fields["namespace"] = key.namespace
+		}
+		if key.actor != "" {
+			fields["actor"] = key.actor
+		}
+		if key.client != "" {
+			fields["client"] = key.client
+		}
+		audit.WithFields(fields).Infof("read project %v %v times", key.project, counts[key])
+	}
+
+	return len(keys)
+}
+
+//StartReadAudit writes the aggregated reads to the audit log every interval
+func (handler *Handler) StartReadAudit(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			handler.reads.Flush(handler.auditLog())
+		}
+	}()
+}
+
+//ReadAuditMiddleware counts the successful reads of projects
+func (handler *Handler) ReadAuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method != http.MethodGet || c.Param("project") == "" || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		handler.reads.Count(readKey{namespace: c.Param("namespace"), project: c.Param("project"), actor: actor(c), client: handler.client(c)})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+func Test_Reads_Are_Audited_Aggregated(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	audited := &bytes.Buffer{}
+	audit := log.New()
+	audit.Out = audited
+	audit.Formatter = &log.JSONFormatter{}
+	tokens := NewTokenStore()
+	tokens.Add("ci", "ci-secret", "*")
+	tokens.Add("deploy", "deploy-secret", "*")
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetTokenStore(tokens)
+	handler.SetAuditLogger(audit)
+	reads := NewReadAudit()
+	handler.SetReadAudit(reads)
+	router := handler.GetRouter()
+
+	for _, request := range []struct{ path, token string }{
+		{"/version/p1", "ci-secret"},
+		{"/version/p1", "ci-secret"},
+		{"/history/p1", "ci-secret"},
+		{"/version/p1", "deploy-secret"},
+		{"/ns/team/version/p1", "deploy-secret"},
+		{"/version/unknown", "deploy-secret"},
+		{"/projects", "deploy-secret"},
+	} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", request.path, nil)
+		req.Header.Set("Authorization", "Bearer "+request.token)
+		router.ServeHTTP(res, req)
+	}
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	req.Header.Set("Authorization", "Bearer ci-secret")
+	router.ServeHTTP(res, req)
+	audited.Reset()
+
+	Ω.Expect(reads.Flush(audit)).To(Equal(4))
+
+	entries := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(audited.String()), "\n") {
+		entry := map[string]interface{}{}
+		Ω.Expect(json.Unmarshal([]byte(line), &entry)).To(BeNil())
+		entries = append(entries, entry)
+	}
+	Ω.Expect(entries[0]["msg"]).To(Equal("read project p1 3 times"))
+	Ω.Expect(entries[0]["actor"]).To(Equal("ci"))
+	Ω.Expect(entries[1]["actor"]).To(Equal("deploy"))
+	Ω.Expect(entries[1]["reads"]).To(BeNumerically("==", 1))
+	Ω.Expect(entries[2]["msg"]).To(Equal("read project unknown 1 times"))
+	Ω.Expect(entries[3]["namespace"]).To(Equal("team"))
+
+	audited.Reset()
+	Ω.Expect(reads.Flush(audit)).To(Equal(0))
+	Ω.Expect(audited.String()).To(BeEmpty())
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var (
+	//ErrNoVersionField is returned, if a manifest has no version field to replace
+	ErrNoVersionField = errors.New("Manifest has no version field")
+
+	validField  = regexp.MustCompile("^[A-Za-z][A-Za-z0-9_-]*$")
+	tomlTable   = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+	plainNumber = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+//tomlVersionTables are the tables of a pyproject.toml with the version of the package
+var tomlVersionTables = map[string]bool{"project": true, "tool.poetry": true}
+
+//manifestRenderers replace the top level version field of a json (package.json), yaml (Chart.yaml) or toml (pyproject.toml) manifest by content type, keeping its formatting
+var manifestRenderers = map[string]func(manifest []byte, field string, version string) ([]byte, error){
+	"application/json":   renderJSON,
+	"application/yaml":   renderYAML,
+	"application/x-yaml": renderYAML,
+	"text/yaml":          renderYAML,
+	"application/toml":   renderTOML,
+	"text/toml":          renderTOML,
+}
+
+func renderJSON(manifest []byte, field string, version string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(manifest))
+	depth, key := 0, true
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, ErrNoVersionField
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid json manifest")
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			key = token == json.Delim('{')
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+			// after a nested value the next token of the enclosing object is a key again
+			key = true
+			continue
+		}
+		if depth == 1 && key && token == field {
+			start := decoder.InputOffset()
+			value, err := decoder.Token()
+			if _, isString := value.(string); err != nil || !isString {
+				return nil, errors.Errorf("Field %v of the json manifest is not a string", field)
+			}
+			end := decoder.InputOffset()
+			quoted, _ := json.Marshal(version)
+			// the value starts behind the colon and maybe some spaces
+			separator := bytes.IndexByte(manifest[start:end], '"')
+			return concat(manifest[:int(start)+separator], quoted, manifest[end:]), nil
+		}
+		if depth == 1 {
+			key = !key
+		}
+	}
+}
+
+func renderYAML(manifest []byte, field string, version string) ([]byte, error) {
+	line := regexp.MustCompile(`(?m)^(` + field + `:[ \t]*)("[^"\n]*"|'[^'\n]*'|[^\s#]*)`)
+	match := line.FindSubmatchIndex(manifest)
+	if match == nil {
+		return nil, ErrNoVersionField
+	}
+
+	value := quoteLike(string(manifest[match[4]:match[5]]), version)
+	return concat(manifest[:match[3]], []byte(value), manifest[match[5]:]), nil
+}
+
+func renderTOML(manifest []byte, field string, version string) ([]byte, error) {
+	line := regexp.MustCompile(`^(\s*` + field + `\s*=\s*)("[^"]*"|'[^']*')`)
+	lines := strings.SplitAfter(string(manifest), "\n")
+	table := ""
+	for i, text := range lines {
+		if match := tomlTable.FindStringSubmatch(text); match != nil {
+			table = strings.TrimSpace(match[1])
+			continue
+		}
+		if match := line.FindStringSubmatchIndex(text); match != nil && tomlVersionTables[table] {
+			lines[i] = text[:match[3]] + quoteLike(text[match[4]:match[5]], version) + text[match[5]:]
+			return []byte(strings.Join(lines, "")), nil
+		}
+	}
+
+	return nil, ErrNoVersionField
+}
+
+//quoteLike quotes the version like the value it replaces, plain values are only quoted if they would read as a number
+func quoteLike(value string, version string) string {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		return "'" + version + "'"
+	case strings.HasPrefix(value, "\""), plainNumber.MatchString(version):
+		return strconv.Quote(version)
+	}
+
+	return version
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+//OnRender is a handler for replacing the version field of a manifest in the body by the current version of a given project
+func (handler *Handler) OnRender(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	field := context.DefaultQuery("field", "version")
+	if !validField.MatchString(field) {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Errorf("%v is not a valid field", field))
+		return
+	}
+
+	version, err := service.GetVersion(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if version == "" {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No version for project %v", projectKey(context)))
+		return
+	}
+
+	render, exists := manifestRenderers[context.ContentType()]
+	if !exists {
+		_ = context.AbortWithError(http.StatusUnsupportedMediaType, errors.Errorf("Cannot render manifests of type %v, use application/json, application/yaml or application/toml", context.ContentType()))
+		return
+	}
+
+	manifest, err := ioutil.ReadAll(context.Request.Body)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Cannot read manifest"))
+		return
+	}
+
+	rendered, err := render(manifest, field, version)
+	if err == ErrNoVersionField {
+		_ = context.AbortWithError(http.StatusUnprocessableEntity, errors.Errorf("Manifest has no %v field", field))
+		return
+	}
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	handler.logger.Infof("render %v of project %v into manifest", version, projectKey(context))
+	context.Data(http.StatusOK, context.GetHeader("Content-Type"), rendered)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Render_Package_JSON(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	manifest := `{
+  "name": "myservice",
+  "dependencies": {"version": "1.0.0"},
+  "scripts": [{"version": "x"}],
+  "version" : "0.0.0",
+  "private": true
+}`
+
+	rendered, err := renderJSON([]byte(manifest), "version", "1.4.2")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(string(rendered)).To(Equal(strings.Replace(manifest, `"version" : "0.0.0"`, `"version" : "1.4.2"`, 1)))
+}
+
+func Test_Render_Chart_YAML(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	manifest := "apiVersion: v2\nname: myservice\n  version: nested\nversion: 0.1.0 # chart version\nappVersion: \"1.0\"\n"
+
+	version, err := renderYAML([]byte(manifest), "version", "1.4.2")
+	appVersion, _ := renderYAML([]byte(manifest), "appVersion", "1.4")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(string(version)).To(Equal("apiVersion: v2\nname: myservice\n  version: nested\nversion: 1.4.2 # chart version\nappVersion: \"1.0\"\n"))
+	Ω.Expect(string(appVersion)).To(HaveSuffix("appVersion: \"1.4\"\n"))
+}
+
+func Test_Render_Pyproject_TOML(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	manifest := "[build-system]\nversion = \"ignored\"\n\n[tool.poetry]\nname = \"myservice\"\nversion = '0.1.0'\n"
+
+	rendered, err := renderTOML([]byte(manifest), "version", "1.4.2")
+	_, missing := renderTOML([]byte("[build-system]\nversion = \"1\"\n"), "version", "1.4.2")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(string(rendered)).To(Equal("[build-system]\nversion = \"ignored\"\n\n[tool.poetry]\nname = \"myservice\"\nversion = '1.4.2'\n"))
+	Ω.Expect(missing).To(Equal(ErrNoVersionField))
+}
+
+func Test_Render_Route(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.4.2", "myservice")), nil)
+	render := func(contentType string, manifest string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/render/myservice", strings.NewReader(manifest))
+		req.Header.Set("Content-Type", contentType)
+		handler.GetRouter().ServeHTTP(res, req)
+		return res
+	}
+
+	rendered := render("application/json", `{"version": "0.0.0"}`)
+	missing := render("application/yaml", "name: myservice\n")
+	invalid := render("application/json", `{"version": `)
+	unsupported := render("text/plain", "version=0.0.0")
+
+	Ω.Expect(rendered.Code).To(Equal(http.StatusOK))
+	Ω.Expect(rendered.Body.String()).To(Equal(`{"version": "1.4.2"}`))
+	Ω.Expect(missing.Code).To(Equal(http.StatusUnprocessableEntity))
+	Ω.Expect(invalid.Code).To(Equal(http.StatusBadRequest))
+	Ω.Expect(unsupported.Code).To(Equal(http.StatusUnsupportedMediaType))
+}
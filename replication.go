@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	replicateElement = "replicate"
+	//conflictElement is the element of events about concurrent changes in both regions
+	conflictElement = "replication-conflict"
+)
+
+//ReplicatedChange is a change of a project sent to the peer region, previous is the version it was changed from
+type ReplicatedChange struct {
+	Namespace string    `json:"namespace,omitempty"`
+	Project   string    `json:"project"`
+	Previous  string    `json:"previous"`
+	Version   string    `json:"version"`
+	Region    string    `json:"region"`
+	Time      time.Time `json:"time"`
+}
+
+//ReplicationResult is the version of the project after applying a replicated change
+type ReplicationResult struct {
+	Version  string `json:"version"`
+	Applied  bool   `json:"applied"`
+	Conflict bool   `json:"conflict"`
+}
+
+//Replicator sends changes asynchronously to the vbump instance of the peer region, failed changes are sent again
+type Replicator struct {
+	mutex   sync.Mutex
+	peer    string
+	token   *Secret
+	region  string
+	client  *http.Client
+	logger  *log.Logger
+	pending map[string]ReplicatedChange
+	wake    chan struct{}
+}
+
+//NewReplicator constructs a replicator sending to the base url of the peer with an admin token
+func NewReplicator(peer string, token *Secret, region string, logger *log.Logger) *Replicator {
+	if logger == nil {
+		logger = log.New()
+	}
+
+	return &Replicator{
+		peer:    strings.TrimSuffix(peer, "/"),
+		token:   token,
+		region:  region,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		logger:  logger,
+		pending: map[string]ReplicatedChange{},
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+//SetReplicator enables replication of all changes to the peer region
+func (handler *Handler) SetReplicator(replicator *Replicator) {
+	handler.replicator = replicator
+}
+
+//Enqueue queues the change for the peer, pending changes of the same project are merged into one
+func (replicator *Replicator) Enqueue(namespace string, project string, entry *HistoryEntry) {
+	// replicated changes are not sent back
+	if entry.Element == replicateElement {
+		return
+	}
+
+	key := namespace + "/" + project
+	replicator.mutex.Lock()
+	change, exists := replicator.pending[key]
+	if !exists {
+		change = ReplicatedChange{Namespace: namespace, Project: project, Previous: entry.Previous, Region: replicator.region}
+	}
+	change.Version, change.Time = entry.Version, entry.Time
+	replicator.pending[key] = change
+	replicator.mutex.Unlock()
+
+	select {
+	case replicator.wake <- struct{}{}:
+	default:
+	}
+}
+
+//Start sends the pending changes, as soon as they are queued and retries failed ones every interval
+func (replicator *Replicator) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-replicator.wake:
+			case <-ticker.C:
+			}
+			replicator.Flush()
+		}
+	}()
+}
+
+//Flush sends all pending changes and returns the number of changes, which failed
+func (replicator *Replicator) Flush() int {
+	replicator.mutex.Lock()
+	changes := make([]ReplicatedChange, 0, len(replicator.pending))
+	for _, change := range replicator.pending {
+		changes = append(changes, change)
+	}
+	replicator.mutex.Unlock()
+
+	failed := 0
+	for _, change := range changes {
+		if err := replicator.send(change); err != nil {
+			replicatedChanges.With(prometheus.Labels{"result": "failed"}).Inc()
+			replicator.logger.Warn(err)
+			failed++
+			continue
+		}
+		replicatedChanges.With(prometheus.Labels{"result": "sent"}).Inc()
+
+		replicator.mutex.Lock()
+		key := change.Namespace + "/" + change.Project
+		// the project may have changed again while sending
+		if replicator.pending[key].Version == change.Version {
+			delete(replicator.pending, key)
+		}
+		replicator.mutex.Unlock()
+	}
+
+	return failed
+}
+
+func (replicator *Replicator) send(change ReplicatedChange) error {
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot replicate project %v", change.Project)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, replicator.peer+"/admin/replicate", bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrapf(err, "Cannot replicate project %v", change.Project)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if token := replicator.token.Value(); token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := replicator.client.Do(request)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot replicate project %v to %v", change.Project, replicator.peer)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("Replicate project %v to %v failed with status %v", change.Project, replicator.peer, res.StatusCode)
+	}
+
+	return nil
+}
+
+//winner returns the higher of both versions by release, then by prerelease, then by text, so both regions pick the same
+func winner(a string, b string) string {
+	releaseA, errA := parseSemver(releaseOf(a))
+	releaseB, errB := parseSemver(releaseOf(b))
+	if errA == nil && errB == nil {
+		compared := releaseA.compare(releaseB)
+		if compared == 0 {
+			compared = comparePrerelease(prereleaseOf(a), prereleaseOf(b))
+		}
+		if compared > 0 {
+			return a
+		}
+		if compared < 0 {
+			return b
+		}
+	}
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+//Replicate applies a change of the peer region, if the project changed here as well, the highest version wins, an empty version deletes the project
+func (v *Version) Replicate(change ReplicatedChange) (*ReplicationResult, error) {
+	unlock, err := v.locks.Lock(v.namespace + "/" + change.Project)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	current, err := v.readLockedForChange(change.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	// both regions handing out the same version from the same previous one is a conflict as well
+	conflict := current != change.Previous && current != change.Version || current == change.Version && v.changedHereAlike(change)
+	result := &ReplicationResult{Version: current, Conflict: current != "" && conflict}
+	// without a conflict the change is applied as it is, even if it lowers the version
+	next := change.Version
+	if current != "" && conflict {
+		next = winner(current, change.Version)
+	}
+	if next == current {
+		return result, nil
+	}
+	if next == "" {
+		if _, err := v.bury(change.Project, current); err != nil {
+			return nil, err
+		}
+		result.Version, result.Applied = next, true
+		return result, nil
+	}
+
+	if err := v.fileProvider.StoreVersion(change.Project, next); err != nil {
+		return nil, errors.Wrapf(err, "Cannot replicate project %v", change.Project)
+	}
+	if err := v.touch(change.Project, true); err != nil {
+		return nil, err
+	}
+	entry := HistoryEntry{
+		Time:     v.now().UTC(),
+		Element:  replicateElement,
+		Previous: current,
+		Version:  next,
+		Reason:   "replicated from " + change.Region,
+		Actor:    v.annotation.Actor,
+		Epoch:    v.epochOf(change.Project, replicateElement),
+	}
+	if err := v.recordHistory(change.Project, entry); err != nil {
+		return nil, err
+	}
+
+	result.Version, result.Applied = next, true
+	return result, nil
+}
+
+//changedHereAlike returns true, if the last change of the project here made the same change as the peer
+func (v *Version) changedHereAlike(change ReplicatedChange) bool {
+	history, err := v.History(change.Project)
+	if err != nil || len(history) == 0 {
+		return false
+	}
+
+	last := history[len(history)-1]
+	return last.Element != replicateElement && last.Previous == change.Previous && last.Version == change.Version
+}
+
+//OnReplicate is a handler for applying a change of the peer region, conflicts are logged and published as events
+func (handler *Handler) OnReplicate(context *gin.Context) {
+	change := ReplicatedChange{}
+	if err := context.ShouldBindBodyWith(&change, binding.JSON); err != nil || change.Project == "" || change.Version != "" && !validateVersion(releaseOf(change.Version)) {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Errorf("Invalid replicated change %v", err))
+		return
+	}
+
+	service := handler.version
+	if change.Namespace != "" {
+		namespaced, err := handler.version.Namespace(change.Namespace)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		service = namespaced
+	}
+
+	result, err := service.Replicate(change)
+	if err != nil {
+		_ = context.AbortWithError(changeStatus(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	if result.Applied {
+		replicatedChanges.With(prometheus.Labels{"result": "applied"}).Inc()
+		handler.auditLog().Infof("replicated version %v of project %v from region %v", result.Version, change.Project, change.Region)
+	}
+	if result.Conflict {
+		replicatedChanges.With(prometheus.Labels{"result": "conflict"}).Inc()
+		handler.logger.Warnf("replication conflict on project %v, version %v of region %v was changed here as well, %v wins", change.Project, change.Version, change.Region, result.Version)
+		// the event carries the version of the peer as previous and the winning version
+		handler.events.Publish(Event{
+			Namespace: change.Namespace,
+			Project:   change.Project,
+			Element:   conflictElement,
+			Previous:  change.Version,
+			Version:   result.Version,
+			Reason:    "concurrent change in region " + change.Region,
+			Time:      time.Now().UTC(),
+		})
+	}
+	context.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func replicatedRegion(version string) (*Handler, *httptest.Server) {
+	tokens := NewTokenStore()
+	tokens.Add("peer", "secret", "*")
+	handler := NewHandler(NewVersion(adapter.NewMock(version, "p1")), nil)
+	handler.SetTokenStore(tokens)
+	return handler, httptest.NewServer(handler.GetRouter())
+}
+
+func bumpIn(server *httptest.Server, path string) string {
+	req, _ := http.NewRequest("POST", server.URL+path, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	defer res.Body.Close()
+	return res.Status
+}
+
+func Test_Replicate_Changes_To_Peer(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	east, eastServer := replicatedRegion("1.0.0")
+	defer eastServer.Close()
+	west, westServer := replicatedRegion("1.0.0")
+	defer westServer.Close()
+	east.SetReplicator(NewReplicator(westServer.URL, NewSecret("secret"), "east", nil))
+
+	Ω.Expect(bumpIn(eastServer, "/minor/p1")).To(Equal("200 OK"))
+	Ω.Expect(bumpIn(eastServer, "/patch/p1")).To(Equal("200 OK"))
+	Ω.Expect(bumpIn(eastServer, "/ns/team/major/p2")).To(Equal("200 OK"))
+	Ω.Expect(east.replicator.Flush()).To(Equal(0))
+
+	version, _ := west.version.GetVersion("p1")
+	Ω.Expect(version).To(Equal("1.1.1"))
+	history, _ := west.version.History("p1")
+	Ω.Expect(history).To(HaveLen(1))
+	Ω.Expect(history[0].Element).To(Equal("replicate"))
+	Ω.Expect(history[0].Previous).To(Equal("1.0.0"))
+	Ω.Expect(history[0].Reason).To(Equal("replicated from east"))
+	eastNamespace, _ := east.version.Namespace("team")
+	westNamespace, _ := west.version.Namespace("team")
+	expected, _ := eastNamespace.GetVersion("p2")
+	version, _ = westNamespace.GetVersion("p2")
+	Ω.Expect(version).NotTo(BeEmpty())
+	Ω.Expect(version).To(Equal(expected))
+}
+
+func Test_Highest_Version_Wins_Replication_Conflicts(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	east, eastServer := replicatedRegion("1.0.0")
+	defer eastServer.Close()
+	west, westServer := replicatedRegion("1.0.0")
+	defer westServer.Close()
+	east.SetReplicator(NewReplicator(westServer.URL, NewSecret("secret"), "east", nil))
+	west.SetReplicator(NewReplicator(eastServer.URL, NewSecret("secret"), "west", nil))
+	events := west.events.Subscribe()
+	defer west.events.Unsubscribe(events)
+
+	Ω.Expect(bumpIn(eastServer, "/minor/p1")).To(Equal("200 OK"))
+	Ω.Expect(bumpIn(westServer, "/patch/p1")).To(Equal("200 OK"))
+	Ω.Expect(east.replicator.Flush()).To(Equal(0))
+	Ω.Expect(west.replicator.Flush()).To(Equal(0))
+
+	eastVersion, _ := east.version.GetVersion("p1")
+	westVersion, _ := west.version.GetVersion("p1")
+	Ω.Expect(eastVersion).To(Equal("1.1.0"))
+	Ω.Expect(westVersion).To(Equal("1.1.0"))
+	conflict := Event{}
+	Ω.Eventually(events).Should(Receive(&conflict))
+	Ω.Expect(conflict.Element).To(Equal("patch"))
+	Ω.Eventually(events).Should(Receive(&conflict))
+	Ω.Expect(conflict.Element).To(Equal("replication-conflict"))
+	Ω.Expect(conflict.Previous).To(Equal("1.1.0"))
+	Ω.Expect(conflict.Version).To(Equal("1.1.0"))
+	Ω.Expect(conflict.Reason).To(Equal("concurrent change in region east"))
+}
+
+func Test_Same_Version_In_Both_Regions_Is_A_Replication_Conflict(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	east, eastServer := replicatedRegion("1.0.0")
+	defer eastServer.Close()
+	west, westServer := replicatedRegion("1.0.0")
+	defer westServer.Close()
+	east.SetReplicator(NewReplicator(westServer.URL, NewSecret("secret"), "east", nil))
+	events := west.events.Subscribe()
+	defer west.events.Unsubscribe(events)
+
+	Ω.Expect(bumpIn(eastServer, "/patch/p1")).To(Equal("200 OK"))
+	Ω.Expect(bumpIn(westServer, "/patch/p1")).To(Equal("200 OK"))
+	Ω.Expect(east.replicator.Flush()).To(Equal(0))
+
+	westVersion, _ := west.version.GetVersion("p1")
+	Ω.Expect(westVersion).To(Equal("1.0.1"))
+	conflict := Event{}
+	Ω.Eventually(events).Should(Receive(&conflict))
+	Ω.Expect(conflict.Element).To(Equal("patch"))
+	Ω.Eventually(events).Should(Receive(&conflict))
+	Ω.Expect(conflict.Element).To(Equal("replication-conflict"))
+	Ω.Expect(conflict.Version).To(Equal("1.0.1"))
+}
+
+func Test_Prerelease_Loses_Against_Its_Release(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(winner("1.4.0-rc.1", "1.4.0")).To(Equal("1.4.0"))
+	Ω.Expect(winner("1.4.0", "1.4.0-rc.1")).To(Equal("1.4.0"))
+	Ω.Expect(winner("1.4.0-rc.9", "1.4.0-rc.10")).To(Equal("1.4.0-rc.10"))
+	Ω.Expect(winner("1.4.0-rc.1", "1.3.9")).To(Equal("1.4.0-rc.1"))
+}
+
+func Test_Replicate_Lower_Versions_Without_Conflict(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("2.0.0", "p1"))
+
+	result, err := version.Replicate(ReplicatedChange{Project: "p1", Previous: "2.0.0", Version: "1.9.0", Region: "east"})
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(result.Applied).To(BeTrue())
+	Ω.Expect(result.Conflict).To(BeFalse())
+	Ω.Expect(current).To(Equal("1.9.0"))
+}
+
+func Test_Replicate_Deleted_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	east, eastServer := replicatedRegion("1.0.0")
+	defer eastServer.Close()
+	west, westServer := replicatedRegion("1.0.0")
+	defer westServer.Close()
+	east.SetReplicator(NewReplicator(westServer.URL, NewSecret("secret"), "east", nil))
+
+	req, _ := http.NewRequest("DELETE", eastServer.URL+"/version/p1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := http.DefaultClient.Do(req)
+	Ω.Expect(err).To(BeNil())
+	res.Body.Close()
+	Ω.Expect(res.StatusCode).To(Equal(http.StatusOK))
+	Ω.Expect(east.replicator.Flush()).To(Equal(0))
+
+	version, _ := west.version.GetVersion("p1")
+	tombstone, _ := west.version.Tombstone("p1")
+	Ω.Expect(version).To(BeEmpty())
+	Ω.Expect(tombstone).NotTo(BeNil())
+	Ω.Expect(tombstone.Version).To(Equal("1.0.0"))
+}
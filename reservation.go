@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	reservationDocument   = "reservation"
+	defaultReservationTTL = 15 * time.Minute
+)
+
+var (
+	//ErrNoReservation is returned when confirming a version without an active reservation
+	ErrNoReservation = newError(ErrNotFound, "version is not reserved")
+	//ErrReservationOutdated is returned when confirming a reservation not higher than the current version
+	ErrReservationOutdated = newError(ErrConflict, "reserved version is not higher than the current version")
+)
+
+//Reservation holds a version of a project until it is confirmed or expires
+type Reservation struct {
+	Version string    `json:"version"`
+	Element string    `json:"element"`
+	Expires time.Time `json:"expires"`
+	Actor   string    `json:"actor,omitempty"`
+}
+
+//Reservations returns the active reservations of the given project, oldest first
+func (v *Version) Reservations(project string) ([]Reservation, error) {
+	document, err := v.fileProvider.ReadDocument(reservationDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get reservations for project %v", project)
+	}
+
+	reservations, active := []Reservation{}, []Reservation{}
+	if document != nil {
+		if err := json.Unmarshal(document, &reservations); err != nil {
+			return nil, errors.Wrapf(err, "Cannot parse reservations for project %v", project)
+		}
+	}
+	for _, reservation := range reservations {
+		if reservation.Expires.After(v.now()) {
+			active = append(active, reservation)
+		}
+	}
+
+	return active, nil
+}
+
+//Reserve holds the next version of the element for the given project for the ttl without changing the project
+func (v *Version) Reserve(project string, element string, ttl time.Duration) (*Reservation, error) {
+	// reservations are handed out apart from the changes of the project, but one after the other
+	unlock, err := v.locks.Lock(v.namespace + "/" + project + "/" + reservationDocument)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	current, err := v.readForChange(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot reserve %v version on project %v", element, project)
+	}
+	if current == "" && !v.mayCreate(element) {
+		return nil, errors.Wrapf(ErrUnknownProject, "Cannot reserve %v version on project %v", element, project)
+	}
+	if err := checkStoredVersion(project, current); err != nil {
+		return nil, errors.Wrapf(err, "Cannot reserve %v version on project %v", element, project)
+	}
+
+	reservations, err := v.Reservations(project)
+	if err != nil {
+		return nil, err
+	}
+
+	gapFree, err := v.isGapFree(project)
+	if err != nil {
+		return nil, err
+	}
+	if gapFree && len(reservations) > 0 {
+		return nil, violate("gapFree", "Project %v has the pending reservation %v", project, reservations[0].Version)
+	}
+
+	// reservations are handed out in order, so the last one is the highest
+	base := current
+	if len(reservations) > 0 {
+		base = reservations[len(reservations)-1].Version
+	}
+	next, err := nextVersion(base, element, 1)
+	if err != nil {
+		return nil, err
+	}
+	if initial := v.initialVersionOf(project); base == "" && initial != "" {
+		next = initial
+	}
+	if err := v.checkPolicy(project, element, current, next); err != nil {
+		return nil, errors.Wrapf(err, "Cannot reserve %v version on project %v", element, project)
+	}
+
+	reservation := Reservation{Version: next, Element: element, Expires: v.now().Add(ttl).UTC(), Actor: v.annotation.Actor}
+	if err := v.storeReservations(project, append(reservations, reservation)); err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+//Confirm sets the reserved version on the given project and records the change
+func (v *Version) Confirm(project string, version string) (*HistoryEntry, error) {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project + "/" + reservationDocument)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	reservations, err := v.Reservations(project)
+	if err != nil {
+		return nil, err
+	}
+
+	index := reservationIndex(reservations, version)
+	if index < 0 {
+		return nil, errors.Wrapf(ErrNoReservation, "Cannot confirm version %v for project %v", version, project)
+	}
+
+	entry, err := v.change(project, reservations[index].Element, func(current string) (string, error) {
+		reserved, err := parseSemver(version)
+		if err != nil {
+			return "", err
+		}
+		if parsed, err := parseSemver(current); err == nil && reserved.compare(parsed) <= 0 {
+			return "", ErrReservationOutdated
+		}
+		return version, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot confirm version %v for project %v", version, project)
+	}
+
+	return entry, v.storeReservations(project, append(reservations[:index], reservations[index+1:]...))
+}
+
+//Release gives up the reservation of the version for the given project
+func (v *Version) Release(project string, version string) error {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project + "/" + reservationDocument)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	reservations, err := v.Reservations(project)
+	if err != nil {
+		return err
+	}
+
+	index := reservationIndex(reservations, version)
+	if index < 0 {
+		return errors.Wrapf(ErrNoReservation, "Cannot release version %v for project %v", version, project)
+	}
+
+	return v.storeReservations(project, append(reservations[:index], reservations[index+1:]...))
+}
+
+func reservationIndex(reservations []Reservation, version string) int {
+	for i, reservation := range reservations {
+		if reservation.Version == version {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (v *Version) storeReservations(project string, reservations []Reservation) error {
+	document, err := json.Marshal(reservations)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot encode reservations for project %v", project)
+	}
+
+	err = v.fileProvider.StoreDocument(reservationDocument, project, document)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot store reservations for project %v", project)
+	}
+
+	return nil
+}
+
+//SetReservationTTL sets the default time a reserved version is held
+func (handler *Handler) SetReservationTTL(ttl time.Duration) {
+	handler.reservationTTL = ttl
+}
+
+//ttlOf returns the ttl of the "ttl" query parameter or the default ttl of reservations
+func (handler *Handler) ttlOf(context *gin.Context) (time.Duration, error) {
+	if text := context.Query("ttl"); text != "" {
+		return parseAge(text)
+	}
+	if handler.reservationTTL == 0 {
+		return defaultReservationTTL, nil
+	}
+
+	return handler.reservationTTL, nil
+}
+
+//OnReserve is a handler for reserving the next version of an element for a given project
+func (handler *Handler) OnReserve(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	ttl, err := handler.ttlOf(context)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	handler.reserve(context, service, context.Param("element"), ttl, http.StatusOK)
+}
+
+//reserve reserves the next version of the element for the project of the request and responds with the given status
+func (handler *Handler) reserve(context *gin.Context, service *Version, element string, ttl time.Duration, status int) {
+	reservation, err := service.Reserve(context.Param("project"), element, ttl)
+	if err != nil {
+		abortChange(context, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	handler.changeLog(context).Infof("reserve %v version %v on project %v until %v", reservation.Element, reservation.Version, projectKey(context), reservation.Expires)
+	context.String(status, "%s", service.Display(context.Param("project"), reservation.Version))
+}
+
+//OnConfirm is a handler for confirming a reserved version of a given project
+func (handler *Handler) OnConfirm(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	project := context.Param("project")
+	version, err := service.parseVersion(project, context.Param("version"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	entry, err := service.Confirm(project, version)
+	if err != nil {
+		abortChange(context, err, http.StatusInternalServerError)
+		return
+	}
+
+	countBump(context.Param("namespace"), project, entry.Element)
+	handler.changed(context, service, entry)
+	handler.changeLog(context).Infof("confirm %v version %v on project %v", entry.Element, entry.Version, projectKey(context))
+	context.String(http.StatusOK, "%s", service.Display(project, entry.Version))
+}
+
+//OnRelease is a handler for releasing a reserved version of a given project
+func (handler *Handler) OnRelease(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	project := context.Param("project")
+	version, err := service.parseVersion(project, context.Param("version"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := service.Release(project, version); err != nil {
+		_ = context.AbortWithError(changeStatus(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	handler.logger.Infof("release reserved version %v on project %v", version, projectKey(context))
+	context.Status(http.StatusNoContent)
+}
+
+//OnListReservations is a handler for listing the active reservations of a given project
+func (handler *Handler) OnListReservations(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	reservations, err := service.Reservations(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, reservations)
+}
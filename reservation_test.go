@@ -0,0 +1,197 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func Test_Reserve_And_Confirm(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+
+	first, _ := version.Reserve("p1", "minor", time.Minute)
+	second, _ := version.Reserve("p1", "minor", time.Minute)
+	current, _ := version.GetVersion("p1")
+	entry, err := version.Confirm("p1", "1.3.0")
+	reservations, _ := version.Reservations("p1")
+
+	Ω.Expect(first.Version).To(Equal("1.3.0"))
+	Ω.Expect(second.Version).To(Equal("1.4.0"))
+	Ω.Expect(current).To(Equal("1.2.0"))
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Element).To(Equal("minor"))
+	Ω.Expect(entry.Version).To(Equal("1.3.0"))
+	Ω.Expect(reservations).To(HaveLen(1))
+	Ω.Expect(reservations[0].Version).To(Equal("1.4.0"))
+}
+
+func Test_Expired_Reservation_Is_Reused(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+	version.now = func() time.Time { return now }
+
+	_, _ = version.Reserve("p1", "patch", time.Minute)
+	now = now.Add(2 * time.Minute)
+	_, err := version.Confirm("p1", "1.2.1")
+	reservation, _ := version.Reserve("p1", "patch", time.Minute)
+
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNoReservation))
+	Ω.Expect(reservation.Version).To(Equal("1.2.1"))
+}
+
+func Test_Confirm_Outdated_Reservation(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+
+	_, _ = version.Reserve("p1", "minor", time.Minute)
+	_, _ = version.Reserve("p1", "minor", time.Minute)
+	_, _ = version.Confirm("p1", "1.4.0")
+	_, err := version.Confirm("p1", "1.3.0")
+
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrReservationOutdated))
+}
+
+func Test_Release_Reservation(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+
+	_, _ = version.Reserve("p1", "patch", time.Minute)
+	err := version.Release("p1", "1.2.1")
+	reservations, _ := version.Reservations("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(reservations).To(BeEmpty())
+}
+
+func Test_Reservation_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.2.0", "p1")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/reserve/minor/p1?ttl=1h", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.3.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/confirm/p1/1.3.0", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.3.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/confirm/p1/1.3.0", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/reserve/build/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(422))
+}
+
+func Test_Gap_Free_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Policy: &Policy{GapFree: true}}})
+
+	_, errFirst := version.Reserve("p1", "minor", time.Minute)
+	_, errPending := version.Reserve("p1", "minor", time.Minute)
+	_, errGap := version.Set("p1", "1.4.0")
+	_, errStep := version.BumpBy("p1", "patch", 2)
+	_, errBump := version.Bump("p1", "patch")
+	_, errElements := version.BumpElements("p1", BumpRequest{Elements: []string{"patch"}})
+	_, errNext := version.Set("p1", "1.2.1")
+
+	Ω.Expect(errFirst).To(BeNil())
+	Ω.Expect(errPending).To(BeAssignableToTypeOf(&PolicyViolation{}))
+	Ω.Expect(errors.Cause(errGap)).To(BeAssignableToTypeOf(&PolicyViolation{}))
+	Ω.Expect(errors.Cause(errStep)).To(BeAssignableToTypeOf(&PolicyViolation{}))
+	Ω.Expect(errors.Cause(errBump)).To(BeAssignableToTypeOf(&PolicyViolation{}))
+	Ω.Expect(errors.Cause(errElements)).To(BeAssignableToTypeOf(&PolicyViolation{}))
+	Ω.Expect(errNext).To(BeNil())
+}
+
+func Test_Gap_Free_Bump_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.2.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Policy: &Policy{GapFree: true}}})
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/p1", nil)
+	router.ServeHTTP(res, req)
+	current, _ := version.GetVersion("p1")
+	Ω.Expect(res.Code).To(Equal(202))
+	Ω.Expect(res.Body.String()).To(Equal("1.3.0"))
+	Ω.Expect(current).To(Equal("1.2.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/confirm/p1/1.3.0", nil)
+	router.ServeHTTP(res, req)
+	current, _ = version.GetVersion("p1")
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(current).To(Equal("1.3.0"))
+}
+
+//slowDocuments widens the window between reading and storing a document
+type slowDocuments struct {
+	adapter.IFileProvider
+}
+
+func (provider *slowDocuments) ReadDocument(kind string, project string) ([]byte, error) {
+	document, err := provider.IFileProvider.ReadDocument(kind, project)
+	time.Sleep(time.Millisecond)
+	return document, err
+}
+
+func Test_Concurrent_Reservations_Are_Distinct(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	basePath, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(basePath)
+	version := NewVersion(&slowDocuments{adapter.New(basePath)})
+	_, _ = version.SetVersion("p1", "1.0.0")
+
+	// the reservers only report, the results are asserted by the test goroutine
+	versions, failures := make(chan string, 20), make(chan error, 20)
+	reservers := sync.WaitGroup{}
+	for r := 0; r < 20; r++ {
+		reservers.Add(1)
+		go func() {
+			defer reservers.Done()
+			reservation, err := version.Reserve("p1", "patch", time.Minute)
+			if err != nil {
+				failures <- err
+				return
+			}
+			versions <- reservation.Version
+		}()
+	}
+	reservers.Wait()
+	close(versions)
+	close(failures)
+
+	Ω.Expect(failures).To(BeEmpty())
+	seen := map[string]bool{}
+	for reserved := range versions {
+		Ω.Expect(seen).NotTo(HaveKey(reserved), "duplicate reservation %v", reserved)
+		seen[reserved] = true
+	}
+	reservations, err := version.Reservations("p1")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(reservations).To(HaveLen(20))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//Resolve returns the highest version the given project ever had matching the range, "" if there is none
+func (v *Version) Resolve(project string, constraint *Constraint) (string, error) {
+	history, err := v.History(project)
+	if err != nil {
+		return "", err
+	}
+	current, err := v.readVersion(project)
+	if err != nil {
+		return "", errors.Wrapf(err, "Cannot get version for project %v", project)
+	}
+
+	candidates := []string{current}
+	for _, entry := range history {
+		candidates = append(candidates, entry.Previous, entry.Version)
+	}
+
+	resolved, highest := "", semver{}
+	for _, candidate := range candidates {
+		if !constraint.Matches(candidate) {
+			continue
+		}
+		parsed, _ := parseSemver(candidate)
+		if resolved == "" || parsed.compare(highest) > 0 {
+			resolved, highest = candidate, parsed
+		}
+	}
+
+	return resolved, nil
+}
+
+//OnResolve is a handler for resolving a semver range against the versions of a given project
+func (handler *Handler) OnResolve(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	constraint, err := ParseConstraint(context.Query("range"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	version, err := service.Resolve(context.Param("project"), constraint)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if version == "" {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No version of project %v matches %v", projectKey(context), context.Query("range")))
+		return
+	}
+
+	context.String(http.StatusOK, "%s", service.Display(context.Param("project"), version))
+}
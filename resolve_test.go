@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Resolve_Highest_Historical_Version(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.0", "p1"))
+	_, _ = version.BumpPatch("p1")
+	_, _ = version.BumpPatch("p1")
+	_, _ = version.BumpMinor("p1")
+	_, _ = version.BumpMajor("p1")
+
+	tilde, _ := ParseConstraint("~1.4")
+	caret, _ := ParseConstraint("^1")
+	none, _ := ParseConstraint("^3")
+	actualTilde, _ := version.Resolve("p1", tilde)
+	actualCaret, _ := version.Resolve("p1", caret)
+	actualNone, _ := version.Resolve("p1", none)
+
+	Ω.Expect(actualTilde).To(Equal("1.4.2"))
+	Ω.Expect(actualCaret).To(Equal("1.5.0"))
+	Ω.Expect(actualNone).To(Equal(""))
+}
+
+func Test_Resolve_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.4.3", "p1")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/resolve/p1?range=~1.4", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.4.3"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/resolve/p1?range=~2", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/resolve/p1?range=abc", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
+func Test_Resolve_Displays_Prefix(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.4.3", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Prefix: "v"}})
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/resolve/p1?range=~1.4", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(Equal("v1.4.3"))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+//listen listens on the address, with reusePort several processes listen on the same port, so a new binary can take over before the old one stops
+func listen(address string, reusePort bool) (net.Listener, error) {
+	config := net.ListenConfig{}
+	if reusePort {
+		config.Control = reusePortControl
+	}
+
+	listener, err := config.Listen(context.Background(), "tcp", address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not listen on %v", address)
+	}
+
+	return listener, nil
+}
+
+//serve serves requests until SIGTERM or SIGINT, then stops accepting connections and waits up to the timeout for running requests
+func serve(server *http.Server, listener net.Listener, timeout time.Duration, logger *log.Logger) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(signals)
+
+	stopped := make(chan error, 1)
+	go func() {
+		received := <-signals
+		logger.Infof("Server is shutting down after %v, waiting up to %v for running requests", received, timeout)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		stopped <- server.Shutdown(ctx)
+	}()
+
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+
+	return <-stopped
+}
@@ -0,0 +1,20 @@
+// +build linux
+
+package main
+
+import "syscall"
+
+//soReusePort is SO_REUSEPORT of linux, which the syscall package doesn't define
+const soReusePort = 0xf
+
+//reusePortControl sets SO_REUSEPORT on the socket before it is bound
+func reusePortControl(network string, address string, conn syscall.RawConn) error {
+	var err error
+	if controlErr := conn.Control(func(fd uintptr) {
+		err = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, soReusePort, 1)
+	}); controlErr != nil {
+		return controlErr
+	}
+
+	return err
+}
@@ -0,0 +1,14 @@
+// +build !linux
+
+package main
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+//reusePortControl is only supported on linux
+func reusePortControl(network string, address string, conn syscall.RawConn) error {
+	return errors.New("--reuse-port is only supported on linux")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+func Test_Reuse_Port_For_Overlapping_Processes(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("--reuse-port is only supported on linux")
+	}
+	Ω := NewGomegaWithT(t)
+
+	old, err := listen("127.0.0.1:0", true)
+	Ω.Expect(err).NotTo(HaveOccurred())
+	defer old.Close()
+	next, err := listen(old.Addr().String(), true)
+	Ω.Expect(err).NotTo(HaveOccurred())
+	defer next.Close()
+
+	_, err = listen(old.Addr().String(), false)
+	Ω.Expect(err).To(HaveOccurred())
+}
+
+func Test_Running_Requests_Finish_After_SIGTERM(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM can't be sent on windows")
+	}
+	Ω := NewGomegaWithT(t)
+	listener, _ := listen("127.0.0.1:0", false)
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})}
+	served := make(chan error, 1)
+	go func() { served <- serve(server, listener, time.Second, log.New()) }()
+
+	status := make(chan int, 1)
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			status <- 0
+			return
+		}
+		res.Body.Close()
+		status <- res.StatusCode
+	}()
+	<-started
+	process, _ := os.FindProcess(os.Getpid())
+	Ω.Expect(process.Signal(syscall.SIGTERM)).To(Succeed())
+
+	Ω.Eventually(status, time.Second).Should(Receive(Equal(http.StatusOK)))
+	Ω.Eventually(served, time.Second).Should(Receive(BeNil()))
+}
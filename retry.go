@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//retryingProvider retries storage calls failing with transient errors with exponential backoff
+type retryingProvider struct {
+	provider adapter.IFileProvider
+	retries  int
+	backoff  time.Duration
+	sleep    func(time.Duration)
+}
+
+//RetryStorage retries storage calls failing with transient errors up to retries times, waiting backoff doubled on every retry
+func (v *Version) RetryStorage(retries int, backoff time.Duration) {
+	v.fileProvider = &retryingProvider{provider: v.fileProvider, retries: retries, backoff: backoff, sleep: time.Sleep}
+}
+
+func (err *StorageError) Unwrap() error {
+	return err.Err
+}
+
+//isTransient returns true for errors, which may go away by retrying, like EIO on NFS or timeouts
+func isTransient(err error) bool {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case syscall.EIO, syscall.EAGAIN, syscall.EINTR, syscall.EBUSY, syscall.ETIMEDOUT, syscall.ESTALE:
+			return true
+		}
+		return false
+	}
+
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+
+	return os.IsTimeout(err)
+}
+
+func (r *retryingProvider) retry(operation string, f func() error) error {
+	backoff := r.backoff
+	err := f()
+	for attempt := 0; attempt < r.retries && err != nil && isTransient(err); attempt++ {
+		storageRetries.With(prometheus.Labels{"operation": operation}).Inc()
+		r.sleep(backoff)
+		backoff *= 2
+		err = f()
+	}
+
+	return err
+}
+
+func (r *retryingProvider) ReadVersion(project string) (version string, err error) {
+	err = r.retry("read_version", func() error {
+		version, err = r.provider.ReadVersion(project)
+		return err
+	})
+	return version, err
+}
+
+func (r *retryingProvider) StoreVersion(project string, version string) error {
+	return r.retry("store_version", func() error { return r.provider.StoreVersion(project, version) })
+}
+
+func (r *retryingProvider) ListProjects() (projects []string, err error) {
+	err = r.retry("list_projects", func() error {
+		projects, err = r.provider.ListProjects()
+		return err
+	})
+	return projects, err
+}
+
+func (r *retryingProvider) ReadDocument(kind string, project string) (document []byte, err error) {
+	err = r.retry("read_document", func() error {
+		document, err = r.provider.ReadDocument(kind, project)
+		return err
+	})
+	return document, err
+}
+
+func (r *retryingProvider) StoreDocument(kind string, project string, document []byte) error {
+	return r.retry("store_document", func() error { return r.provider.StoreDocument(kind, project, document) })
+}
+
+func (r *retryingProvider) DeleteDocument(kind string, project string) error {
+	return r.retry("delete_document", func() error { return r.provider.DeleteDocument(kind, project) })
+}
+
+func (r *retryingProvider) ListDocuments(kind string) (projects []string, err error) {
+	err = r.retry("list_documents", func() error {
+		projects, err = r.provider.ListDocuments(kind)
+		return err
+	})
+	return projects, err
+}
+
+//AppendHistory is not retried, a retried append could record the entry twice
+func (r *retryingProvider) AppendHistory(project string, entry []byte) error {
+	return r.provider.AppendHistory(project, entry)
+}
+
+func (r *retryingProvider) ReadHistory(project string) (history [][]byte, err error) {
+	err = r.retry("read_history", func() error {
+		history, err = r.provider.ReadHistory(project)
+		return err
+	})
+	return history, err
+}
+
+//ReplaceHistory is retried, replacing the history twice has the same result
+func (r *retryingProvider) ReplaceHistory(project string, entries [][]byte) error {
+	return r.retry("replace_history", func() error { return r.provider.ReplaceHistory(project, entries) })
+}
+
+func (r *retryingProvider) Namespace(namespace string) (adapter.IFileProvider, error) {
+	var provider adapter.IFileProvider
+	err := r.retry("namespace", func() (err error) {
+		provider, err = r.provider.Namespace(namespace)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	retrying := *r
+	retrying.provider = provider
+	return &retrying, nil
+}
+
+func (r *retryingProvider) ListNamespaces() (namespaces []string, err error) {
+	err = r.retry("list_namespaces", func() error {
+		namespaces, err = r.provider.ListNamespaces()
+		return err
+	})
+	return namespaces, err
+}
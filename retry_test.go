@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type flakyProvider struct {
+	adapter.IFileProvider
+	failures int
+	err      error
+}
+
+func (provider *flakyProvider) StoreVersion(project string, version string) error {
+	if provider.failures > 0 {
+		provider.failures--
+		return errors.Wrap(provider.err, "Store version in file failed")
+	}
+
+	return provider.IFileProvider.StoreVersion(project, version)
+}
+
+func Test_Retry_Transient_Storage_Errors(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	before := testutil.ToFloat64(storageRetries.With(prometheus.Labels{"operation": "store_version"}))
+	eio := &os.PathError{Op: "write", Path: "p1", Err: syscall.EIO}
+	version := NewVersion(&flakyProvider{IFileProvider: adapter.NewMock("1.0.0", "p1"), failures: 2, err: eio})
+	version.RetryStorage(2, time.Millisecond)
+	waits := []time.Duration{}
+	version.fileProvider.(*retryingProvider).sleep = func(wait time.Duration) { waits = append(waits, wait) }
+
+	bumped, err := version.BumpPatch("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bumped).To(Equal("1.0.1"))
+	Ω.Expect(waits).To(Equal([]time.Duration{time.Millisecond, 2 * time.Millisecond}))
+	Ω.Expect(testutil.ToFloat64(storageRetries.With(prometheus.Labels{"operation": "store_version"}))).To(Equal(before + 2))
+}
+
+func Test_Retry_Gives_Up(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(&flakyProvider{IFileProvider: adapter.NewMock("1.0.0", "p1"), failures: 3, err: syscall.ESTALE})
+	version.RetryStorage(2, 0)
+
+	_, err := version.BumpPatch("p1")
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_No_Retry_Of_Permanent_Errors(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	provider := &flakyProvider{IFileProvider: adapter.NewMock("1.0.0", "p1"), failures: 1, err: &os.PathError{Op: "open", Path: "p1", Err: syscall.EACCES}}
+	version := NewVersion(provider)
+	version.RetryStorage(2, 0)
+
+	_, err := version.BumpPatch("p1")
+
+	Ω.Expect(err).NotTo(BeNil())
+	Ω.Expect(isTransient(err)).To(BeFalse())
+	Ω.Expect(isTransient(errors.Wrap(&StorageError{Err: syscall.EIO}, "bump"))).To(BeTrue())
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const schedulerActor = "scheduler"
+
+//Schedule bumps a project periodically
+type Schedule struct {
+	Cron     string `json:"cron"`
+	Element  string `json:"element"`
+	Jitter   string `json:"jitter,omitempty"`
+	CheckURL string `json:"checkUrl,omitempty"`
+}
+
+//CheckResponse is returned by the check hook of a schedule
+type CheckResponse struct {
+	Changed bool `json:"changed"`
+}
+
+//Validate returns an error, if the schedule has an invalid cron expression, element or jitter
+func (schedule *Schedule) Validate() error {
+	if schedule == nil {
+		return nil
+	}
+
+	if _, err := ParseCron(schedule.Cron); err != nil {
+		return err
+	}
+	if _, err := nextVersion("0.0.0", schedule.Element, 1); err != nil {
+		return err
+	}
+	if _, err := schedule.jitter(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (schedule *Schedule) jitter() (time.Duration, error) {
+	if schedule.Jitter == "" {
+		return 0, nil
+	}
+
+	return parseAge(schedule.Jitter)
+}
+
+//changed asks the check hook, if the project changed since the current version, true if there is no hook
+func (schedule *Schedule) changed(project string, version string) (bool, error) {
+	if schedule.CheckURL == "" {
+		return true, nil
+	}
+
+	check, err := url.Parse(schedule.CheckURL)
+	if err != nil {
+		return false, errors.Wrapf(err, "Invalid check url %v", schedule.CheckURL)
+	}
+	query := check.Query()
+	query.Set("project", project)
+	query.Set("version", version)
+	check.RawQuery = query.Encode()
+
+	res, err := schemeClient.Get(check.String())
+	if err != nil {
+		return false, errors.Wrapf(err, "Cannot check project %v", project)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return false, errors.Errorf("Check of project %v failed with status %v", project, res.StatusCode)
+	}
+
+	response := CheckResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return false, errors.Wrapf(err, "Cannot parse check of project %v", project)
+	}
+
+	return response.Changed, nil
+}
+
+//RunSchedules starts the bumps of all projects with a schedule due at the given time, delayed by their jitter
+func (handler *Handler) RunSchedules(now time.Time) error {
+	return handler.forEachSchedule(func(namespace string, service *Version, project string, schedule *Schedule) {
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil || !cron.Matches(now) {
+			return
+		}
+
+		jitter, _ := schedule.jitter()
+		delay := time.Duration(0)
+		if jitter > 0 {
+			delay = time.Duration(rand.Int63n(int64(jitter)))
+		}
+		time.AfterFunc(delay, func() {
+			handler.runSchedule(namespace, service, project, schedule)
+		})
+	})
+}
+
+func (handler *Handler) forEachSchedule(run func(string, *Version, string, *Schedule)) error {
+	namespaces, err := handler.version.fileProvider.ListNamespaces()
+	if err != nil {
+		return errors.Wrap(err, "Cannot list namespaces")
+	}
+
+	for _, namespace := range append([]string{""}, namespaces...) {
+		service := handler.version
+		if namespace != "" {
+			if service, err = handler.version.Namespace(namespace); err != nil {
+				return err
+			}
+		}
+
+		projects, err := service.ListProjects(ProjectFilter{})
+		if err != nil {
+			return err
+		}
+		for _, project := range projects {
+			if project.Metadata != nil && project.Metadata.Schedule != nil {
+				run(namespace, service, project.Name, project.Metadata.Schedule)
+			}
+		}
+	}
+
+	return nil
+}
+
+//runSchedule bumps the project, unless the check hook reports it unchanged, and returns the result of the run
+func (handler *Handler) runSchedule(namespace string, service *Version, project string, schedule *Schedule) string {
+	result := handler.scheduledBump(namespace, service, project, schedule)
+	scheduledRuns.With(prometheus.Labels{"result": result}).Inc()
+
+	return result
+}
+
+func (handler *Handler) scheduledBump(namespace string, service *Version, project string, schedule *Schedule) string {
+	key := project
+	if namespace != "" {
+		key = namespace + "/" + project
+	}
+
+	current, err := service.readVersion(project)
+	if err != nil {
+		handler.logger.Error(errors.Wrapf(err, "Cannot get version for project %v", key))
+		return "failed"
+	}
+	if err := service.checkHookURL(schedule.CheckURL); err != nil {
+		handler.logger.Error(errors.Wrapf(err, "Cannot check project %v", key))
+		return "failed"
+	}
+	changed, err := schedule.changed(project, current)
+	if err != nil {
+		handler.logger.Error(err)
+		return "failed"
+	}
+	if !changed {
+		handler.logger.Infof("skip scheduled %v bump on unchanged project %v", schedule.Element, key)
+		return "skipped"
+	}
+
+	entry, err := service.WithAnnotation(Annotation{Reason: "scheduled " + schedule.Cron, Actor: schedulerActor}).Bump(project, schedule.Element)
+	if err != nil {
+		handler.logger.Error(err)
+		return "failed"
+	}
+
+	countBump(namespace, project, schedule.Element)
+	handler.publish(namespace, project, service, entry)
+	handler.auditLog().Infof("scheduled bump %v version to %v on project %v", schedule.Element, entry.Version, key)
+	return "bumped"
+}
+
+//StartScheduler runs the schedules of all projects every minute in the background
+func (handler *Handler) StartScheduler() {
+	go func() {
+		for now := range time.Tick(time.Minute) {
+			if err := handler.RunSchedules(now); err != nil {
+				handler.logger.Error(err)
+			}
+		}
+	}()
+}
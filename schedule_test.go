@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func newCheckHook(changed bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("project") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(CheckResponse{Changed: changed})
+	}))
+}
+
+func Test_Validate_Schedule(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect((&Schedule{Cron: "@nightly", Element: "patch", Jitter: "5m"}).Validate()).To(BeNil())
+	Ω.Expect((&Schedule{Cron: "@often", Element: "patch"}).Validate()).NotTo(BeNil())
+	Ω.Expect((&Schedule{Cron: "@nightly", Element: "build"}).Validate()).NotTo(BeNil())
+	Ω.Expect((&Schedule{Cron: "@nightly", Element: "patch", Jitter: "soon"}).Validate()).NotTo(BeNil())
+}
+
+func Test_Run_Schedule(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "nightly"))
+	handler := NewHandler(version, nil)
+
+	result := handler.runSchedule("", version, "nightly", &Schedule{Cron: "@nightly", Element: "patch"})
+	current, _ := version.GetVersion("nightly")
+	history, _ := version.History("nightly")
+
+	Ω.Expect(result).To(Equal("bumped"))
+	Ω.Expect(current).To(Equal("1.0.1"))
+	Ω.Expect(history[0].Actor).To(Equal("scheduler"))
+}
+
+func Test_Run_Schedule_With_Check_Hook(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	unchanged, changed := newCheckHook(false), newCheckHook(true)
+	defer unchanged.Close()
+	defer changed.Close()
+	version := NewVersion(adapter.NewMock("1.0.0", "nightly"))
+	_ = version.AllowHookURLs([]string{unchanged.URL, changed.URL, "http://127.0.0.1:1"})
+	handler := NewHandler(version, nil)
+
+	skipped := handler.runSchedule("", version, "nightly", &Schedule{Cron: "@nightly", Element: "patch", CheckURL: unchanged.URL})
+	bumped := handler.runSchedule("", version, "nightly", &Schedule{Cron: "@nightly", Element: "patch", CheckURL: changed.URL})
+	failed := handler.runSchedule("", version, "nightly", &Schedule{Cron: "@nightly", Element: "patch", CheckURL: "http://127.0.0.1:1"})
+	current, _ := version.GetVersion("nightly")
+
+	Ω.Expect(skipped).To(Equal("skipped"))
+	Ω.Expect(bumped).To(Equal("bumped"))
+	Ω.Expect(failed).To(Equal("failed"))
+	Ω.Expect(current).To(Equal("1.0.1"))
+}
+
+func Test_Run_Schedule_With_Check_Hook_Not_Allowed(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	changed := newCheckHook(true)
+	defer changed.Close()
+	version := NewVersion(adapter.NewMock("1.0.0", "nightly"))
+	handler := NewHandler(version, nil)
+
+	result := handler.runSchedule("", version, "nightly", &Schedule{Cron: "@nightly", Element: "patch", CheckURL: changed.URL})
+	current, _ := version.GetVersion("nightly")
+	err := version.SetMetadata("nightly", &Metadata{Config: Config{Schedule: &Schedule{Cron: "@nightly", Element: "patch", CheckURL: changed.URL}}})
+
+	Ω.Expect(result).To(Equal("failed"))
+	Ω.Expect(current).To(Equal("1.0.0"))
+	Ω.Expect(errors.Is(err, ErrHookNotAllowed)).To(BeTrue())
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	//ErrSchemeHook is returned when the versioning scheme hook of a project fails
+	ErrSchemeHook = errors.New("versioning scheme hook failed")
+	//ErrHookNotAllowed is returned for hook urls of projects, which are not below an url allowed by the operator
+	ErrHookNotAllowed = errors.New("hook url is not allowed")
+)
+
+var (
+	schemeClient  = &http.Client{Timeout: 10 * time.Second}
+	schemeVersion = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.:~+_-]*$`)
+)
+
+//Scheme is a custom versioning scheme of a project implemented by an external http hook
+type Scheme struct {
+	HookURL string `json:"hookUrl"`
+}
+
+//SchemeRequest is posted to the hook to compute the next version
+type SchemeRequest struct {
+	Project string `json:"project"`
+	Version string `json:"version"`
+	Element string `json:"element"`
+	Step    int    `json:"step"`
+}
+
+//SchemeResponse is returned by the hook with the next version
+type SchemeResponse struct {
+	Version string `json:"version"`
+}
+
+//AllowHookURLs sets the urls, below which projects may call hooks, without any url projects cannot use hooks
+func (v *Version) AllowHookURLs(allowed []string) error {
+	hookURLs := []*url.URL{}
+	for _, text := range allowed {
+		parsed, err := url.Parse(text)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return errors.Errorf("%v is not a valid hook url", text)
+		}
+		hookURLs = append(hookURLs, parsed)
+	}
+
+	v.hookURLs = hookURLs
+	return nil
+}
+
+//checkHookURL returns ErrHookNotAllowed, if the hook url is not below one of the allowed urls, "" is no hook
+func (v *Version) checkHookURL(hook string) error {
+	if hook == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(hook)
+	if err == nil && parsed.User == nil && !strings.Contains(parsed.Path, "..") {
+		for _, allowed := range v.hookURLs {
+			below := parsed.Path == allowed.Path || strings.HasPrefix(parsed.Path, strings.TrimSuffix(allowed.Path, "/")+"/")
+			if parsed.Scheme == allowed.Scheme && strings.EqualFold(parsed.Host, allowed.Host) && below {
+				return nil
+			}
+		}
+	}
+
+	return errors.Wrapf(ErrHookNotAllowed, "%v is not an allowed hook url", hook)
+}
+
+//checkHooks returns ErrHookNotAllowed, if the config of a project has a hook url, which is not allowed
+func (v *Version) checkHooks(config *Config) error {
+	if config.Scheme != nil {
+		if err := v.checkHookURL(config.Scheme.HookURL); err != nil {
+			return err
+		}
+	}
+	if config.Schedule != nil {
+		if err := v.checkHookURL(config.Schedule.CheckURL); err != nil {
+			return err
+		}
+	}
+	if config.Analyzer != nil {
+		if err := v.checkHookURL(config.Analyzer.HookURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//schemeOf returns the custom versioning scheme of the given project or nil, if it uses semantic versions
+func (v *Version) schemeOf(project string) (*Scheme, error) {
+	meta, err := v.GetMetadata(project)
+	if err != nil || meta == nil || meta.Scheme == nil || meta.Scheme.HookURL == "" {
+		return nil, err
+	}
+	if err := v.checkHookURL(meta.Scheme.HookURL); err != nil {
+		return nil, err
+	}
+
+	return meta.Scheme, nil
+}
+
+//Next asks the hook of the scheme for the next version
+func (scheme *Scheme) Next(request SchemeRequest) (string, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", errors.Wrap(err, "Cannot encode scheme request")
+	}
+
+	res, err := schemeClient.Post(scheme.HookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrapf(ErrSchemeHook, "Cannot call %v for project %v: %v", scheme.HookURL, request.Project, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return "", errors.Wrapf(ErrSchemeHook, "Hook %v for project %v responded with status %v", scheme.HookURL, request.Project, res.StatusCode)
+	}
+
+	response := SchemeResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return "", errors.Wrapf(ErrSchemeHook, "Cannot parse response of %v for project %v: %v", scheme.HookURL, request.Project, err)
+	}
+	if !schemeVersion.MatchString(response.Version) {
+		return "", errors.Wrapf(ErrSchemeHook, "Hook %v returned invalid version %q for project %v", scheme.HookURL, response.Version, request.Project)
+	}
+
+	return response.Version, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func newSchemeHook(version string, status int) (*httptest.Server, chan SchemeRequest) {
+	requests := make(chan SchemeRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := SchemeRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		requests <- request
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(SchemeResponse{Version: version})
+	}))
+
+	return server, requests
+}
+
+func Test_Bump_With_Scheme_Hook(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	hook, requests := newSchemeHook("2:1.4-2", http.StatusOK)
+	defer hook.Close()
+	version := NewVersion(adapter.NewMock("2:1.4-1", "p1"))
+	_ = version.AllowHookURLs([]string{hook.URL})
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Scheme: &Scheme{HookURL: hook.URL}}})
+
+	entry, err := version.Bump("p1", "patch")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(entry.Version).To(Equal("2:1.4-2"))
+	Ω.Expect(<-requests).To(Equal(SchemeRequest{Project: "p1", Version: "2:1.4-1", Element: "patch", Step: 1}))
+}
+
+func Test_Set_Version_With_Scheme(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("2:1.4-1", "p1"))
+	_ = version.AllowHookURLs([]string{"http://localhost"})
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Scheme: &Scheme{HookURL: "http://localhost"}}})
+
+	_, errValid := version.Set("p1", "2:1.5-1")
+	_, errInvalid := version.Set("p1", "2 1.5")
+
+	Ω.Expect(errValid).To(BeNil())
+	Ω.Expect(errInvalid).NotTo(BeNil())
+}
+
+func Test_Failing_Scheme_Hook_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	hook, _ := newSchemeHook("", http.StatusInternalServerError)
+	defer hook.Close()
+	version := NewVersion(adapter.NewMock("2:1.4-1", "p1"))
+	_ = version.AllowHookURLs([]string{hook.URL})
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Scheme: &Scheme{HookURL: hook.URL}}})
+	router := NewHandler(version, nil).GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	current, _ := version.GetVersion("p1")
+
+	Ω.Expect(res.Code).To(Equal(502))
+	Ω.Expect(current).To(Equal("2:1.4-1"))
+}
+
+func Test_Scheme_Hook_Must_Be_Allowed(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.AllowHookURLs([]string{"https://hooks.example.com/vbump"})
+	router := NewHandler(version, nil).GetRouter()
+
+	for _, hook := range []string{"http://169.254.169.254/latest", "https://hooks.example.com.evil.io/vbump", "https://hooks.example.com/vbump-other", "https://hooks.example.com/vbump/../admin"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/project/p1/meta", strings.NewReader(`{"scheme": {"hookUrl": "`+hook+`"}}`))
+		router.ServeHTTP(res, req)
+
+		Ω.Expect(res.Code).To(Equal(http.StatusForbidden), hook)
+	}
+	Ω.Expect(version.SetMetadata("p1", &Metadata{Config: Config{Scheme: &Scheme{HookURL: "https://hooks.example.com/vbump/debian"}}})).To(BeNil())
+
+	// hooks stored before the url was allowed are not called
+	_ = version.AllowHookURLs(nil)
+	_, err := version.Bump("p1", "patch")
+	Ω.Expect(errors.Is(err, ErrHookNotAllowed)).To(BeTrue())
+}
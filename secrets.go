@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	fileSecretPrefix  = "file:"
+	vaultSecretPrefix = "vault:"
+)
+
+//Secret is a secret value, which is read again from its file or vault on every refresh
+type Secret struct {
+	reference string
+	value     atomic.Value
+}
+
+//NewSecret constructs a secret with a fixed value
+func NewSecret(value string) *Secret {
+	secret := &Secret{}
+	secret.value.Store(value)
+	return secret
+}
+
+//Value returns the current value of the secret, "" for no secret
+func (secret *Secret) Value() string {
+	if secret == nil {
+		return ""
+	}
+
+	return secret.value.Load().(string)
+}
+
+//isSecretReference returns true for references to a file or a vault secret
+func isSecretReference(value string) bool {
+	return strings.HasPrefix(value, fileSecretPrefix) || strings.HasPrefix(value, vaultSecretPrefix)
+}
+
+//VaultClient reads secrets from the kv engine of hashicorp vault, the token file may be renewed by the vault agent
+type VaultClient struct {
+	address   string
+	tokenFile string
+	client    *http.Client
+}
+
+//NewVaultClient constructs a client for the vault at the address, authenticated with the token in the file
+func NewVaultClient(address string, tokenFile string) *VaultClient {
+	return &VaultClient{address: strings.TrimSuffix(address, "/"), tokenFile: tokenFile, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+//Read returns the field of the secret at the path, e.g. secret/data/vbump#slack of kv version 2 or secret/vbump#slack of version 1
+func (vault *VaultClient) Read(reference string) (string, error) {
+	parts := strings.SplitN(reference, "#", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", errors.Errorf("Invalid vault secret %v, expected <path>#<field>", reference)
+	}
+
+	body := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	if err := vault.call(http.MethodGet, parts[0], nil, &body); err != nil {
+		return "", errors.Wrapf(err, "Cannot read vault secret %v", parts[0])
+	}
+	data := body.Data
+	// kv version 2 nests the secret in data.data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[parts[1]].(string)
+	if !ok {
+		return "", errors.Errorf("Vault secret %v has no field %v", parts[0], parts[1])
+	}
+
+	return value, nil
+}
+
+//call sends the payload to the path of the vault api and decodes the response, the token is read from the file on every call
+func (vault *VaultClient) call(method string, path string, payload interface{}, response interface{}) error {
+	token, err := ioutil.ReadFile(vault.tokenFile)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot read vault token from %v", vault.tokenFile)
+	}
+	var body io.Reader
+	if payload != nil {
+		document, err := json.Marshal(payload)
+		if err != nil {
+			return errors.Wrap(err, "Cannot encode vault request")
+		}
+		body = bytes.NewReader(document)
+	}
+	request, err := http.NewRequest(method, vault.address+"/v1/"+strings.TrimPrefix(path, "/"), body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	request.Header.Set("X-Vault-Token", strings.TrimSpace(string(token)))
+
+	res, err := vault.client.Do(request)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("Vault responded with status %v", res.StatusCode)
+	}
+
+	return errors.Wrap(json.NewDecoder(res.Body).Decode(response), "Cannot parse vault response")
+}
+
+//Secrets resolves references to files and vault secrets and reads them again on every refresh
+type Secrets struct {
+	mutex   sync.Mutex
+	vault   *VaultClient
+	secrets map[string]*Secret
+}
+
+//NewSecrets constructs a registry of secrets, vault may be nil, if no vault is configured
+func NewSecrets(vault *VaultClient) *Secrets {
+	return &Secrets{vault: vault, secrets: map[string]*Secret{}}
+}
+
+//Resolve returns the secret of a file:<path> or vault:<path>#<field> reference, any other value is the secret itself
+func (secrets *Secrets) Resolve(value string) (*Secret, error) {
+	if !isSecretReference(value) {
+		return NewSecret(value), nil
+	}
+
+	secrets.mutex.Lock()
+	defer secrets.mutex.Unlock()
+	if secret, exists := secrets.secrets[value]; exists {
+		return secret, nil
+	}
+
+	read, err := secrets.read(value)
+	if err != nil {
+		return nil, err
+	}
+	secret := NewSecret(read)
+	secret.reference = value
+	secrets.secrets[value] = secret
+
+	return secret, nil
+}
+
+func (secrets *Secrets) read(reference string) (string, error) {
+	if strings.HasPrefix(reference, fileSecretPrefix) {
+		filename := strings.TrimPrefix(reference, fileSecretPrefix)
+		value, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return "", errors.Wrapf(err, "Cannot read secret from %v", filename)
+		}
+		return strings.TrimSpace(string(value)), nil
+	}
+
+	if secrets.vault == nil {
+		return "", errors.Errorf("Cannot read %v, no vault is configured with --vault-address", reference)
+	}
+	return secrets.vault.Read(strings.TrimPrefix(reference, vaultSecretPrefix))
+}
+
+//Refresh reads all resolved secrets again, secrets failing to read keep their value
+func (secrets *Secrets) Refresh() error {
+	secrets.mutex.Lock()
+	resolved := make([]*Secret, 0, len(secrets.secrets))
+	for _, secret := range secrets.secrets {
+		resolved = append(resolved, secret)
+	}
+	secrets.mutex.Unlock()
+
+	var failed error
+	for _, secret := range resolved {
+		value, err := secrets.read(secret.reference)
+		if err != nil {
+			failed = err
+			continue
+		}
+		secret.value.Store(value)
+	}
+
+	return failed
+}
+
+//StartRefresh reads the secrets again every interval
+func (secrets *Secrets) StartRefresh(interval time.Duration, logger *log.Logger) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := secrets.Refresh(); err != nil {
+				logger.Error(err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Resolve_Literal_Secret(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	secret, err := NewSecrets(nil).Resolve("s3cr3t")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(secret.Value()).To(Equal("s3cr3t"))
+	Ω.Expect((*Secret)(nil).Value()).To(Equal(""))
+}
+
+func Test_Refresh_File_Secret(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	dir, _ := ioutil.TempDir("", "secrets")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "slack")
+	_ = ioutil.WriteFile(filename, []byte("first\n"), 0600)
+	secrets := NewSecrets(nil)
+
+	secret, err := secrets.Resolve("file:" + filename)
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(secret.Value()).To(Equal("first"))
+
+	_ = ioutil.WriteFile(filename, []byte("second\n"), 0600)
+	Ω.Expect(secrets.Refresh()).To(BeNil())
+	Ω.Expect(secret.Value()).To(Equal("second"))
+
+	os.Remove(filename)
+	Ω.Expect(secrets.Refresh()).NotTo(BeNil())
+	Ω.Expect(secret.Value()).To(Equal("second"))
+}
+
+func Test_Resolve_Missing_File_Secret(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	_, err := NewSecrets(nil).Resolve("file:/does/not/exist")
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Resolve_Vault_Secret(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/vbump":
+			_, _ = w.Write([]byte(`{"data":{"data":{"slack":"from-kv2"}}}`))
+		case "/v1/kv/vbump":
+			_, _ = w.Write([]byte(`{"data":{"slack":"from-kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "secrets")
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	_ = ioutil.WriteFile(tokenFile, []byte("vault-token\n"), 0600)
+	secrets := NewSecrets(NewVaultClient(server.URL+"/", tokenFile))
+
+	kv2, err := secrets.Resolve("vault:secret/data/vbump#slack")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(kv2.Value()).To(Equal("from-kv2"))
+	kv1, err := secrets.Resolve("vault:kv/vbump#slack")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(kv1.Value()).To(Equal("from-kv1"))
+
+	_, err = secrets.Resolve("vault:kv/vbump#missing")
+	Ω.Expect(err).NotTo(BeNil())
+	_, err = secrets.Resolve("vault:kv/vbump")
+	Ω.Expect(err).NotTo(BeNil())
+	_, err = secrets.Resolve("vault:kv/unknown#slack")
+	Ω.Expect(err).NotTo(BeNil())
+
+	// the token is read again, e.g. after the vault agent renewed it
+	_ = ioutil.WriteFile(tokenFile, []byte("expired"), 0600)
+	_, err = secrets.Resolve("vault:secret/data/other#slack")
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Resolve_Vault_Secret_Without_Vault(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	_, err := NewSecrets(nil).Resolve("vault:secret/data/vbump#slack")
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Webhook_Secret_Of_Project_Resolves_Vault_Only(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"data":{"hook":"from-vault"}}}`))
+	}))
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "secrets")
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	_ = ioutil.WriteFile(tokenFile, []byte("vault-token"), 0600)
+	notifier := NewNotifier("", nil, nil)
+	notifier.SetSecrets(NewSecrets(NewVaultClient(server.URL, tokenFile)))
+
+	secret, err := notifier.secretOf(&Metadata{Config: Config{WebhookSecret: "vault:secret/data/p1#hook"}})
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(secret).To(Equal("from-vault"))
+
+	secret, err = notifier.secretOf(&Metadata{Config: Config{WebhookSecret: "file:" + tokenFile}})
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(secret).To(Equal("file:" + tokenFile))
+}
+
+func Test_Reload_Tokens(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	file, _ := ioutil.TempFile("", "tokens")
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString("ci secret1 *\n")
+	file.Close()
+	tokens, _ := LoadTokens(file.Name())
+
+	_ = ioutil.WriteFile(file.Name(), []byte("ci secret2 *\n"), 0600)
+	Ω.Expect(tokens.Reload(file.Name())).To(BeNil())
+	Ω.Expect(tokens.Lookup("secret1")).To(BeNil())
+	Ω.Expect(tokens.Lookup("secret2").Name).To(Equal("ci"))
+
+	Ω.Expect(tokens.Reload("/does/not/exist")).NotTo(BeNil())
+	Ω.Expect(tokens.Lookup("secret2")).NotTo(BeNil())
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	constraintTerm = regexp.MustCompile(`^(>=|<=|>|<|=|~|\^)?\s*(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?$`)
+	constraintOps  = regexp.MustCompile(`(>=|<=|>|<|=|~|\^)\s+`)
+)
+
+//semver holds major, minor and patch of a version, missing parts are zero
+type semver [3]int
+
+//parseSemver parses a version with one to three numeric parts
+func parseSemver(version string) (semver, error) {
+	parsed := semver{}
+	if !validateVersion(version) {
+		return parsed, errors.Errorf("%v is not a valid version", version)
+	}
+
+	for i, part := range strings.Split(version, ".") {
+		parsed[i], _ = strconv.Atoi(part)
+	}
+
+	return parsed, nil
+}
+
+//compare returns -1, 0 or 1, if the version is lower, equal or higher than the other version
+func (version semver) compare(other semver) int {
+	for i := range version {
+		if version[i] < other[i] {
+			return -1
+		}
+		if version[i] > other[i] {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+//comparePrerelease compares the prereleases of two versions of the same release, a release ranks above its prereleases
+func comparePrerelease(a string, b string) int {
+	a, b = strings.SplitN(strings.TrimPrefix(a, "-"), "+", 2)[0], strings.SplitN(strings.TrimPrefix(b, "-"), "+", 2)[0]
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	identifiersA, identifiersB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(identifiersA) && i < len(identifiersB); i++ {
+		if compared := compareIdentifier(identifiersA[i], identifiersB[i]); compared != 0 {
+			return compared
+		}
+	}
+
+	return compareInt(len(identifiersA), len(identifiersB))
+}
+
+//compareIdentifier compares prerelease identifiers, numeric identifiers rank below alphanumeric ones
+func compareIdentifier(a string, b string) int {
+	numberA, errA := strconv.Atoi(a)
+	numberB, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInt(numberA, numberB)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func compareInt(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+type comparison struct {
+	operator string
+	version  semver
+}
+
+func (c comparison) matches(version semver) bool {
+	result := version.compare(c.version)
+	switch c.operator {
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	default:
+		return result == 0
+	}
+}
+
+//Constraint is a semver range like "~1.4", "^2", "1.x", ">=1.2 <2" or alternatives joined by "||"
+type Constraint struct {
+	alternatives [][]comparison
+}
+
+//ParseConstraint parses a semver range
+func ParseConstraint(text string) (*Constraint, error) {
+	constraint := &Constraint{}
+	for _, alternative := range strings.Split(text, "||") {
+		comparisons := []comparison{}
+		for _, term := range strings.Fields(constraintOps.ReplaceAllString(alternative, "$1")) {
+			parsed, err := parseConstraintTerm(term)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%v is not a valid range", text)
+			}
+			comparisons = append(comparisons, parsed...)
+		}
+		if len(comparisons) == 0 {
+			return nil, errors.Errorf("%v is not a valid range", text)
+		}
+		constraint.alternatives = append(constraint.alternatives, comparisons)
+	}
+
+	return constraint, nil
+}
+
+//parseConstraintTerm translates a single term into lower and upper bounds
+func parseConstraintTerm(term string) ([]comparison, error) {
+	match := constraintTerm.FindStringSubmatch(term)
+	if match == nil {
+		return nil, errors.Errorf("%v is not a valid range term", term)
+	}
+
+	operator := match[1]
+	base := semver{}
+	given := 0
+	for i, part := range match[2:] {
+		if part == "" || part == "x" || part == "X" || part == "*" {
+			break
+		}
+		base[i], _ = strconv.Atoi(part)
+		given++
+	}
+
+	switch {
+	case given == 0:
+		return []comparison{{">=", semver{}}}, nil
+	case operator == "^":
+		upper := semver{base[0] + 1}
+		if base[0] == 0 && given > 1 {
+			upper = semver{0, base[1] + 1}
+			if base[1] == 0 && given > 2 {
+				upper = semver{0, 0, base[2] + 1}
+			}
+		}
+		return []comparison{{">=", base}, {"<", upper}}, nil
+	case operator == "~":
+		upper := semver{base[0], base[1] + 1}
+		if given == 1 {
+			upper = semver{base[0] + 1}
+		}
+		return []comparison{{">=", base}, {"<", upper}}, nil
+	case operator == "" || operator == "=":
+		if given == 3 {
+			return []comparison{{"=", base}}, nil
+		}
+		upper := base
+		upper[given-1]++
+		return []comparison{{">=", base}, {"<", upper}}, nil
+	default:
+		return []comparison{{operator, base}}, nil
+	}
+}
+
+//Matches returns true, if the version satisfies the range
+func (constraint *Constraint) Matches(version string) bool {
+	parsed, err := parseSemver(version)
+	if err != nil {
+		return false
+	}
+
+	for _, comparisons := range constraint.alternatives {
+		matches := true
+		for _, c := range comparisons {
+			matches = matches && c.matches(parsed)
+		}
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func matchingVersions(text string, versions ...string) []string {
+	constraint, _ := ParseConstraint(text)
+	matching := []string{}
+	for _, version := range versions {
+		if constraint.Matches(version) {
+			matching = append(matching, version)
+		}
+	}
+
+	return matching
+}
+
+func Test_Compare_Semver(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	a, _ := parseSemver("1.2")
+	b, _ := parseSemver("1.2.0")
+	c, _ := parseSemver("1.10.0")
+
+	Ω.Expect(a.compare(b)).To(Equal(0))
+	Ω.Expect(a.compare(c)).To(Equal(-1))
+	Ω.Expect(c.compare(a)).To(Equal(1))
+}
+
+func Test_Tilde_Range(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(matchingVersions("~1.4", "1.3.9", "1.4", "1.4.7", "1.5.0")).To(Equal([]string{"1.4", "1.4.7"}))
+	Ω.Expect(matchingVersions("~1", "0.9", "1.0.0", "1.9", "2.0")).To(Equal([]string{"1.0.0", "1.9"}))
+}
+
+func Test_Caret_Range(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(matchingVersions("^1.4", "1.3", "1.4.0", "1.9.9", "2.0.0")).To(Equal([]string{"1.4.0", "1.9.9"}))
+	Ω.Expect(matchingVersions("^0.4.2", "0.4.1", "0.4.2", "0.4.9", "0.5.0")).To(Equal([]string{"0.4.2", "0.4.9"}))
+}
+
+func Test_Wildcard_And_Partial_Range(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(matchingVersions("1.4.x", "1.3", "1.4.3", "1.5")).To(Equal([]string{"1.4.3"}))
+	Ω.Expect(matchingVersions("1", "0.1", "1.4.3", "2")).To(Equal([]string{"1.4.3"}))
+	Ω.Expect(matchingVersions("*", "0.1", "2")).To(Equal([]string{"0.1", "2"}))
+	Ω.Expect(matchingVersions("1.4.3", "1.4", "1.4.3")).To(Equal([]string{"1.4.3"}))
+}
+
+func Test_Comparison_Range(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(matchingVersions(">=1.2 <2", "1.1", "1.2", "1.9", "2.0")).To(Equal([]string{"1.2", "1.9"}))
+	Ω.Expect(matchingVersions(">= 1.2 < 2", "1.1", "1.2", "2.0")).To(Equal([]string{"1.2"}))
+	Ω.Expect(matchingVersions("<1 || >=3", "0.9", "1.0", "3.1")).To(Equal([]string{"0.9", "3.1"}))
+}
+
+func Test_Invalid_Range(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	_, empty := ParseConstraint("")
+	_, garbage := ParseConstraint("~a.b")
+
+	Ω.Expect(empty).NotTo(BeNil())
+	Ω.Expect(garbage).NotTo(BeNil())
+}
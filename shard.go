@@ -0,0 +1,122 @@
+package main
+
+import (
+	"hash/crc32"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	shardReplicas        = 100
+	shardForwardedHeader = "X-Vbump-Shard-Forwarded"
+)
+
+//ShardMap splits ownership of projects across a fleet of vbump instances using consistent hashing
+type ShardMap struct {
+	self    string
+	ring    []uint32
+	owners  map[uint32]string
+	proxies map[string]*httputil.ReverseProxy
+}
+
+//NewShardMap constructs a shard map for the given instance urls, self is always part of the ring
+func NewShardMap(self string, peers []string) (*ShardMap, error) {
+	shards := &ShardMap{
+		self:    self,
+		owners:  map[uint32]string{},
+		proxies: map[string]*httputil.ReverseProxy{},
+	}
+
+	instances := append([]string{self}, peers...)
+	for _, instance := range instances {
+		if _, exists := shards.proxies[instance]; exists {
+			continue
+		}
+
+		target, err := url.Parse(instance)
+		if err != nil || target.Host == "" {
+			return nil, errors.Errorf("%v is not a valid shard url", instance)
+		}
+		shards.proxies[instance] = httputil.NewSingleHostReverseProxy(target)
+
+		for i := 0; i < shardReplicas; i++ {
+			hash := crc32.ChecksumIEEE([]byte(instance + "#" + strconv.Itoa(i)))
+			shards.owners[hash] = instance
+			shards.ring = append(shards.ring, hash)
+		}
+	}
+	sort.Slice(shards.ring, func(i, j int) bool { return shards.ring[i] < shards.ring[j] })
+
+	return shards, nil
+}
+
+//Owner returns the url of the instance owning the given project
+func (shards *ShardMap) Owner(project string) string {
+	hash := crc32.ChecksumIEEE([]byte(project))
+	i := sort.Search(len(shards.ring), func(i int) bool { return shards.ring[i] >= hash })
+	if i == len(shards.ring) {
+		i = 0
+	}
+
+	return shards.owners[shards.ring[i]]
+}
+
+//IsLocal returns true, if the given project is owned by this instance
+func (shards *ShardMap) IsLocal(project string) bool {
+	return shards.Owner(project) == shards.self
+}
+
+//isPeer returns true, if the url is another instance of the ring
+func (shards *ShardMap) isPeer(instance string) bool {
+	_, exists := shards.proxies[instance]
+	return exists && instance != shards.self
+}
+
+//foreignOwner returns the instance owning the project of the namespace, if it is not this instance
+func (handler *Handler) foreignOwner(namespace string, project string) string {
+	if handler.shards == nil {
+		return ""
+	}
+	key := project
+	if namespace != "" {
+		key = namespace + "/" + project
+	}
+	if handler.shards.IsLocal(key) {
+		return ""
+	}
+
+	return handler.shards.Owner(key)
+}
+
+//forwardedByPeer returns true, if the request was forwarded by another instance of the ring
+func (handler *Handler) forwardedByPeer(c *gin.Context) bool {
+	return handler.shards != nil && handler.shards.isPeer(c.GetHeader(shardForwardedHeader))
+}
+
+//ShardMiddleware proxies requests for projects owned by another instance, a project is never served by an instance not owning it
+func (handler *Handler) ShardMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		project := projectKey(c)
+		if c.Param("project") == "" || handler.shards.IsLocal(project) {
+			c.Next()
+			return
+		}
+		// the header of a peer stops forwarding loops of instances, which disagree about the ring, other values are ignored
+		if forwarded := c.GetHeader(shardForwardedHeader); handler.shards.isPeer(forwarded) {
+			_ = c.AbortWithError(http.StatusMisdirectedRequest, errors.Errorf("Project %v forwarded by %v is owned by %v", project, forwarded, handler.shards.Owner(project)))
+			return
+		}
+
+		owner := handler.shards.Owner(project)
+		c.Request.Header.Set(shardForwardedHeader, handler.shards.self)
+		handler.logger.Debugf("proxy request for project %v to %v", project, owner)
+		handler.shards.proxies[owner].ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Shard_Owner_Is_Stable(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	shards, _ := NewShardMap("http://a:8080", []string{"http://b:8080", "http://c:8080"})
+	reordered, _ := NewShardMap("http://a:8080", []string{"http://c:8080", "http://b:8080"})
+
+	for i := 0; i < 50; i++ {
+		project := "project" + strconv.Itoa(i)
+		Ω.Expect(shards.Owner(project)).To(Equal(reordered.Owner(project)))
+	}
+}
+
+func Test_Shard_Splits_Projects_Across_Instances(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	shards, _ := NewShardMap("http://a:8080", []string{"http://b:8080"})
+
+	owners := map[string]int{}
+	for i := 0; i < 200; i++ {
+		owners[shards.Owner("project"+strconv.Itoa(i))]++
+	}
+
+	Ω.Expect(owners).To(HaveLen(2))
+	Ω.Expect(owners["http://a:8080"]).To(BeNumerically(">", 50))
+	Ω.Expect(owners["http://b:8080"]).To(BeNumerically(">", 50))
+}
+
+func Test_Shard_With_Invalid_Url(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	_, err := NewShardMap("http://a:8080", []string{"not a url"})
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Shard_Proxies_Foreign_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("from peer " + r.Header.Get(shardForwardedHeader)))
+	}))
+	defer peer.Close()
+
+	shards, _ := NewShardMap("http://self:8080", []string{peer.URL})
+	foreign, local := "", ""
+	for i := 0; foreign == "" || local == ""; i++ {
+		project := "p" + strconv.Itoa(i)
+		if shards.IsLocal(project) {
+			local = project
+		} else {
+			foreign = project
+		}
+	}
+
+	fileProvider := adapter.NewMock("1.0.0", local)
+	handler := NewHandler(NewVersion(fileProvider), nil)
+	handler.SetShardMap(shards)
+	server := httptest.NewServer(handler.GetRouter())
+	defer server.Close()
+
+	res, _ := http.Get(server.URL + "/version/" + foreign)
+	body, _ := ioutil.ReadAll(res.Body)
+	Ω.Expect(string(body)).To(Equal("from peer http://self:8080"))
+
+	res, _ = http.Get(server.URL + "/version/" + local)
+	body, _ = ioutil.ReadAll(res.Body)
+	Ω.Expect(string(body)).To(Equal("1.0.0"))
+
+	// a forwarded request for a foreign project is never served here
+	req, _ := http.NewRequest("POST", server.URL+"/patch/"+foreign, nil)
+	req.Header.Set(shardForwardedHeader, peer.URL)
+	res, _ = http.DefaultClient.Do(req)
+	Ω.Expect(res.StatusCode).To(Equal(http.StatusMisdirectedRequest))
+
+	req, _ = http.NewRequest("POST", server.URL+"/patch/"+foreign, nil)
+	req.Header.Set(shardForwardedHeader, "x")
+	res, _ = http.DefaultClient.Do(req)
+	body, _ = ioutil.ReadAll(res.Body)
+	Ω.Expect(string(body)).To(Equal("from peer http://self:8080"))
+	exists, _ := handler.version.Exists(foreign)
+	Ω.Expect(exists).To(BeFalse())
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//versionSignatureHeader carries the detached JWS of the version in the response of a change
+const versionSignatureHeader = "X-Vbump-Signature"
+
+//Signer signs the JWS signing input with an ed25519 key
+type Signer interface {
+	Sign(input []byte) ([]byte, error)
+}
+
+//jwsHeader is the protected header of the JWS, it binds the version to the project
+type jwsHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Project   string `json:"project"`
+	IssuedAt  int64  `json:"iat"`
+}
+
+//NewSigner constructs a signer with the transit key of vault:<mount>/<key> or else with the key of the PEM file
+func NewSigner(key string, vault *VaultClient) (Signer, error) {
+	if strings.HasPrefix(key, vaultSecretPrefix) {
+		return NewVaultSigner(vault, strings.TrimPrefix(key, vaultSecretPrefix))
+	}
+
+	return NewKeySigner(key)
+}
+
+//keySigner signs with a local key
+type keySigner struct {
+	key ed25519.PrivateKey
+}
+
+//NewKeySigner constructs a signer with the ed25519 private key of the PEM file in PKCS#8
+func NewKeySigner(filename string) (Signer, error) {
+	document, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot read signing key %v", filename)
+	}
+	block, _ := pem.Decode(document)
+	if block == nil {
+		return nil, errors.Errorf("Signing key %v is no PEM file", filename)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse signing key %v", filename)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("Signing key %v is no ed25519 key", filename)
+	}
+
+	return &keySigner{key: private}, nil
+}
+
+func (signer *keySigner) Sign(input []byte) ([]byte, error) {
+	return ed25519.Sign(signer.key, input), nil
+}
+
+//vaultSigner signs with an ed25519 key of the transit engine of vault, the key never leaves vault
+type vaultSigner struct {
+	vault *VaultClient
+	path  string
+}
+
+//NewVaultSigner constructs a signer with the transit key, e.g. transit/vbump for the key vbump of the engine mounted at transit
+func NewVaultSigner(vault *VaultClient, key string) (Signer, error) {
+	if vault == nil {
+		return nil, errors.Errorf("Cannot sign with vault key %v, no vault is configured with --vault-address", key)
+	}
+	index := strings.LastIndex(key, "/")
+	if index <= 0 || index == len(key)-1 {
+		return nil, errors.Errorf("Invalid vault key %v, expected <mount>/<key>", key)
+	}
+
+	return &vaultSigner{vault: vault, path: key[:index] + "/sign" + key[index:]}, nil
+}
+
+func (signer *vaultSigner) Sign(input []byte) ([]byte, error) {
+	response := struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}{}
+	if err := signer.vault.call(http.MethodPost, signer.path, map[string]string{"input": base64.StdEncoding.EncodeToString(input)}, &response); err != nil {
+		return nil, errors.Wrapf(err, "Cannot sign with vault key %v", signer.path)
+	}
+
+	// signatures look like vault:v1:<base64>
+	parts := strings.Split(response.Data.Signature, ":")
+	signature, err := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid signature of vault key %v", signer.path)
+	}
+	return signature, nil
+}
+
+//SetSigner signs the versions in the responses of changes, the key id is told in the JWS header
+func (handler *Handler) SetSigner(signer Signer, keyID string) {
+	handler.signer = signer
+	handler.signerKeyID = keyID
+}
+
+//signVersion returns the JWS with detached payload of the version, <header>..<signature>
+func (handler *Handler) signVersion(namespace string, project string, version string) (string, error) {
+	header, err := json.Marshal(jwsHeader{
+		Algorithm: "EdDSA",
+		KeyID:     handler.signerKeyID,
+		Namespace: namespace,
+		Project:   project,
+		IssuedAt:  time.Now().Unix(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Cannot encode signature header")
+	}
+
+	protected := base64.RawURLEncoding.EncodeToString(header)
+	signature, err := handler.signer.Sign([]byte(protected + "." + base64.RawURLEncoding.EncodeToString([]byte(version))))
+	if err != nil {
+		return "", err
+	}
+	return protected + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+//sign tells the signature of the version in the response header, the change is not failed, if signing fails
+func (handler *Handler) sign(context *gin.Context, version string) {
+	if handler.signer == nil {
+		return
+	}
+
+	signature, err := handler.signVersion(context.Param("namespace"), context.Param("project"), version)
+	if err != nil {
+		handler.logger.Error(err)
+		return
+	}
+	context.Header(versionSignatureHeader, signature)
+}
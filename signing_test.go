@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+//verifySignature checks the detached JWS of the version and returns its header
+func verifySignature(Ω *GomegaWithT, signature string, version string, key ed25519.PublicKey) jwsHeader {
+	parts := strings.Split(signature, ".")
+	Ω.Expect(parts).To(HaveLen(3))
+	Ω.Expect(parts[1]).To(BeEmpty())
+	signed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(ed25519.Verify(key, []byte(parts[0]+"."+base64.RawURLEncoding.EncodeToString([]byte(version))), signed)).To(BeTrue())
+
+	header := jwsHeader{}
+	document, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	Ω.Expect(json.Unmarshal(document, &header)).To(BeNil())
+	return header
+}
+
+func Test_Sign_Version_Of_Change(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	encoded, _ := x509.MarshalPKCS8PrivateKey(private)
+	dir, _ := ioutil.TempDir("", "signing")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "key.pem")
+	_ = ioutil.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: encoded}), 0600)
+	signer, err := NewSigner(filename, nil)
+	Ω.Expect(err).To(BeNil())
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetSigner(signer, "key-1")
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+	header := verifySignature(Ω, res.Header().Get(versionSignatureHeader), "1.0.1", public)
+	Ω.Expect(header.Algorithm).To(Equal("EdDSA"))
+	Ω.Expect(header.KeyID).To(Equal("key-1"))
+	Ω.Expect(header.Project).To(Equal("p1"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Header().Get(versionSignatureHeader)).To(BeEmpty())
+}
+
+func Test_Sign_Without_Signer(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Header().Get(versionSignatureHeader)).To(BeEmpty())
+}
+
+func Test_Invalid_Signing_Key(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	file, _ := ioutil.TempFile("", "key")
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString("no key")
+	file.Close()
+
+	_, err := NewSigner(file.Name(), nil)
+	Ω.Expect(err).NotTo(BeNil())
+	_, err = NewSigner("vault:transit/vbump", nil)
+	Ω.Expect(err).NotTo(BeNil())
+	_, err = NewSigner("vault:vbump", NewVaultClient("http://vault", ""))
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Sign_With_Vault_Transit_Key(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/transit/sign/vbump" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		request := struct {
+			Input string `json:"input"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		input, _ := base64.StdEncoding.DecodeString(request.Input)
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, input))
+		_, _ = w.Write([]byte(`{"data":{"signature":"vault:v1:` + signature + `"}}`))
+	}))
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "signing")
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	_ = ioutil.WriteFile(tokenFile, []byte("vault-token"), 0600)
+	signer, err := NewSigner("vault:transit/vbump", NewVaultClient(server.URL, tokenFile))
+	Ω.Expect(err).To(BeNil())
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetSigner(signer, "")
+
+	signature, err := handler.signVersion("team", "p1", "2.0.0")
+
+	Ω.Expect(err).To(BeNil())
+	header := verifySignature(Ω, signature, "2.0.0", public)
+	Ω.Expect(header.Namespace).To(Equal("team"))
+}
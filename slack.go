@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//slackMaxAge is the maximum age of a signed slack request, older requests may be replayed
+const slackMaxAge = 5 * time.Minute
+
+const slackUsage = "Usage: `/vbump major|minor|patch <project> [reason]`, `/vbump get <project>` or `/vbump set <project> <version>`, use `<namespace>/<project>` for projects of a namespace"
+
+//SlackResponse is the message answering a slash command
+type SlackResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+//SetSlackSigningSecret enables the slack slash command verified with the signing secret of the slack app
+func (handler *Handler) SetSlackSigningSecret(secret string) {
+	handler.UseSlackSigningSecret(NewSecret(secret))
+}
+
+//UseSlackSigningSecret enables the slack slash command with a signing secret, which is refreshed from its file or vault
+func (handler *Handler) UseSlackSigningSecret(secret *Secret) {
+	handler.slackSecret = secret
+}
+
+//verifySlackSignature returns an error, if the request was not signed by slack within the last minutes
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("Missing or invalid slack request timestamp")
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > slackMaxAge || age < -slackMaxAge {
+		return errors.Errorf("Slack request timestamp %v is too old", timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + timestamp + ":"))
+	_, _ = mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("Invalid slack signature")
+	}
+
+	return nil
+}
+
+//OnSlackCommand is a handler for slack slash commands like "/vbump patch myservice"
+func (handler *Handler) OnSlackCommand(context *gin.Context) {
+	body, err := ioutil.ReadAll(context.Request.Body)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Cannot read slack command"))
+		return
+	}
+	if err := verifySlackSignature(handler.slackSecret.Value(), context.Request.Header, body, time.Now()); err != nil {
+		_ = context.AbortWithError(http.StatusUnauthorized, err)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid slack command"))
+		return
+	}
+
+	args := strings.Fields(form.Get("text"))
+	if len(args) >= 2 {
+		namespace, project := handler.slackProject(args[1])
+		if owner := handler.foreignOwner(namespace, project); owner != "" {
+			if handler.forwardedByPeer(context) {
+				context.JSON(http.StatusOK, SlackResponse{ResponseType: "ephemeral", Text: fmt.Sprintf("Project %v is owned by %v", args[1], owner)})
+				return
+			}
+			// the owner verifies the signature of slack again
+			context.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+			context.Request.Header.Set(shardForwardedHeader, handler.shards.self)
+			handler.logger.Debugf("proxy slack command for project %v to %v", args[1], owner)
+			handler.shards.proxies[owner].ServeHTTP(context.Writer, context.Request)
+			return
+		}
+	}
+
+	text, err := handler.slackCommand(form.Get("user_name"), args, context.Query("propagate") == "true")
+	if err != nil {
+		handler.logger.Errorf("slack command %q of %v failed: %v", form.Get("text"), form.Get("user_name"), err)
+		context.JSON(http.StatusOK, SlackResponse{ResponseType: "ephemeral", Text: err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, SlackResponse{ResponseType: "in_channel", Text: text})
+}
+
+//slackProject returns the namespace and the project of a project argument like "payments/billing"
+func (handler *Handler) slackProject(arg string) (string, string) {
+	namespace, project := "", arg
+	if parts := strings.SplitN(project, "/", 2); len(parts) == 2 {
+		namespace, project = parts[0], parts[1]
+	}
+
+	return namespace, handler.projectName(project)
+}
+
+//slackCommand performs the command of a slack user and returns the message for the channel, bumps are propagated to the dependents on request
+func (handler *Handler) slackCommand(user string, args []string, propagate bool) (string, error) {
+	if len(args) < 2 {
+		return "", errors.New(slackUsage)
+	}
+
+	namespace, project := handler.slackProject(args[1])
+	if err := validateProjectName(project); err != nil {
+		return "", err
+	}
+	service := handler.version
+	if namespace != "" {
+		namespaced, err := handler.version.Namespace(namespace)
+		if err != nil {
+			return "", err
+		}
+		service = namespaced
+	}
+	service = service.WithAnnotation(Annotation{Reason: strings.Join(args[2:], " "), Actor: "slack:" + user, Client: "slack"})
+	release := func(bool) {}
+	if handler.quotas != nil && namespace != "" && args[0] != "get" {
+		reserved, _, err := handler.quotas.reserve(namespace, service, project)
+		if err != nil {
+			return "", err
+		}
+		release = reserved
+	}
+	changed := false
+	defer func() { release(changed) }()
+
+	var entry *HistoryEntry
+	var err error
+	operation := args[0]
+	switch args[0] {
+	case "get":
+		version, err := service.GetVersion(project)
+		if err != nil {
+			return "", err
+		}
+		if version == "" {
+			return "", errors.Errorf("Unknown project %v", args[1])
+		}
+		return fmt.Sprintf("%v is at *%v*", args[1], service.Display(project, version)), nil
+	case "major", "minor", "patch":
+		operation = "bump"
+		entry, err = service.Bump(project, args[0])
+		if err == nil {
+			countBump(namespace, project, args[0])
+		}
+	case "set":
+		if len(args) != 3 {
+			return "", errors.New(slackUsage)
+		}
+		service = service.WithAnnotation(Annotation{Actor: "slack:" + user, Client: "slack"})
+		entry, err = service.Set(project, args[2])
+	default:
+		return "", errors.New(slackUsage)
+	}
+	if err != nil {
+		countFailure(namespace, project, operation, err)
+		return "", err
+	}
+
+	changed = true
+	countClientChange(entry)
+	handler.publish(namespace, project, service, entry)
+	handler.auditLog().Infof("slack user %v changed %v from %v to %v", user, args[1], entry.Previous, entry.Version)
+	if propagate && operation == "bump" {
+		handler.propagateBump(namespace, project, service, entry, handler.auditLog())
+	}
+	return eventText(newEvent(namespace, project, entry)), nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func slackRequest(secret string, text string, timestamp time.Time) *http.Request {
+	body := url.Values{"command": {"/vbump"}, "text": {text}, "user_name": {"alice"}}.Encode()
+	seconds := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + seconds + ":" + body))
+
+	req, _ := http.NewRequest("POST", "/slack/command", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", seconds)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func slackHandler(version *Version) *Handler {
+	handler := NewHandler(version, nil)
+	handler.SetSlackSigningSecret("s3cr3t")
+	tokens := NewTokenStore()
+	tokens.Add("ci", "t0k3n", "*")
+	handler.SetTokenStore(tokens)
+	return handler
+}
+
+func Test_Slack_Command_Bumps_Project(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "myservice"))
+
+	res := httptest.NewRecorder()
+	slackHandler(version).GetRouter().ServeHTTP(res, slackRequest("s3cr3t", "patch myservice hotfix for login", time.Now()))
+	response := SlackResponse{}
+	_ = json.Unmarshal(res.Body.Bytes(), &response)
+	history, _ := version.History("myservice")
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(response).To(Equal(SlackResponse{ResponseType: "in_channel", Text: "vbump: patch bump of myservice to 1.0.1 by slack:alice (hotfix for login)"}))
+	Ω.Expect(history[0].Client).To(Equal("slack"))
+}
+
+func Test_Slack_Command_Get_And_Set(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "myservice"))
+	router := slackHandler(version).GetRouter()
+
+	set := httptest.NewRecorder()
+	router.ServeHTTP(set, slackRequest("s3cr3t", "set payments/billing 2.0.0", time.Now()))
+	get := httptest.NewRecorder()
+	router.ServeHTTP(get, slackRequest("s3cr3t", "get payments/billing", time.Now()))
+	namespaced, _ := version.Namespace("payments")
+	current, _ := namespaced.GetVersion("billing")
+
+	Ω.Expect(current).To(Equal("2.0.0"))
+	Ω.Expect(get.Body.String()).To(ContainSubstring("payments/billing is at *2.0.0*"))
+}
+
+func Test_Slack_Command_Errors_Are_Ephemeral(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "myservice"))
+
+	res := httptest.NewRecorder()
+	slackHandler(version).GetRouter().ServeHTTP(res, slackRequest("s3cr3t", "deploy myservice", time.Now()))
+	response := SlackResponse{}
+	_ = json.Unmarshal(res.Body.Bytes(), &response)
+
+	Ω.Expect(response.ResponseType).To(Equal("ephemeral"))
+	Ω.Expect(response.Text).To(HavePrefix("Usage:"))
+}
+
+func Test_Slack_Command_Verifies_Signature(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "myservice"))
+	router := slackHandler(version).GetRouter()
+
+	forged := httptest.NewRecorder()
+	router.ServeHTTP(forged, slackRequest("guessed", "patch myservice", time.Now()))
+	replayed := httptest.NewRecorder()
+	router.ServeHTTP(replayed, slackRequest("s3cr3t", "patch myservice", time.Now().Add(-10*time.Minute)))
+	current, _ := version.GetVersion("myservice")
+
+	Ω.Expect(forged.Code).To(Equal(http.StatusUnauthorized))
+	Ω.Expect(replayed.Code).To(Equal(http.StatusUnauthorized))
+	Ω.Expect(current).To(Equal("1.0.0"))
+}
+
+func Test_Slack_Command_Is_Disabled_Without_Secret(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "myservice")), nil)
+
+	res := httptest.NewRecorder()
+	handler.GetRouter().ServeHTTP(res, slackRequest("", "patch myservice", time.Now()))
+
+	Ω.Expect(res.Code).To(Equal(http.StatusNotFound))
+}
+
+func Test_Slack_Command_Is_Proxied_To_Owner(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		text := "from peer " + r.Header.Get(shardForwardedHeader)
+		if err := verifySlackSignature("s3cr3t", r.Header, body, time.Now()); err != nil {
+			text = err.Error()
+		}
+		_ = json.NewEncoder(w).Encode(SlackResponse{ResponseType: "in_channel", Text: text})
+	}))
+	defer peer.Close()
+	shards, _ := NewShardMap("http://self:8080", []string{peer.URL})
+	foreign := ""
+	for i := 0; foreign == ""; i++ {
+		if project := "p" + strconv.Itoa(i); !shards.IsLocal(project) {
+			foreign = project
+		}
+	}
+	version := NewVersion(adapter.NewMock("1.0.0", "myservice"))
+	handler := slackHandler(version)
+	handler.SetShardMap(shards)
+	server := httptest.NewServer(handler.GetRouter())
+	defer server.Close()
+	command := func(forwarded string) string {
+		req := slackRequest("s3cr3t", "patch "+foreign, time.Now())
+		req.URL, _ = url.Parse(server.URL + "/slack/command")
+		if forwarded != "" {
+			req.Header.Set(shardForwardedHeader, forwarded)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err.Error()
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		return string(body)
+	}
+
+	proxied := command("")
+	forwarded := command(peer.URL)
+	exists, _ := version.Exists(foreign)
+
+	Ω.Expect(proxied).To(ContainSubstring(`"text":"from peer http://self:8080"`))
+	Ω.Expect(forwarded).To(ContainSubstring("ephemeral"))
+	Ω.Expect(exists).To(BeFalse())
+}
+
+func Test_Slack_Command_Respects_Namespace_Quota(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "myservice"))
+	handler := slackHandler(version)
+	quotas := NewQuotas(Quota{})
+	quotas.Set("team", Quota{MaxBumpsPerHour: 1})
+	handler.SetQuotas(quotas)
+	router := handler.GetRouter()
+
+	router.ServeHTTP(httptest.NewRecorder(), slackRequest("s3cr3t", "patch team/billing", time.Now()))
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, slackRequest("s3cr3t", "patch team/billing", time.Now()))
+	response := SlackResponse{}
+	_ = json.Unmarshal(res.Body.Bytes(), &response)
+	namespaced, _ := version.Namespace("team")
+	current, _ := namespaced.GetVersion("billing")
+
+	Ω.Expect(response.ResponseType).To(Equal("ephemeral"))
+	Ω.Expect(response.Text).To(Equal("Namespace team exceeded its bumps per hour"))
+	Ω.Expect(current).To(Equal("0.0.1"))
+}
+
+func Test_Slack_Command_Propagates_Bump(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "library-x"))
+	_, _ = version.SetVersion("service-a", "2.0.0")
+	_ = version.SetMetadata("library-x", &Metadata{Config: Config{Dependents: []string{"service-a"}}})
+
+	res := httptest.NewRecorder()
+	req := slackRequest("s3cr3t", "minor library-x", time.Now())
+	req.URL.RawQuery = "propagate=true"
+	slackHandler(version).GetRouter().ServeHTTP(res, req)
+	current, _ := version.GetVersion("service-a")
+
+	Ω.Expect(res.Body.String()).To(ContainSubstring("1.1.0"))
+	Ω.Expect(current).To(Equal("2.0.1"))
+}
+
+func Test_Slack_Command_Rejects_Invalid_Project_Names(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "myservice"))
+
+	res := httptest.NewRecorder()
+	slackHandler(version).GetRouter().ServeHTTP(res, slackRequest("s3cr3t", "patch ..x", time.Now()))
+	response := SlackResponse{}
+	_ = json.Unmarshal(res.Body.Bytes(), &response)
+
+	Ω.Expect(response.ResponseType).To(Equal("ephemeral"))
+	Ω.Expect(response.Text).To(ContainSubstring("invalid project name"))
+}
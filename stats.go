@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mostBumpedLimit = 10
+
+var statsWindows = []struct {
+	name   string
+	length time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+//Stats aggregates the bumps of all projects
+type Stats struct {
+	Projects     int                       `json:"projects"`
+	Bumps        map[string]map[string]int `json:"bumps"`
+	MostBumped   []ProjectBumps            `json:"mostBumped"`
+	LastActivity *time.Time                `json:"lastActivity,omitempty"`
+}
+
+//ProjectBumps is the number of bumps of a single project
+type ProjectBumps struct {
+	Project string `json:"project"`
+	Bumps   int    `json:"bumps"`
+}
+
+//Stats returns the total of projects, changes per element and time window, the most bumped projects and the last activity
+func (v *Version) Stats() (*Stats, error) {
+	projects, err := v.Projects()
+	if err != nil {
+		return nil, err
+	}
+
+	now := v.now()
+	stats := &Stats{Projects: len(projects), Bumps: map[string]map[string]int{}, MostBumped: []ProjectBumps{}}
+	for _, window := range statsWindows {
+		stats.Bumps[window.name] = map[string]int{}
+	}
+
+	for _, project := range projects {
+		history, err := v.History(project)
+		if err != nil {
+			return nil, err
+		}
+
+		bumps := 0
+		for _, entry := range history {
+			if entry.Element != "set" && entry.Element != createElement {
+				bumps++
+			}
+			for _, window := range statsWindows {
+				if now.Sub(entry.Time) <= window.length {
+					stats.Bumps[window.name][entry.Element]++
+				}
+			}
+		}
+		if bumps > 0 {
+			stats.MostBumped = append(stats.MostBumped, ProjectBumps{Project: project, Bumps: bumps})
+		}
+
+		activity, err := v.GetActivity(project)
+		if err != nil {
+			return nil, err
+		}
+		if activity != nil {
+			last := activity.LastActivity()
+			if stats.LastActivity == nil || last.After(*stats.LastActivity) {
+				stats.LastActivity = &last
+			}
+		}
+	}
+
+	sort.SliceStable(stats.MostBumped, func(i, j int) bool { return stats.MostBumped[i].Bumps > stats.MostBumped[j].Bumps })
+	if len(stats.MostBumped) > mostBumpedLimit {
+		stats.MostBumped = stats.MostBumped[:mostBumpedLimit]
+	}
+
+	return stats, nil
+}
+
+//OnStats is a handler for aggregated statistics over all projects
+func (handler *Handler) OnStats(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	stats, err := service.Stats()
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, stats)
+}
+
+//OnGetHistory is a handler for getting all changes of a given project
+func (handler *Handler) OnGetHistory(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	history, err := service.History(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Stats(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("", ""))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	version.now = func() time.Time { return now }
+
+	_, _ = version.BumpMinor("p1")
+	now = now.Add(10 * 24 * time.Hour)
+	_, _ = version.BumpPatch("p1")
+	_, _ = version.BumpPatch("p1")
+	_, _ = version.BumpMajor("p2")
+	_, _ = version.SetVersion("p3", "1.0")
+	stats, err := version.Stats()
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(stats.Projects).To(Equal(3))
+	Ω.Expect(stats.Bumps["24h"]).To(Equal(map[string]int{"patch": 2, "major": 1, "set": 1}))
+	Ω.Expect(stats.Bumps["30d"]).To(Equal(map[string]int{"minor": 1, "patch": 2, "major": 1, "set": 1}))
+	Ω.Expect(stats.MostBumped).To(Equal([]ProjectBumps{{"p1", 3}, {"p2", 1}}))
+	Ω.Expect(*stats.LastActivity).To(Equal(now))
+}
+
+func Test_Stats_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("", "")), nil)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/stats", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"projects":0,"bumps":{"24h":{},"7d":{},"30d":{}},"mostBumped":[]}`))
+}
+
+func Test_History_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0", "p1"))
+	version.now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/minor/p1", nil)
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/history/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(MatchJSON(`[{"time":"2024-01-01T00:00:00Z","element":"minor","previous":"1.0","version":"1.1"}]`))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+//StorageError is a failure of the storage backend
+type StorageError struct {
+	Operation string
+	Err       error
+}
+
+func (err *StorageError) Error() string {
+	return err.Err.Error()
+}
+
+//Is returns true for the ErrStorage category
+func (err *StorageError) Is(target error) bool {
+	return target == ErrStorage
+}
+
+//slowStorage logs storage operations and lock waits taking at least the threshold, zero disables it
+type slowStorage struct {
+	threshold time.Duration
+	logger    *log.Logger
+}
+
+//LogSlowStorage logs storage operations and lock waits taking at least the threshold with their project and operation
+func (v *Version) LogSlowStorage(threshold time.Duration, logger *log.Logger) {
+	v.slow.threshold, v.slow.logger = threshold, logger
+}
+
+func (slow *slowStorage) log(operation string, project string, duration time.Duration) {
+	if slow.threshold <= 0 || duration < slow.threshold || slow.logger == nil {
+		return
+	}
+
+	slow.logger.WithFields(log.Fields{"operation": operation, "project": project, "duration": duration.String()}).Warnf("slow %v of project %v took %v", operation, project, duration)
+}
+
+//meteredProvider counts the errors and observes the duration of the storage backend and marks its errors as storage errors
+type meteredProvider struct {
+	provider  adapter.IFileProvider
+	namespace string
+	slow      *slowStorage
+}
+
+//metered wraps the provider to count its errors, providers are wrapped once only
+func metered(provider adapter.IFileProvider, slow *slowStorage) adapter.IFileProvider {
+	if _, ok := provider.(*meteredProvider); ok {
+		return provider
+	}
+
+	return &meteredProvider{provider: provider, slow: slow}
+}
+
+//observe observes the duration of the operation since start, it is deferred with the start time
+func (m *meteredProvider) observe(operation string, project string, start time.Time) {
+	duration := time.Since(start)
+	storageDuration.With(prometheus.Labels{"operation": operation}).Observe(duration.Seconds())
+	if m.namespace != "" {
+		project = m.namespace + "/" + project
+	}
+	m.slow.log(operation, project, duration)
+}
+
+func storageFailure(operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	storageErrors.With(prometheus.Labels{"operation": operation}).Inc()
+	return &StorageError{Operation: operation, Err: err}
+}
+
+func (m *meteredProvider) ReadVersion(project string) (string, error) {
+	defer m.observe("read_version", project, time.Now())
+	version, err := m.provider.ReadVersion(project)
+	return version, storageFailure("read_version", err)
+}
+
+func (m *meteredProvider) StoreVersion(project string, version string) error {
+	defer m.observe("store_version", project, time.Now())
+	return storageFailure("store_version", m.provider.StoreVersion(project, version))
+}
+
+func (m *meteredProvider) ListProjects() ([]string, error) {
+	defer m.observe("list_projects", "", time.Now())
+	projects, err := m.provider.ListProjects()
+	return projects, storageFailure("list_projects", err)
+}
+
+func (m *meteredProvider) ReadDocument(kind string, project string) ([]byte, error) {
+	defer m.observe("read_document", project, time.Now())
+	document, err := m.provider.ReadDocument(kind, project)
+	return document, storageFailure("read_document", err)
+}
+
+func (m *meteredProvider) StoreDocument(kind string, project string, document []byte) error {
+	defer m.observe("store_document", project, time.Now())
+	return storageFailure("store_document", m.provider.StoreDocument(kind, project, document))
+}
+
+func (m *meteredProvider) DeleteDocument(kind string, project string) error {
+	defer m.observe("delete_document", project, time.Now())
+	return storageFailure("delete_document", m.provider.DeleteDocument(kind, project))
+}
+
+func (m *meteredProvider) ListDocuments(kind string) ([]string, error) {
+	defer m.observe("list_documents", "", time.Now())
+	projects, err := m.provider.ListDocuments(kind)
+	return projects, storageFailure("list_documents", err)
+}
+
+func (m *meteredProvider) AppendHistory(project string, entry []byte) error {
+	defer m.observe("append_history", project, time.Now())
+	return storageFailure("append_history", m.provider.AppendHistory(project, entry))
+}
+
+func (m *meteredProvider) ReadHistory(project string) ([][]byte, error) {
+	defer m.observe("read_history", project, time.Now())
+	history, err := m.provider.ReadHistory(project)
+	return history, storageFailure("read_history", err)
+}
+
+func (m *meteredProvider) ReplaceHistory(project string, entries [][]byte) error {
+	defer m.observe("replace_history", project, time.Now())
+	return storageFailure("replace_history", m.provider.ReplaceHistory(project, entries))
+}
+
+func (m *meteredProvider) Namespace(namespace string) (adapter.IFileProvider, error) {
+	defer m.observe("namespace", "", time.Now())
+	provider, err := m.provider.Namespace(namespace)
+	if err != nil {
+		return nil, storageFailure("namespace", err)
+	}
+
+	return &meteredProvider{provider: provider, namespace: namespace, slow: m.slow}, nil
+}
+
+func (m *meteredProvider) ListNamespaces() ([]string, error) {
+	defer m.observe("list_namespaces", "", time.Now())
+	namespaces, err := m.provider.ListNamespaces()
+	return namespaces, storageFailure("list_namespaces", err)
+}
+
+//errorClass classifies the error of a failed change for the metrics
+func errorClass(err error) string {
+	if _, ok := errors.Cause(err).(*PolicyViolation); ok {
+		return "policy"
+	}
+
+	switch {
+	case errors.Is(err, ErrStorage):
+		return "storage"
+	case errors.Is(err, ErrArchived):
+		return "archived"
+	case errors.Is(err, ErrUnknownProject):
+		return "unknown_project"
+	case errors.Is(err, ErrSchemeHook):
+		return "scheme"
+	case errors.Is(err, ErrAnalyzerHook):
+		return "analyzer"
+	case errors.Is(err, ErrConflict), errors.Is(err, ErrNoReservation):
+		return "conflict"
+	}
+
+	return "invalid"
+}
+
+//countFailure counts the failed change of the project of the request
+func countFailure(namespace string, project string, operation string, err error) {
+	failedChanges.With(prometheus.Labels{"namespace": namespace, "project": projectLabels.Of(namespace, project), "operation": operation, "class": errorClass(err)}).Inc()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	log "github.com/sirupsen/logrus"
+)
+
+type brokenProvider struct {
+	adapter.IFileProvider
+}
+
+func (provider *brokenProvider) StoreVersion(project string, version string) error {
+	return errors.New("disk full")
+}
+
+func Test_Storage_Errors_Are_Counted(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	before := testutil.ToFloat64(storageErrors.With(prometheus.Labels{"operation": "store_version"}))
+	version := NewVersion(&brokenProvider{adapter.NewMock("1.0.0", "p1")})
+
+	_, err := version.BumpPatch("p1")
+
+	Ω.Expect(err).NotTo(BeNil())
+	Ω.Expect(errorClass(err)).To(Equal("storage"))
+	Ω.Expect(testutil.ToFloat64(storageErrors.With(prometheus.Labels{"operation": "store_version"}))).To(Equal(before + 1))
+}
+
+type slowStoreProvider struct {
+	adapter.IFileProvider
+}
+
+func (provider *slowStoreProvider) StoreVersion(project string, version string) error {
+	time.Sleep(20 * time.Millisecond)
+	return provider.IFileProvider.StoreVersion(project, version)
+}
+
+func (provider *slowStoreProvider) Namespace(namespace string) (adapter.IFileProvider, error) {
+	namespaced, err := provider.IFileProvider.Namespace(namespace)
+	return &slowStoreProvider{namespaced}, err
+}
+
+func Test_Slow_Storage_Operations_Are_Logged(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	logged := &bytes.Buffer{}
+	logger := log.New()
+	logger.Out = logged
+	version := NewVersion(&slowStoreProvider{adapter.NewMock("1.0.0", "p1")})
+	version.LogSlowStorage(10*time.Millisecond, logger)
+	namespaced, _ := version.Namespace("team")
+
+	_, err := namespaced.BumpPatch("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(logged.String()).To(ContainSubstring("slow store_version of project team/p1"))
+	Ω.Expect(logged.String()).To(ContainSubstring("operation=store_version"))
+	Ω.Expect(logged.String()).NotTo(ContainSubstring("read_version"))
+	Ω.Expect(testutil.CollectAndCount(storageDuration)).To(BeNumerically(">=", 2))
+}
+
+func Test_Slow_Lock_Waits_Are_Logged(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	logged := &bytes.Buffer{}
+	logger := log.New()
+	logger.Out = logged
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.LogSlowStorage(10*time.Millisecond, logger)
+
+	unlock, _ := version.locks.Lock("/p1")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		unlock()
+	}()
+	_, err := version.BumpPatch("p1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(logged.String()).To(ContainSubstring("slow lock of project p1 took"))
+}
+
+func Test_Error_Class(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(errorClass(errors.Wrap(&PolicyViolation{Rule: "maxStep"}, "bump"))).To(Equal("policy"))
+	Ω.Expect(errorClass(errors.Wrap(ErrArchived, "bump"))).To(Equal("archived"))
+	Ω.Expect(errorClass(ErrUnknownProject)).To(Equal("unknown_project"))
+	Ω.Expect(errorClass(errors.New("1 0 is not a valid version"))).To(Equal("invalid"))
+}
+
+func Test_Failed_Bumps_Are_Counted(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	labels := prometheus.Labels{"namespace": "", "project": "failing", "operation": "set", "class": "invalid"}
+	before := testutil.ToFloat64(failedChanges.With(labels))
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "failing")), nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/version/failing/x.y", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusUnprocessableEntity))
+	Ω.Expect(testutil.ToFloat64(failedChanges.With(labels))).To(Equal(before + 1))
+}
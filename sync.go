@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+)
+
+//DesiredState is the state of projects managed declaratively
+type DesiredState struct {
+	Projects []DesiredProject `json:"projects"`
+	Prune    bool             `json:"prune,omitempty"`
+}
+
+//DesiredProject is the desired state of a single project, the version only applies to new projects
+type DesiredProject struct {
+	Name    string            `json:"name"`
+	Version string            `json:"version,omitempty"`
+	Config  *Config           `json:"config,omitempty"`
+	Aliases map[string]string `json:"aliases,omitempty"`
+}
+
+//SyncChange is a single change made to converge a project to its desired state
+type SyncChange struct {
+	Project string      `json:"project"`
+	Action  string      `json:"action"`
+	Before  interface{} `json:"before,omitempty"`
+	After   interface{} `json:"after,omitempty"`
+}
+
+//Sync converges the projects to the desired state and returns the changes, dry run only computes them
+func (v *Version) Sync(state *DesiredState, dryRun bool) ([]SyncChange, error) {
+	desired := map[string]bool{}
+	for _, project := range state.Projects {
+		if project.Name == "" {
+			return nil, errors.New("Invalid project without name")
+		}
+		if project.Config != nil {
+			if err := project.Config.Validate(); err != nil {
+				return nil, errors.Wrapf(err, "Invalid config of project %v", project.Name)
+			}
+		}
+		desired[project.Name] = true
+	}
+
+	changes := []SyncChange{}
+	for _, project := range state.Projects {
+		changed, err := v.syncProject(project, dryRun)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, changed...)
+	}
+
+	if state.Prune {
+		projects, err := v.ListProjects(ProjectFilter{})
+		if err != nil {
+			return changes, err
+		}
+		for _, project := range projects {
+			if desired[project.Name] {
+				continue
+			}
+			if !dryRun {
+				if err := v.Archive(project.Name); err != nil {
+					return changes, err
+				}
+			}
+			changes = append(changes, SyncChange{Project: project.Name, Action: "archive"})
+		}
+	}
+
+	return changes, nil
+}
+
+func (v *Version) syncProject(project DesiredProject, dryRun bool) ([]SyncChange, error) {
+	changes := []SyncChange{}
+	exists, err := v.Exists(project.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		version := v.createVersionOf(project.Name, project.Version)
+		if !dryRun {
+			entry, err := v.Create(project.Name, version, nil)
+			if err != nil {
+				return nil, err
+			}
+			version = entry.Version
+		}
+		changes = append(changes, SyncChange{Project: project.Name, Action: "create", After: version})
+	}
+
+	archived, err := v.IsArchived(project.Name)
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		if !dryRun {
+			if err := v.Unarchive(project.Name); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, SyncChange{Project: project.Name, Action: "unarchive"})
+	}
+
+	meta, err := v.GetMetadata(project.Name)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		meta = &Metadata{}
+	}
+	if project.Config != nil && !sameJSON(meta.Config, *project.Config) {
+		changes = append(changes, SyncChange{Project: project.Name, Action: "config", Before: meta.Config, After: *project.Config})
+		meta.Config = *project.Config
+		if !dryRun {
+			if err := v.SetMetadata(project.Name, meta); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if project.Aliases != nil {
+		aliases, err := v.Aliases(project.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !sameJSON(aliases, project.Aliases) {
+			changes = append(changes, SyncChange{Project: project.Name, Action: "aliases", Before: aliases, After: project.Aliases})
+			if !dryRun {
+				if err := v.storeAliases(project.Name, project.Aliases); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func sameJSON(a interface{}, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+//OnSync is a handler for converging projects to a desired state document, ?dryRun=true only returns the changes
+func (handler *Handler) OnSync(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	state := &DesiredState{}
+	if err := context.ShouldBindBodyWith(state, binding.JSON); err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Wrap(err, "Invalid desired state"))
+		return
+	}
+
+	dryRun := context.Query("dryRun") == "true"
+	changes, err := service.Sync(state, dryRun)
+	if err != nil {
+		_ = context.AbortWithError(changeStatus(err, http.StatusBadRequest), err)
+		return
+	}
+
+	if !dryRun {
+		handler.auditLog().Infof("synced %v changes to desired state", len(changes))
+	}
+	context.JSON(http.StatusOK, gin.H{"dryRun": dryRun, "changes": changes})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Sync_Converges_Projects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.SetMetadata("p1", &Metadata{Owner: "payments"})
+	_, _ = version.Set("p3", "3.0.0")
+	state := &DesiredState{Prune: true, Projects: []DesiredProject{
+		{Name: "p1", Config: &Config{Prefix: "v"}, Aliases: map[string]string{"stable": "1.0.0"}},
+		{Name: "p2", Version: "2.0.0"},
+	}}
+
+	changes, err := version.Sync(state, false)
+	meta, _ := version.GetMetadata("p1")
+	aliases, _ := version.Aliases("p1")
+	created, _ := version.GetVersion("p2")
+	archived, _ := version.IsArchived("p3")
+	again, errAgain := version.Sync(state, false)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(changes).To(HaveLen(4))
+	Ω.Expect(changes[0].Action).To(Equal("config"))
+	Ω.Expect(changes[1].Action).To(Equal("aliases"))
+	Ω.Expect(changes[2]).To(Equal(SyncChange{Project: "p2", Action: "create", After: "2.0.0"}))
+	Ω.Expect(changes[3]).To(Equal(SyncChange{Project: "p3", Action: "archive"}))
+	Ω.Expect(meta.Owner).To(Equal("payments"))
+	Ω.Expect(meta.Prefix).To(Equal("v"))
+	Ω.Expect(aliases).To(Equal(map[string]string{"stable": "1.0.0"}))
+	Ω.Expect(created).To(Equal("2.0.0"))
+	Ω.Expect(archived).To(BeTrue())
+	Ω.Expect(errAgain).To(BeNil())
+	Ω.Expect(again).To(BeEmpty())
+}
+
+func Test_Sync_Dry_Run(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_ = version.Archive("p1")
+
+	changes, err := version.Sync(&DesiredState{Projects: []DesiredProject{{Name: "p1"}, {Name: "p2"}}}, true)
+	archived, _ := version.IsArchived("p1")
+	exists, _ := version.Exists("p2")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(changes).To(Equal([]SyncChange{{Project: "p1", Action: "unarchive"}, {Project: "p2", Action: "create", After: "0.0.0"}}))
+	Ω.Expect(archived).To(BeTrue())
+	Ω.Expect(exists).To(BeFalse())
+}
+
+func Test_Sync_Invalid_State(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+
+	_, errName := version.Sync(&DesiredState{Projects: []DesiredProject{{}}}, false)
+	_, errConfig := version.Sync(&DesiredState{Projects: []DesiredProject{{Name: "p2"}, {Name: "p1", Config: &Config{Prefix: "x"}}}}, false)
+	exists, _ := version.Exists("p2")
+
+	Ω.Expect(errName).NotTo(BeNil())
+	Ω.Expect(errConfig).NotTo(BeNil())
+	Ω.Expect(exists).To(BeFalse())
+}
+
+func Test_Sync_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	tokens := NewTokenStore()
+	tokens.Add("ci", "secret", "*", adminScope)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+	handler.SetTokenStore(tokens)
+	body, _ := json.Marshal(DesiredState{Projects: []DesiredProject{{Name: "p2", Version: "0.1.0"}}})
+
+	req, _ := http.NewRequest("POST", "/sync?dryRun=true", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler.GetRouter().ServeHTTP(w, req)
+
+	Ω.Expect(w.Code).To(Equal(http.StatusOK))
+	Ω.Expect(w.Body.String()).To(MatchJSON(`{"dryRun":true,"changes":[{"project":"p2","action":"create","after":"0.1.0"}]}`))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+var validCommit = regexp.MustCompile("^[A-Za-z0-9]{1,40}$")
+
+//DockerTags returns the image tags of a version: the version, its minor and major release and latest, prereleases only get their own tag
+func DockerTags(version string, commit string) []string {
+	// docker tags must not contain the "+" of build metadata
+	tag := strings.Replace(version, "+", "_", -1)
+	tags := []string{tag}
+	if prereleaseOf(version) == "" {
+		parts := strings.Split(version, ".")
+		for i := len(parts) - 1; i > 0; i-- {
+			tags = append(tags, strings.Join(parts[:i], "."))
+		}
+	}
+	if commit != "" {
+		tags = append(tags, tag+"-"+commit)
+	}
+	if prereleaseOf(version) == "" {
+		tags = append(tags, latestAlias)
+	}
+
+	return tags
+}
+
+//OnTags is a handler for suggesting the docker image tags of the current version of a given project, optionally for a commit
+func (handler *Handler) OnTags(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	commit := context.Query("sha")
+	if commit != "" && !validCommit.MatchString(commit) {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Errorf("%v is not a valid commit sha", commit))
+		return
+	}
+
+	version, err := service.GetVersion(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if version == "" {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No version for project %v", projectKey(context)))
+		return
+	}
+
+	tags := DockerTags(version, commit)
+	if context.Query("format") == "text" {
+		context.String(http.StatusOK, "%s\n", strings.Join(tags, "\n"))
+		return
+	}
+
+	context.JSON(http.StatusOK, tags)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Docker_Tags(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(DockerTags("1.4.2", "abc123")).To(Equal([]string{"1.4.2", "1.4", "1", "1.4.2-abc123", "latest"}))
+	Ω.Expect(DockerTags("1.4", "")).To(Equal([]string{"1.4", "1", "latest"}))
+	Ω.Expect(DockerTags("1.5.0-rc.1+build.5", "abc123")).To(Equal([]string{"1.5.0-rc.1_build.5", "1.5.0-rc.1_build.5-abc123"}))
+}
+
+func Test_Tags_Route(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.4.2", "myservice")), nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tags/myservice?sha=abc123&format=text", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+	invalid := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tags/myservice?sha=abc.rm", nil)
+	handler.GetRouter().ServeHTTP(invalid, req)
+	unknown := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tags/other", nil)
+	handler.GetRouter().ServeHTTP(unknown, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(Equal("1.4.2\n1.4\n1\n1.4.2-abc123\nlatest\n"))
+	Ω.Expect(invalid.Code).To(Equal(http.StatusBadRequest))
+	Ω.Expect(unknown.Code).To(Equal(http.StatusNotFound))
+}
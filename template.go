@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"text/template"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//Templates are go templates of a project rendered with the event of every change
+type Templates struct {
+	ReleaseNotes string `json:"releaseNotes,omitempty"`
+	Payload      string `json:"payload,omitempty"`
+}
+
+//Validate returns an error, if a template can't be parsed
+func (templates *Templates) Validate() error {
+	if templates == nil {
+		return nil
+	}
+
+	if _, err := parseTemplate("releaseNotes", templates.ReleaseNotes); err != nil {
+		return err
+	}
+	if _, err := parseTemplate("payload", templates.Payload); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func parseTemplate(name string, text string) (*template.Template, error) {
+	parsed, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid %v template", name)
+	}
+
+	return parsed, nil
+}
+
+func renderTemplate(name string, text string, event Event) (string, error) {
+	parsed, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	rendered := &bytes.Buffer{}
+	if err := parsed.Execute(rendered, event); err != nil {
+		return "", errors.Wrapf(err, "Cannot render %v template", name)
+	}
+
+	return rendered.String(), nil
+}
+
+//releaseNotes renders the release notes template of the project or the default text for the event
+func releaseNotes(event Event, meta *Metadata) (string, error) {
+	if meta == nil || meta.Templates == nil || meta.Templates.ReleaseNotes == "" {
+		return eventText(event), nil
+	}
+
+	return renderTemplate("releaseNotes", meta.Templates.ReleaseNotes, event)
+}
+
+//OnReleaseNotes is a handler for rendering the release notes of the last change of a given project
+func (handler *Handler) OnReleaseNotes(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	project := context.Param("project")
+	history, err := service.History(project)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if len(history) == 0 {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No changes for project %v", projectKey(context)))
+		return
+	}
+
+	meta, err := service.GetMetadata(project)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	notes, err := releaseNotes(newEvent(context.Param("namespace"), project, &history[len(history)-1]), meta)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	context.String(http.StatusOK, "%s", notes)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Validate_Templates(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect((&Templates{ReleaseNotes: "{{.Project}} {{.Version}}"}).Validate()).To(BeNil())
+	Ω.Expect((&Templates{Payload: "{{.Project"}).Validate()).NotTo(BeNil())
+	Ω.Expect((*Templates)(nil).Validate()).To(BeNil())
+}
+
+func Test_Release_Notes_With_Template(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	event := Event{Project: "p1", Element: "minor", Previous: "1.0", Version: "1.1", Actor: "ci", Reason: "feature"}
+	meta := &Metadata{Config: Config{Templates: &Templates{ReleaseNotes: "{{.Project}} {{.Previous}} -> {{.Version}} ({{.Element}}, {{.Actor}}: {{.Reason}})"}}}
+
+	actual, err := releaseNotes(event, meta)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal("p1 1.0 -> 1.1 (minor, ci: feature)"))
+}
+
+func Test_Release_Notes_With_Unknown_Variable(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	meta := &Metadata{Config: Config{Templates: &Templates{ReleaseNotes: "{{.Unknown}}"}}}
+
+	_, err := releaseNotes(Event{}, meta)
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func Test_Notifier_Sends_Payload_Template(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	received := make(chan string, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer webhook.Close()
+	notifier := NewNotifier(webhook.URL, map[string]string{}, nil)
+	meta := &Metadata{Config: Config{Templates: &Templates{Payload: `{"release":"{{.Project}}@{{.Version}}"}`}}}
+
+	err := notifier.Send(Event{Project: "p1", Version: "2.0"}, meta)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(<-received).To(Equal(`{"release":"p1@2.0"}`))
+}
+
+func Test_Release_Notes_With_Handler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0", "p1"))
+	version.now = func() time.Time { return time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC) }
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/releasenotes/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/project/p1/meta", strings.NewReader(`{"templates":{"releaseNotes":"{{.Project"}}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/project/p1/meta", strings.NewReader(`{"templates":{"releaseNotes":"Release {{.Version}} on {{.Time.Format \"2006-01-02\"}}"}}`))
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/minor/p1", nil)
+	router.ServeHTTP(res, req)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/releasenotes/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("Release 1.1 on 2024-05-01"))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//VersionAt returns the version the given project had at the time from its history and its epoch, "" if it didn't exist yet
+func (v *Version) VersionAt(project string, at time.Time) (string, int, error) {
+	history, err := v.History(project)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// a compacted history starts with a change of a version, which was current before
+	version, epoch := "", 0
+	if len(history) > 0 {
+		version, epoch = history[0].Previous, history[0].Epoch
+		if history[0].Element == epochElement && epoch > 0 {
+			epoch--
+		}
+	}
+	for _, entry := range history {
+		if entry.Time.After(at) {
+			break
+		}
+		version, epoch = entry.Version, entry.Epoch
+	}
+
+	return version, epoch, nil
+}
+
+//versionAt responds with the version of the project at the time of the at query parameter
+func (handler *Handler) versionAt(context *gin.Context, service *Version, at string) {
+	when, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusBadRequest, errors.Errorf("%v is not a valid time, expected RFC 3339 like 2024-05-01T12:00:00Z", at))
+		return
+	}
+
+	version, epoch, err := service.VersionAt(context.Param("project"), when)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if version == "" {
+		_ = context.AbortWithError(http.StatusNotFound, errors.Errorf("No version of project %v at %v", projectKey(context), at))
+		return
+	}
+
+	// the version is shown in the epoch it had back then
+	context.String(http.StatusOK, "%s", service.displayInEpoch(context.Param("project"), version, epoch))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Get_Version_At_Time(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	version := NewVersion(adapter.NewMock("", ""))
+	version.now = func() time.Time { return now }
+	_, _ = version.SetVersion("p1", "1.0.0")
+	now = now.Add(time.Hour)
+	_, _ = version.BumpMinor("p1")
+	router := NewHandler(version, nil).GetRouter()
+
+	for at, expected := range map[string]string{"2024-05-01T12:00:00Z": "1.0.0", "2024-05-01T12:59:59Z": "1.0.0", "2024-05-01T15:00:00+02:00": "1.1.0", "2025-01-01T00:00:00Z": "1.1.0"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/version/p1?at="+url.QueryEscape(at), nil)
+		router.ServeHTTP(res, req)
+
+		Ω.Expect(res.Code).To(Equal(http.StatusOK), at)
+		Ω.Expect(res.Body.String()).To(Equal(expected), at)
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/p1?at=2024-05-01T11:00:00Z", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusNotFound))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1?at=yesterday", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusBadRequest))
+}
+
+func Test_Version_At_Time_Before_Compacted_History(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	version := NewVersion(adapter.NewMock("", ""))
+	version.now = func() time.Time { return now }
+	_, _ = version.SetVersion("p1", "1.0.0")
+	now = now.Add(time.Hour)
+	_, _ = version.BumpMinor("p1")
+	_, _ = version.CompactHistory(HistoryRetention{MaxEntries: 1}, nil)
+
+	before, _, err := version.VersionAt("p1", now.Add(-time.Minute))
+
+	Ω.Expect(err).NotTo(HaveOccurred())
+	Ω.Expect(before).To(Equal("1.0.0"))
+}
+
+func Test_Version_At_Time_Of_Previous_Epoch(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	version := NewVersion(adapter.NewMock("", ""))
+	version.now = func() time.Time { return now }
+	_, _ = version.SetVersion("p1", "1.0.0")
+	_ = version.SetMetadata("p1", &Metadata{Config: Config{Prefix: "v"}})
+	now = now.Add(time.Hour)
+	_, _ = version.StartEpoch("p1", "0.1.0")
+	router := NewHandler(version, nil).GetRouter()
+
+	for at, expected := range map[string]string{"2024-05-01T12:00:00Z": "v1.0.0", "2024-05-01T13:00:00Z": "v1!0.1.0"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/version/p1?at="+url.QueryEscape(at), nil)
+		router.ServeHTTP(res, req)
+
+		Ω.Expect(res.Body.String()).To(Equal(expected), at)
+	}
+}
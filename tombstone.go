@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	tombstoneDocument = "tombstone"
+	historyDocument   = "history"
+	//deleteElement is the element of replicated changes deleting a project
+	deleteElement = "delete"
+
+	//DefaultDeletionRetention is the time deleted projects are kept, before they are purged
+	DefaultDeletionRetention = 30 * 24 * time.Hour
+)
+
+//ErrDeleted is returned when changing a deleted project, which has to be restored or purged first
+var ErrDeleted = newError(ErrFrozen, "project is deleted")
+
+//Tombstone records the version of a deleted project, which is kept until the retention expired
+type Tombstone struct {
+	Version string    `json:"version"`
+	Deleted time.Time `json:"deleted"`
+	Expires time.Time `json:"expires"`
+	Actor   string    `json:"actor,omitempty"`
+}
+
+//RetainDeleted keeps deleted projects for the retention, before they can be purged
+func (v *Version) RetainDeleted(retention time.Duration) {
+	v.retention = retention
+}
+
+//Delete removes the version of the given project, its documents and history are kept with a tombstone until purged
+func (v *Version) Delete(project string) (*Tombstone, error) {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	version, err := v.readVersion(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot delete project %v", project)
+	}
+	if version == "" {
+		return nil, errors.Wrapf(ErrUnknownProject, "Cannot delete project %v", project)
+	}
+
+	return v.bury(project, version)
+}
+
+//bury replaces the version of the given project by a tombstone, the project must be locked
+func (v *Version) bury(project string, version string) (*Tombstone, error) {
+	now := v.now().UTC()
+	tombstone := &Tombstone{Version: version, Deleted: now, Expires: now.Add(v.retention), Actor: v.annotation.Actor}
+	document, err := json.Marshal(tombstone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot delete project %v", project)
+	}
+	// the tombstone is stored first, so a failed delete leaves a project, which can be deleted again
+	if err := v.fileProvider.StoreDocument(tombstoneDocument, project, document); err != nil {
+		return nil, errors.Wrapf(err, "Cannot delete project %v", project)
+	}
+	if err := v.fileProvider.StoreVersion(project, ""); err != nil {
+		return nil, errors.Wrapf(err, "Cannot delete project %v", project)
+	}
+
+	return tombstone, nil
+}
+
+//Tombstone returns the tombstone of the given project or nil, if it isn't deleted
+func (v *Version) Tombstone(project string) (*Tombstone, error) {
+	document, err := v.fileProvider.ReadDocument(tombstoneDocument, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot get tombstone of project %v", project)
+	}
+	if document == nil {
+		return nil, nil
+	}
+
+	tombstone := &Tombstone{}
+	if err := json.Unmarshal(document, tombstone); err != nil {
+		return nil, errors.Wrapf(err, "Cannot parse tombstone of project %v", project)
+	}
+
+	return tombstone, nil
+}
+
+//checkDeleted returns ErrDeleted, if the given project is deleted
+func (v *Version) checkDeleted(project string) error {
+	tombstone, err := v.Tombstone(project)
+	if err != nil {
+		return err
+	}
+	if tombstone != nil {
+		return errors.Wrapf(ErrDeleted, "Project %v was deleted at %v", project, tombstone.Deleted.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+//Purge permanently removes the deleted projects, whose retention expired, all deleted projects with all
+func (v *Version) Purge(all bool) ([]string, error) {
+	projects, err := v.fileProvider.ListDocuments(tombstoneDocument)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list deleted projects")
+	}
+
+	purged := []string{}
+	for _, project := range projects {
+		removed, err := v.purge(project, all)
+		if err != nil {
+			return purged, err
+		}
+		if removed {
+			purged = append(purged, project)
+		}
+	}
+
+	return purged, nil
+}
+
+func (v *Version) purge(project string, all bool) (bool, error) {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	tombstone, err := v.Tombstone(project)
+	if err != nil || tombstone == nil || (!all && v.now().Before(tombstone.Expires)) {
+		return false, err
+	}
+
+	for _, kind := range append([]string{historyDocument}, documentKinds...) {
+		if err := v.fileProvider.DeleteDocument(kind, project); err != nil {
+			return false, errors.Wrapf(err, "Cannot purge project %v", project)
+		}
+	}
+	// the tombstone goes last, so a failed purge is picked up by the next one
+	if err := v.fileProvider.DeleteDocument(tombstoneDocument, project); err != nil {
+		return false, errors.Wrapf(err, "Cannot purge project %v", project)
+	}
+
+	return true, nil
+}
+
+//OnDelete is a handler for deleting a given project, it can be restored until the retention expired
+func (handler *Handler) OnDelete(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	tombstone, err := service.Delete(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(changeStatus(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	if handler.replicator != nil {
+		handler.replicator.Enqueue(context.Param("namespace"), context.Param("project"), &HistoryEntry{Time: tombstone.Deleted, Element: deleteElement, Previous: tombstone.Version})
+	}
+	handler.changeLog(context).Infof("deleted project %v with version %v", projectKey(context), tombstone.Version)
+	context.JSON(http.StatusOK, tombstone)
+}
+
+//OnPurge is a handler for permanently removing deleted projects of all namespaces
+func (handler *Handler) OnPurge(context *gin.Context) {
+	all := context.Query("all") == "true"
+	purged, err := handler.version.Purge(all)
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	namespaces, err := handler.version.fileProvider.ListNamespaces()
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, namespace := range namespaces {
+		service, err := handler.version.Namespace(namespace)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		projects, err := service.Purge(all)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		for _, project := range projects {
+			purged = append(purged, namespace+"/"+project)
+		}
+	}
+
+	handler.auditLog().Infof("purged deleted projects %v", purged)
+	context.JSON(http.StatusOK, gin.H{"purged": purged})
+}
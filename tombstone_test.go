@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_Delete_Keeps_A_Tombstone(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.now = func() time.Time { return time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC) }
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"version": "1.0.0", "deleted": "2021-03-01T12:00:00Z", "expires": "2021-03-31T12:00:00Z"}`))
+	projects, _ := version.Projects()
+	Ω.Expect(projects).To(BeEmpty())
+
+	for _, path := range []string{"/patch/p1", "/project/p1", "/version/p1/2.0.0"} {
+		res = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(http.StatusConflict), path)
+	}
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(http.StatusNotFound))
+}
+
+func Test_Purge_Removes_Expired_Deleted_Projects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.now = func() time.Time { return now }
+	version.RetainDeleted(24 * time.Hour)
+	_, _ = version.BumpPatch("p1")
+	_, _ = version.Delete("p1")
+	team, _ := version.Namespace("team")
+	_, _ = team.SetVersion("p2", "2.0.0")
+	_, _ = team.Delete("p2")
+
+	tokens := NewTokenStore()
+	tokens.Add("admin", "admin-secret", "*", adminScope)
+	handler := NewHandler(version, nil)
+	handler.SetTokenStore(tokens)
+	router := handler.GetRouter()
+	purge := func(query string) map[string][]string {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/admin/purge"+query, nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(http.StatusOK))
+
+		result := map[string][]string{}
+		Ω.Expect(json.Unmarshal(res.Body.Bytes(), &result)).To(Succeed())
+		return result
+	}
+
+	Ω.Expect(purge("")["purged"]).To(BeEmpty())
+
+	now = now.Add(25 * time.Hour)
+	Ω.Expect(purge("")["purged"]).To(Equal([]string{"p1", "team/p2"}))
+
+	tombstone, _ := version.Tombstone("p1")
+	history, _ := version.History("p1")
+	Ω.Expect(tombstone).To(BeNil())
+	Ω.Expect(history).To(BeEmpty())
+
+	// purged projects start over
+	Ω.Expect(version.BumpPatch("p1")).To(Equal("0.0.1"))
+}
+
+func Test_Purge_All_Requires_Admin(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	_, _ = version.Delete("p1")
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/purge?all=true", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusForbidden))
+	tombstone, _ := version.Tombstone("p1")
+	Ω.Expect(tombstone).NotTo(BeNil())
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	traceParent = regexp.MustCompile("^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$")
+	b3TraceID   = regexp.MustCompile("^([0-9a-f]{16}|[0-9a-f]{32})$")
+)
+
+//SetTraceExemplars attaches the trace id of requests as exemplar to the request latency, which needs the OpenMetrics format
+func (handler *Handler) SetTraceExemplars(enabled bool) {
+	handler.traceExemplars = enabled
+}
+
+//traceID returns the trace id of a W3C traceparent or B3 header of the request, "" if it isn't traced
+func traceID(c *gin.Context) string {
+	if match := traceParent.FindStringSubmatch(c.GetHeader("traceparent")); match != nil && match[1] != "00000000000000000000000000000000" {
+		return match[1]
+	}
+	if id := c.GetHeader("X-B3-TraceId"); b3TraceID.MatchString(id) {
+		return id
+	}
+
+	return ""
+}
+
+//LatencyMiddleware observes the duration of requests per route, with the trace id as exemplar if enabled
+func (handler *Handler) LatencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		observer := requestDuration.With(prometheus.Labels{"route": route, "method": c.Request.Method, "status": strconv.Itoa(c.Writer.Status())})
+		took := time.Since(start).Seconds()
+		if id := traceID(c); handler.traceExemplars && id != "" {
+			observer.(prometheus.ExemplarObserver).ObserveWithExemplar(took, prometheus.Labels{"trace_id": id})
+			return
+		}
+		observer.Observe(took)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+)
+
+func Test_Trace_ID_Of_Request(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	traceOf := func(header string, value string) string {
+		context, _ := gin.CreateTestContext(httptest.NewRecorder())
+		context.Request, _ = http.NewRequest("GET", "/", nil)
+		context.Request.Header.Set(header, value)
+		return traceID(context)
+	}
+
+	Ω.Expect(traceOf("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+	Ω.Expect(traceOf("traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")).To(BeEmpty())
+	Ω.Expect(traceOf("X-B3-TraceId", "80f198ee56343ba8")).To(Equal("80f198ee56343ba8"))
+	Ω.Expect(traceOf("traceparent", "garbage")).To(BeEmpty())
+}
+
+func Test_Latency_Exemplars_In_OpenMetrics(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "traced")), nil)
+	handler.SetTraceExemplars(true)
+	router := handler.GetRouter()
+
+	bump := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/traced", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(bump, req)
+	metrics := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	router.ServeHTTP(metrics, req)
+
+	Ω.Expect(metrics.Header().Get("Content-Type")).To(HavePrefix("application/openmetrics-text"))
+	Ω.Expect(metrics.Body.String()).To(MatchRegexp(`vbump_request_duration_seconds_bucket\{method="POST",route="/patch/:project",status="200",le="[^"]+"\} [0-9]+ # \{trace_id="4bf92f3577b34da6a3ce929d0e0e4736"\}`))
+}
+
+func Test_Latency_Without_Exemplars(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+
+	metrics := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	router.ServeHTTP(metrics, req)
+
+	Ω.Expect(metrics.Header().Get("Content-Type")).To(HavePrefix("text/plain"))
+}
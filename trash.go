@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+//TrashedProject is a deleted project, which can still be restored
+type TrashedProject struct {
+	Name string `json:"name"`
+	Tombstone
+}
+
+//Trash returns the deleted projects, which were not purged yet
+func (v *Version) Trash() ([]TrashedProject, error) {
+	projects, err := v.fileProvider.ListDocuments(tombstoneDocument)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list deleted projects")
+	}
+
+	trashed := []TrashedProject{}
+	for _, project := range projects {
+		tombstone, err := v.Tombstone(project)
+		if err != nil {
+			return nil, err
+		}
+		if tombstone != nil {
+			trashed = append(trashed, TrashedProject{Name: project, Tombstone: *tombstone})
+		}
+	}
+
+	return trashed, nil
+}
+
+//Restore brings back the version of the deleted project and removes its tombstone
+func (v *Version) Restore(project string) (string, error) {
+	unlock, err := v.locks.Lock(v.namespace + "/" + project)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	tombstone, err := v.Tombstone(project)
+	if err != nil {
+		return "", errors.Wrapf(err, "Cannot restore project %v", project)
+	}
+	if tombstone == nil {
+		return "", errors.Wrapf(ErrUnknownProject, "Cannot restore project %v, it is not deleted", project)
+	}
+
+	if err := v.fileProvider.StoreVersion(project, tombstone.Version); err != nil {
+		return "", errors.Wrapf(err, "Cannot restore project %v", project)
+	}
+	if err := v.fileProvider.DeleteDocument(tombstoneDocument, project); err != nil {
+		return "", errors.Wrapf(err, "Cannot restore project %v", project)
+	}
+
+	return tombstone.Version, nil
+}
+
+//OnListTrash is a handler for listing the deleted projects, which can still be restored
+func (handler *Handler) OnListTrash(context *gin.Context) {
+	service, ok := handler.versionFor(context)
+	if !ok {
+		return
+	}
+
+	trashed, err := service.Trash()
+	if err != nil {
+		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, trashed)
+}
+
+//OnRestore is a handler for restoring a deleted project with its version, documents and history
+func (handler *Handler) OnRestore(context *gin.Context) {
+	service, ok := handler.changingVersionFor(context)
+	if !ok {
+		return
+	}
+
+	version, err := service.Restore(context.Param("project"))
+	if err != nil {
+		_ = context.AbortWithError(changeStatus(err, http.StatusInternalServerError), err)
+		return
+	}
+
+	handler.changeLog(context).Infof("restored project %v with version %v", projectKey(context), version)
+	context.String(http.StatusOK, "%s", service.Display(context.Param("project"), version))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_List_And_Restore_Trash(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	version := NewVersion(adapter.NewMock("1.0.0", "p1"))
+	version.now = func() time.Time { return time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC) }
+	_, _ = version.BumpMinor("p1")
+	Ω.Expect(version.SetMetadata("p1", &Metadata{Owner: "team-a"})).To(Succeed())
+	_, _ = version.Delete("p1")
+	router := NewHandler(version, nil).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/trash", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`[{"name": "p1", "version": "1.1.0", "deleted": "2021-03-01T12:00:00Z", "expires": "2021-03-31T12:00:00Z"}]`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/trash/p1/restore", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusOK))
+	Ω.Expect(res.Body.String()).To(Equal("1.1.0"))
+	meta, _ := version.GetMetadata("p1")
+	Ω.Expect(meta.Owner).To(Equal("team-a"))
+	trash, _ := version.Trash()
+	Ω.Expect(trash).To(BeEmpty())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.1.1"))
+}
+
+func Test_Restore_Project_Which_Is_Not_Deleted(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	router := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil).GetRouter()
+
+	for _, path := range []string{"/trash/p1/restore", "/ns/team/trash/p2/restore"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(http.StatusNotFound), path)
+	}
+}
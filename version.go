@@ -1,139 +1,310 @@
 package main
 
 import (
+	"context"
+	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"maibornwolff/vbump/adapter"
 
 	"github.com/pkg/errors"
 )
 
+var validNamespace = regexp.MustCompile("^[A-Za-z0-9][A-Za-z0-9_.-]*$")
+
+//ErrInvalidProjectName is returned for project names, which would leave the directory of their namespace
+var ErrInvalidProjectName = errors.New("invalid project name")
+
+//validateProjectName returns ErrInvalidProjectName, if the name is empty, contains a path separator or ".." or starts with a dot
+func validateProjectName(project string) error {
+	if project == "" || strings.ContainsAny(project, `/\`) || strings.Contains(project, "..") || strings.HasPrefix(project, ".") {
+		return errors.Wrapf(ErrInvalidProjectName, "%q is not a valid project name", project)
+	}
+
+	return nil
+}
+
 //Version bumps major, minor, patch part of a given project
 type Version struct {
 	fileProvider adapter.IFileProvider
+	now          func() time.Time
+	annotation   Annotation
+	ctx          context.Context
+	namespace    string
+	locks        Locker
+	slow         *slowStorage
+
+	explicitCreation bool
+	strictProjects   bool
+	outbox           bool
+	initialVersions  []InitialVersion
+	projectTemplates map[string]ProjectTemplate
+	deniedProjects   *regexp.Regexp
+	capacity         *capacity
+	retention        time.Duration
+	hookURLs         []*url.URL
+	policyAgent      *PolicyAgent
 }
 
 //NewVersion constructs new fileprovider
 func NewVersion(provider adapter.IFileProvider) *Version {
+	slow := &slowStorage{}
 	return &Version{
-		fileProvider: provider,
+		fileProvider: metered(provider, slow),
+		now:          time.Now,
+		locks:        timed(newProjectLocks(), slow),
+		slow:         slow,
+		retention:    DefaultDeletionRetention,
 	}
 }
 
-//BumpMajor bumps major version for given project
-func (v *Version) BumpMajor(project string) (string, error) {
-	currentVersion, err := v.fileProvider.ReadVersion(project)
+//Namespace returns the version service for projects isolated in the given namespace
+func (v *Version) Namespace(namespace string) (*Version, error) {
+	if !validNamespace.MatchString(namespace) {
+		return nil, errors.Errorf("%v is not a valid namespace", namespace)
+	}
+
+	provider, err := v.fileProvider.Namespace(namespace)
 	if err != nil {
-		return "", errors.Wrapf(err, "Cannot bump major version on project %v", project)
+		return nil, errors.Wrapf(err, "Cannot open namespace %v", namespace)
 	}
 
-	major, minor, patch := extractVersionParts(currentVersion)
-	newMajor := convertAndInc(major)
-	minor = resetPart(minor)
-	patch = resetPart(patch)
-	newVersion := formatVersion(newMajor, minor, patch)
-	v.fileProvider.StoreVersion(project, newVersion)
+	namespaced := *v
+	namespaced.fileProvider = provider
+	namespaced.namespace = namespace
+	return &namespaced, nil
+}
 
-	return newVersion, nil
+//BumpMajor bumps major version for given project
+func (v *Version) BumpMajor(project string) (string, error) {
+	return versionOf(v.Bump(project, "major"))
 }
 
 //BumpMinor bumps minor version for given project
 func (v *Version) BumpMinor(project string) (string, error) {
-	currentVersion, err := v.fileProvider.ReadVersion(project)
+	return versionOf(v.Bump(project, "minor"))
+}
+
+//BumpPatch bumps patch version for given project
+func (v *Version) BumpPatch(project string) (string, error) {
+	return versionOf(v.Bump(project, "patch"))
+}
+
+//Bump bumps the given element (major, minor or patch) for given project and returns the recorded change
+func (v *Version) Bump(project string, element string) (*HistoryEntry, error) {
+	return v.BumpBy(project, element, 1)
+}
+
+//BumpBy increments the given element by step for given project, limited by the policy of the project
+func (v *Version) BumpBy(project string, element string, step int) (*HistoryEntry, error) {
+	if err := v.checkStep(project, step); err != nil {
+		return nil, err
+	}
+	if err := v.checkUnreserved(project); err != nil {
+		return nil, err
+	}
+
+	scheme, err := v.schemeOf(project)
 	if err != nil {
-		return "", errors.Wrapf(err, "Cannot bump minor version on project %v", project)
+		return nil, err
+	}
+	requested := element
+	element, analysis, err := v.analyze(project, element)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot bump %v version on project %v", requested, project)
 	}
 
-	major, minor, patch := extractVersionParts(currentVersion)
-	newMinor := convertAndInc(minor)
-	major = initEmptyPartToZero(major)
-	patch = resetPart(patch)
-	newVersion := formatVersion(major, newMinor, patch)
-	err = v.fileProvider.StoreVersion(project, newVersion)
+	analyzed := v.WithAnnotation(v.annotation)
+	analyzed.annotation.Analysis = analysis
+	entry, err := analyzed.change(project, element, func(currentVersion string) (string, error) {
+		if scheme != nil {
+			return scheme.Next(SchemeRequest{Project: project, Version: currentVersion, Element: element, Step: step})
+		}
+		if err := checkStoredVersion(project, currentVersion); err != nil {
+			return "", err
+		}
+		return nextVersion(currentVersion, element, step)
+	})
 	if err != nil {
-		return "", errors.Wrapf(err, "Cannot bump minor version on project %v", project)
+		return nil, errors.Wrapf(err, "Cannot bump %v version on project %v", element, project)
 	}
 
-	return newVersion, nil
+	return entry, nil
 }
 
-//BumpPatch bumps patch version for given project
-func (v *Version) BumpPatch(project string) (string, error) {
-	currentVersion, err := v.fileProvider.ReadVersion(project)
+//SetVersion sets the current given version for the given project
+func (v *Version) SetVersion(project string, version string) (string, error) {
+	return versionOf(v.Set(project, version))
+}
+
+//Set sets the current given version for the given project and returns the recorded change
+func (v *Version) Set(project string, version string) (*HistoryEntry, error) {
+	version, err := v.parseVersion(project, version)
 	if err != nil {
-		return "", errors.Wrapf(err, "Cannot bump patch version on project %v", project)
+		return nil, err
 	}
 
-	major, minor, patch := extractVersionParts(currentVersion)
-	newPatch := convertAndInc(patch)
-	major = initEmptyPartToZero(major)
-	minor = initEmptyPartToZero(minor)
-	newVersion := formatVersion(major, minor, newPatch)
-	v.fileProvider.StoreVersion(project, newVersion)
-	err = v.fileProvider.StoreVersion(project, newVersion)
+	entry, err := v.change(project, "set", func(string) (string, error) {
+		return version, nil
+	})
 	if err != nil {
-		return "", errors.Wrapf(err, "Cannot bump patch version on project %v", project)
+		return nil, errors.Wrapf(err, "Cannot set version %v for project %v", version, project)
 	}
 
-	return newVersion, err
+	return entry, nil
 }
 
-//SetVersion sets the current given version for the given project
-func (v *Version) SetVersion(project string, version string) (string, error) {
-	isValidated := validateVersion(version)
-	if !isValidated {
-		return "", errors.Errorf("%v is not a valid version", version)
+func versionOf(entry *HistoryEntry, err error) (string, error) {
+	if err != nil {
+		return "", err
 	}
 
-	err := v.fileProvider.StoreVersion(project, version)
+	return entry.Version, nil
+}
+
+//change stores the version computed from the current one and records the change, changes of a project are serialized
+func (v *Version) change(project string, element string, next func(string) (string, error)) (*HistoryEntry, error) {
+	if err := validateProjectName(project); err != nil {
+		return nil, err
+	}
+	unlock, err := v.locks.Lock(v.namespace + "/" + project)
 	if err != nil {
-		return "", errors.Wrapf(err, "Cannot set version %v for project %v", version, project)
+		return nil, err
 	}
+	defer unlock()
 
-	return version, err
+	currentVersion, err := v.readLockedForChange(project)
+	if err != nil {
+		return nil, err
+	}
+	if currentVersion == "" && !v.mayCreate(element) {
+		return nil, ErrUnknownProject
+	}
+	if currentVersion == "" {
+		if err := v.checkProjectName(project); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.checkCapacity(project, currentVersion == ""); err != nil {
+		return nil, err
+	}
+
+	if initial := v.initialVersionOf(project); currentVersion == "" && initial != "" && startsWithInitialVersion(element) {
+		next = func(string) (string, error) { return initial, nil }
+	}
+
+	newVersion, err := next(currentVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.checkPolicy(project, element, currentVersion, newVersion)
+	if err != nil {
+		return nil, err
+	}
+	err = v.checkPolicyAgent(project, element, currentVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &HistoryEntry{
+		Time:     v.now().UTC(),
+		Element:  element,
+		Previous: currentVersion,
+		Version:  newVersion,
+		Reason:   v.annotation.Reason,
+		Actor:    v.annotation.Actor,
+		Client:   v.annotation.Client,
+		Analysis: v.annotation.Analysis,
+		Epoch:    v.epochOf(project, element),
+	}
+
+	// a timed out request must not store its change, the client retries it
+	if err := commitChange(v.ctx); err != nil {
+		return nil, errors.Wrapf(err, "Cannot store version %v of project %v", newVersion, project)
+	}
+	err = v.fileProvider.StoreVersion(project, newVersion)
+	if err != nil {
+		return nil, err
+	}
+	if v.outbox {
+		err = v.appendOutbox(project, entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = v.touch(project, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.recordHistory(project, *entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
 }
 
 //GetVersion returns current version for given project
 func (v *Version) GetVersion(project string) (string, error) {
-	version, err := v.fileProvider.ReadVersion(project)
+	version, err := v.readVersion(project)
 	if err != nil {
 		return "", errors.Wrapf(err, "Cannot get version for project %v", project)
 	}
 
+	if version != "" {
+		// tracking reads is best effort and must not fail the read itself
+		_ = v.touch(project, false)
+	}
+
 	return version, err
 }
 
-//BumpTransientPatch bumps only the patch part on given version without change any project
-func (v *Version) BumpTransientPatch(version string) (string, error) {
-	isValidated := validateVersion(version)
-	if !isValidated {
-		return "", errors.Errorf("%v is not a valid version", version)
+//Exists returns true, if the given project has a version
+func (v *Version) Exists(project string) (bool, error) {
+	if err := validateProjectName(project); err != nil {
+		return false, err
+	}
+	version, err := v.readVersion(project)
+	if err != nil {
+		return false, errors.Wrapf(err, "Cannot get version for project %v", project)
 	}
 
-	major, minor, patch := extractVersionParts(version)
-	newPatch := convertAndInc(patch)
-	major = initEmptyPartToZero(major)
-	minor = initEmptyPartToZero(minor)
-	newVersion := formatVersion(major, minor, newPatch)
+	return version != "", nil
+}
+
+//Projects returns the names of all projects with a version
+func (v *Version) Projects() ([]string, error) {
+	projects, err := v.fileProvider.ListProjects()
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list projects")
+	}
 
-	return newVersion, nil
+	return projects, nil
+}
+
+//BumpTransientPatch bumps only the patch part on given version without change any project
+func (v *Version) BumpTransientPatch(version string) (string, error) {
+	return bumpTransient(version, "patch", finalizePrerelease)
 }
 
 //BumpTransientMinor bumps only the minor part on given version without change any project
 func (v *Version) BumpTransientMinor(version string) (string, error) {
-	isValidated := validateVersion(version)
-	if !isValidated {
-		return "", errors.Errorf("%v is not a valid version", version)
-	}
+	return bumpTransient(version, "minor", finalizePrerelease)
+}
 
-	major, minor, patch := extractVersionParts(version)
-	newMinor := convertAndInc(minor)
-	major = initEmptyPartToZero(major)
-	patch = resetPart(patch)
-	newVersion := formatVersion(major, newMinor, patch)
+//BumpTransient bumps the element of the given version without changing any project, prerelease "next" counts up the prerelease instead
+func (v *Version) BumpTransient(version string, element string, prerelease string) (string, error) {
+	if !validTransientPrerelease(prerelease) {
+		return "", invalidVersionf("%v is not a valid prerelease mode, expected finalize or next", prerelease)
+	}
 
-	return newVersion, nil
+	return bumpTransient(version, element, prerelease)
 }
 
 func validateVersion(version string) bool {
@@ -143,9 +314,50 @@ func validateVersion(version string) bool {
 	return ex3.MatchString(version) || ex2.MatchString(version) || ex1.MatchString(version)
 }
 
-func convertAndInc(version string) string {
+//nextVersion increments the given element of the version by step and resets or initializes the other parts
+func nextVersion(version string, element string, step int) (string, error) {
+	prerelease := strings.HasPrefix(prereleaseOf(version), "-")
+	version = releaseOf(version)
+	major, minor, patch := extractVersionParts(version)
+	if prerelease && step == 1 && isReleaseOf(element, minor, patch) {
+		// like npm, bumping a prerelease of the next version releases it
+		return version, nil
+	}
+	switch element {
+	case "major":
+		major = convertAndInc(major, step)
+		minor = resetPart(minor)
+		patch = resetPart(patch)
+	case "minor":
+		major = initEmptyPartToZero(major)
+		minor = convertAndInc(minor, step)
+		patch = resetPart(patch)
+	case "patch":
+		major = initEmptyPartToZero(major)
+		minor = initEmptyPartToZero(minor)
+		patch = convertAndInc(patch, step)
+	default:
+		return "", invalidVersionf("%v is not a valid version element", element)
+	}
+
+	return formatVersion(major, minor, patch), nil
+}
+
+//isReleaseOf returns true, if the parts below the element are zero, so the version is the next one of the element
+func isReleaseOf(element string, minor string, patch string) bool {
+	switch element {
+	case "major":
+		return initEmptyPartToZero(minor) == "0" && initEmptyPartToZero(patch) == "0"
+	case "minor":
+		return initEmptyPartToZero(patch) == "0"
+	}
+
+	return element == "patch"
+}
+
+func convertAndInc(version string, step int) string {
 	versionToInc, _ := strconv.Atoi(version)
-	newVersion := strconv.Itoa(versionToInc + 1)
+	newVersion := strconv.Itoa(versionToInc + step)
 
 	return newVersion
 }
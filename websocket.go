@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	webSocketGUID       = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxWebSocketMessage = 64 * 1024
+
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+//wsConn is a server side websocket connection (RFC 6455) exchanging JSON text messages
+type wsConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mutex  sync.Mutex
+}
+
+//webSocketAccept returns the accept header for the key of the opening handshake
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContains(header http.Header, name string, token string) bool {
+	for _, value := range strings.Split(header.Get(name), ",") {
+		if strings.EqualFold(strings.TrimSpace(value), token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//upgradeWebSocket completes the opening handshake of a websocket and takes over the connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		return nil, errors.New("Not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, errors.New("Unsupported websocket version")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("Connection cannot be upgraded to a websocket")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot upgrade connection to a websocket")
+	}
+
+	// the deadlines of the server apply to requests, not to the long-lived websocket
+	_ = conn.SetDeadline(time.Time{})
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + webSocketAccept(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "Cannot complete websocket handshake")
+	}
+
+	return &wsConn{conn: conn, reader: rw.Reader}, nil
+}
+
+//readFrame reads a single frame, client frames must be masked
+func (ws *wsConn) readFrame() (bool, byte, []byte, error) {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(ws.reader, head); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin, opcode, masked := head[0]&0x80 != 0, head[0]&0x0f, head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(ws.reader, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(ws.reader, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if !masked {
+		return false, 0, nil, errors.New("Unmasked websocket frame from client")
+	}
+	if length > maxWebSocketMessage {
+		return false, 0, nil, errors.Errorf("Websocket message exceeds %v bytes", maxWebSocketMessage)
+	}
+
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(ws.reader, mask); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+//ReadMessage returns the next data message, answers pings and returns io.EOF after a close
+func (ws *wsConn) ReadMessage() ([]byte, error) {
+	message := []byte{}
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			_ = ws.writeFrame(opClose, payload)
+			return nil, io.EOF
+		case opText, opBinary, opContinuation:
+			message = append(message, payload...)
+		default:
+			return nil, errors.Errorf("Unknown websocket opcode %v", opcode)
+		}
+
+		if len(message) > maxWebSocketMessage {
+			return nil, errors.Errorf("Websocket message exceeds %v bytes", maxWebSocketMessage)
+		}
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 126, byte(length>>8), byte(length))
+	default:
+		extended := make([]byte, 8)
+		binary.BigEndian.PutUint64(extended, uint64(length))
+		frame = append(append(frame, 127), extended...)
+	}
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	_, err := ws.conn.Write(append(frame, payload...))
+	return err
+}
+
+//WriteJSON sends the value as JSON text message, it is safe for concurrent use
+func (ws *wsConn) WriteJSON(value interface{}) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "Cannot encode websocket message")
+	}
+
+	return ws.writeFrame(opText, payload)
+}
+
+//Close closes the connection
+func (ws *wsConn) Close() error {
+	return ws.conn.Close()
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+//WSCommand is a command of a websocket client: subscribe, unsubscribe, get or bump
+type WSCommand struct {
+	ID        string `json:"id,omitempty"`
+	Command   string `json:"command"`
+	Namespace string `json:"namespace,omitempty"`
+	Project   string `json:"project,omitempty"`
+	Element   string `json:"element,omitempty"`
+}
+
+//WSReply answers a command of a websocket client
+type WSReply struct {
+	ID      string `json:"id,omitempty"`
+	Status  int    `json:"status"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+//WSEvent sends a change to subscribed websocket clients
+type WSEvent struct {
+	Event Event `json:"event"`
+}
+
+//wsSubscriptions are the projects a websocket client subscribed to, "*" subscribes all projects of a namespace
+type wsSubscriptions struct {
+	sync.Mutex
+	keys map[string]bool
+}
+
+func (subscriptions *wsSubscriptions) set(namespace string, project string, subscribed bool) {
+	subscriptions.Lock()
+	defer subscriptions.Unlock()
+
+	if project == "" {
+		project = "*"
+	}
+	if subscribed {
+		subscriptions.keys[namespace+"/"+project] = true
+	} else {
+		delete(subscriptions.keys, namespace+"/"+project)
+	}
+}
+
+func (subscriptions *wsSubscriptions) matches(event Event) bool {
+	subscriptions.Lock()
+	defer subscriptions.Unlock()
+
+	return subscriptions.keys[event.Namespace+"/"+event.Project] || subscriptions.keys[event.Namespace+"/*"]
+}
+
+//OnWebSocket returns the handler for websocket clients, commands are served by the router like the http api
+func (handler *Handler) OnWebSocket(router http.Handler) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		ws, err := upgradeWebSocket(context.Writer, context.Request)
+		if err != nil {
+			_ = context.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		defer ws.Close()
+
+		events := handler.events.Subscribe()
+		defer handler.events.Unsubscribe(events)
+		subscriptions := &wsSubscriptions{keys: map[string]bool{}}
+		go func() {
+			for event := range events {
+				if subscriptions.matches(event) {
+					_ = ws.WriteJSON(WSEvent{Event: event})
+				}
+			}
+		}()
+
+		for {
+			message, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			command := WSCommand{}
+			if err := json.Unmarshal(message, &command); err != nil {
+				_ = ws.WriteJSON(WSReply{Status: http.StatusBadRequest, Error: "Invalid command: " + err.Error()})
+				continue
+			}
+			if err := ws.WriteJSON(handler.wsCommand(context, router, subscriptions, command)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (handler *Handler) wsCommand(context *gin.Context, router http.Handler, subscriptions *wsSubscriptions, command WSCommand) WSReply {
+	prefix := ""
+	if command.Namespace != "" {
+		prefix = "/ns/" + url.PathEscape(command.Namespace)
+	}
+
+	switch command.Command {
+	case "subscribe", "unsubscribe":
+		if token, ok := context.Value(tokenKey).(*Token); ok && !token.AllowsNamespace(command.Namespace) {
+			return WSReply{ID: command.ID, Status: http.StatusForbidden, Error: "Token " + token.Name + " is not allowed to access namespace " + command.Namespace}
+		}
+		subscriptions.set(command.Namespace, handler.projectName(command.Project), command.Command == "subscribe")
+		return WSReply{ID: command.ID, Status: http.StatusOK}
+	case "get":
+		return handler.wsDispatch(context, router, command.ID, http.MethodGet, prefix+"/version/"+url.PathEscape(command.Project))
+	case "bump":
+		// the element becomes part of the path, so it must not select another route
+		if command.Element != "major" && command.Element != "minor" && command.Element != "patch" {
+			return WSReply{ID: command.ID, Status: http.StatusBadRequest, Error: command.Element + " is not a valid version element"}
+		}
+		return handler.wsDispatch(context, router, command.ID, http.MethodPost, prefix+"/"+url.PathEscape(command.Element)+"/"+url.PathEscape(command.Project))
+	}
+
+	return WSReply{ID: command.ID, Status: http.StatusBadRequest, Error: "Unknown command " + command.Command}
+}
+
+//wsDispatch serves a command as request by the router with the credentials of the websocket client
+func (handler *Handler) wsDispatch(context *gin.Context, router http.Handler, id string, method string, path string) WSReply {
+	request, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		return WSReply{ID: id, Status: http.StatusBadRequest, Error: err.Error()}
+	}
+	for _, header := range []string{"Authorization", handler.clientHeader} {
+		if value := context.GetHeader(header); header != "" && value != "" {
+			request.Header.Set(header, value)
+		}
+	}
+	request.RemoteAddr = context.Request.RemoteAddr
+	request.TLS = context.Request.TLS
+
+	response := &timeoutWriter{header: http.Header{}}
+	router.ServeHTTP(response, request)
+	if response.code == 0 {
+		response.code = http.StatusOK
+	}
+
+	body := strings.TrimSpace(response.body.String())
+	if response.code >= http.StatusBadRequest {
+		if body == "" {
+			body = http.StatusText(response.code)
+		}
+		return WSReply{ID: id, Status: response.code, Error: body}
+	}
+
+	return WSReply{ID: id, Status: response.code, Version: body}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+
+	. "github.com/onsi/gomega"
+)
+
+type wsTestClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server, headers map[string]string) *wsTestClient {
+	Ω := NewGomegaWithT(t)
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	Ω.Expect(err).To(BeNil())
+
+	handshake := "GET /ws HTTP/1.1\r\nHost: vbump\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	for name, value := range headers {
+		handshake += name + ": " + value + "\r\n"
+	}
+	_, _ = conn.Write([]byte(handshake + "\r\n"))
+
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, nil)
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(res.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+	Ω.Expect(res.Header.Get("Sec-WebSocket-Accept")).To(Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo="))
+
+	return &wsTestClient{conn: conn, reader: reader}
+}
+
+func (client *wsTestClient) send(text string) {
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x81, 0x80 | byte(len(text))}
+	frame = append(frame, mask...)
+	for i := 0; i < len(text); i++ {
+		frame = append(frame, text[i]^mask[i%4])
+	}
+	_, _ = client.conn.Write(frame)
+}
+
+func (client *wsTestClient) receive(value interface{}) {
+	_ = client.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	head := make([]byte, 2)
+	_, _ = client.reader.Read(head[:1])
+	_, _ = client.reader.Read(head[1:])
+	length := int(head[1] & 0x7f)
+	if length == 126 {
+		extended := make([]byte, 2)
+		_, _ = client.reader.Read(extended[:1])
+		_, _ = client.reader.Read(extended[1:])
+		length = int(extended[0])<<8 | int(extended[1])
+	}
+	payload := make([]byte, length)
+	for read := 0; read < length; {
+		n, err := client.reader.Read(payload[read:])
+		if err != nil {
+			break
+		}
+		read += n
+	}
+	_ = json.Unmarshal(payload, value)
+}
+
+func Test_WebSocket_Commands_And_Events(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	datadir, _ := ioutil.TempDir("", "vbump")
+	defer os.RemoveAll(datadir)
+	handler := NewHandler(NewVersion(adapter.New(datadir)), nil)
+	server := httptest.NewServer(handler.GetRouter())
+	defer server.Close()
+	client := dialWebSocket(t, server, map[string]string{"X-Vbump-Client": "dashboard"})
+	defer client.conn.Close()
+
+	client.send(`{"id": "1", "command": "subscribe", "project": "p1"}`)
+	subscribed := WSReply{}
+	client.receive(&subscribed)
+	Ω.Expect(subscribed).To(Equal(WSReply{ID: "1", Status: 200}))
+
+	client.send(`{"id": "2", "command": "bump", "project": "p1", "element": "minor"}`)
+	first, second := map[string]interface{}{}, map[string]interface{}{}
+	client.receive(&first)
+	client.receive(&second)
+	messages := []map[string]interface{}{first, second}
+	Ω.Expect(messages).To(ContainElement(HaveKeyWithValue("version", "0.1")))
+	Ω.Expect(messages).To(ContainElement(HaveKey("event")))
+
+	client.send(`{"id": "3", "command": "get", "project": "p1"}`)
+	got := WSReply{}
+	client.receive(&got)
+	Ω.Expect(got).To(Equal(WSReply{ID: "3", Status: 200, Version: "0.1"}))
+
+	client.send(`{"id": "4", "command": "bump", "project": "p1", "element": "huge"}`)
+	failed := WSReply{}
+	client.receive(&failed)
+	Ω.Expect(failed.Status).To(Equal(http.StatusBadRequest))
+
+	client.send(`{"id": "4a", "command": "bump", "project": "p1", "element": "archive"}`)
+	rejected := WSReply{}
+	client.receive(&rejected)
+	Ω.Expect(rejected.Status).To(Equal(http.StatusBadRequest))
+	archived, _ := handler.version.IsArchived("p1")
+	Ω.Expect(archived).To(BeFalse())
+
+	client.send(`{"id": "5", "command": "delete"}`)
+	unknown := WSReply{}
+	client.receive(&unknown)
+	Ω.Expect(unknown.Status).To(Equal(http.StatusBadRequest))
+}
+
+func Test_WebSocket_Requires_Handshake(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+	handler := NewHandler(NewVersion(adapter.NewMock("1.0.0", "p1")), nil)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/ws", nil)
+	handler.GetRouter().ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(http.StatusBadRequest))
+	Ω.Expect(webSocketAccept("dGhlIHNhbXBsZSBub25jZQ==")).To(Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo="))
+}